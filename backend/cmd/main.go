@@ -4,18 +4,25 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/complynx/rpssl4bu/backend/pkg"
 	gameapi "github.com/complynx/rpssl4bu/backend/pkg/GameAPI"
 	"github.com/complynx/rpssl4bu/backend/pkg/game"
+	"github.com/complynx/rpssl4bu/backend/pkg/matchmaking"
+	"github.com/complynx/rpssl4bu/backend/pkg/notify"
 	p2pgame "github.com/complynx/rpssl4bu/backend/pkg/p2p_game"
 	"github.com/complynx/rpssl4bu/backend/pkg/random"
+	"github.com/complynx/rpssl4bu/backend/pkg/retention"
 	"github.com/complynx/rpssl4bu/backend/pkg/server"
+	"github.com/complynx/rpssl4bu/backend/pkg/stats"
 	"github.com/complynx/rpssl4bu/backend/pkg/storage"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -51,17 +58,53 @@ func getLogger(logLevel, logType *string) *zap.Logger {
 
 var defaultAddr = ":8080"
 
+// scoreHistory is the number of the last scoreboard changes kept for resuming streams
+const scoreHistory = 1000
+
+// command line arguments of the server
+var (
+	rngAddr            = flag.String("rng", "", "address of the random number provider")
+	addr               = flag.String("addr", defaultAddr, "address and port of the server")
+	logLevel           = flag.String("log-level", "info", "log level (debug, info, warn, error, dpanic, panic, fatal)")
+	logType            = flag.String("log-type", "text", "log output type (text or json)")
+	storageURI         = flag.String("storage", "simple", "scores storage (simple, sqlite:///path/to/rpssl.db or eventlog:///path/to/dir)")
+	userHeader         = flag.String("user-header", "", "trusted request header with authenticated user name, set by a reverse proxy")
+	retentionAge       = flag.Duration("retention-max-age", 0, "roll up game records older than this into daily aggregates, e.g. 720h (0 keeps them forever)")
+	retentionCount     = flag.Int("retention-max-per-owner", 0, "roll up all but this many newest game records of every owner (0 keeps them all)")
+	retentionInterval  = flag.Duration("retention-interval", time.Hour, "how often to apply the retention policy")
+	capacity           = flag.Int("scores-capacity", 10, "number of the last scores of every player kept by the in-memory storage")
+	maxOwners          = flag.Int("scores-max-players", storage.DefaultMaxOwners, "number of the players whose scores and statistics are kept in memory, the longest idle ones are dropped first (0 keeps them all)")
+	undoWindow         = flag.Duration("undo-window", storage.DefaultUndoWindow, "how long cleared scores can be restored (0 removes them right away)")
+	maxRecords         = flag.Int("eventlog-max-records", storage.DefaultMaxRecords, "number of the newest records kept by the event log (0 keeps them all)")
+	pingInterval       = flag.Duration("ws-ping-interval", gameapi.DefaultPingInterval, "how often to ping the P2P WebSockets")
+	pongTimeout        = flag.Duration("ws-pong-timeout", gameapi.DefaultPongTimeout, "drop the P2P WebSockets silent for this long, must be longer than the ping interval")
+	inviteSecret       = flag.String("invite-secret", "", "secret signing the invites to the P2P games, a random one by default, which is lost on restart")
+	corsOrigins        = flag.String("cors-origins", "", "comma-separated origins allowed to send the cookies to the API, e.g. https://complynx.net")
+	adminToken         = flag.String("admin-token", "", "bearer token of the operators for the admin API, which is off without it")
+	drainTimeout       = flag.Duration("drain-timeout", 20*time.Second, "how long the shutdown waits for the P2P rounds being played to end")
+	matchmakingTimeout = flag.Duration("matchmaking-timeout", matchmaking.DefaultTimeout, "how long a player waits for an opponent in the matchmaking")
+)
+
 func main() {
-	// Parse command line arguments
-	rngAddr := flag.String("rng", "", "address of the random number provider")
-	addr := flag.String("addr", defaultAddr, "address and port of the server")
-	logLevel := flag.String("log-level", "info", "log level (debug, info, warn, error, dpanic, panic, fatal)")
-	logType := flag.String("log-type", "text", "log output type (text or json)")
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "export", "import":
+			os.Exit(runTransfer(os.Args[1], os.Args[2:]))
+		}
+	}
 	flag.Parse()
 
 	logger := getLogger(logLevel, logType)
-	defer logger.Sync()
+	if err := run(logger); err != nil {
+		logger.Error("Server failed", zap.Error(err))
+		logger.Sync()
+		os.Exit(1)
+	}
+	logger.Sync()
+}
 
+// run serves the API until SIGINT or SIGTERM, the storage is closed on return
+func run(logger *zap.Logger) error {
 	// Create game
 	var rng pkg.RandomProvider
 	if rngAddr == nil || *rngAddr == "" {
@@ -71,25 +114,97 @@ func main() {
 	}
 	gameEngine := game.NewGame(rng)
 
-	storage := storage.NewSimple(10)
+	if *pingInterval <= 0 || *pongTimeout <= *pingInterval {
+		return fmt.Errorf("pong timeout %s must be longer than the positive ping interval %s", *pongTimeout, *pingInterval)
+	}
+	if *capacity < 1 {
+		return fmt.Errorf("scores capacity %d must be positive", *capacity)
+	}
+	storage, err := storage.New(*storageURI, *capacity,
+		storage.WithUndoWindow(*undoWindow),
+		storage.WithMaxRecords(*maxRecords),
+		storage.WithMaxOwners(*maxOwners),
+		storage.WithLogger(logger.Named("Storage")),
+	)
+	if err != nil {
+		return fmt.Errorf("create storage: %w", err)
+	}
+	if closer, ok := storage.(io.Closer); ok {
+		defer closer.Close()
+	}
+	tracker, err := stats.NewTracker(context.Background(), storage, stats.WithMaxOwners(*maxOwners))
+	if err != nil {
+		return fmt.Errorf("load statistics: %w", err)
+	}
+
+	var scheduler pkg.Scheduler
+	policy := types.RetentionPolicy{MaxAge: *retentionAge, MaxPerOwner: *retentionCount}
+	if policy != (types.RetentionPolicy{}) {
+		if _, ok := storage.(pkg.Compactor); !ok {
+			return fmt.Errorf("storage %s doesn't support retention", *storageURI)
+		}
+		// through the tracker, so that statistics aren't rebuilt during compaction
+		scheduler = retention.StartScheduler(tracker.(pkg.Compactor), policy, *retentionInterval, logger.Named("Retention"))
+	}
+
+	broker := notify.NewBroker(tracker, tracker, scoreHistory)
 
-	p2pfactory := p2pgame.NewGameFactory(rng, logger.Named("P2P"))
+	// the ratings and the games are kept only by the storages supporting them
+	ratings, _ := storage.(pkg.RatingStorage)
+	p2pOpts := []p2pgame.Option{p2pgame.WithRatings(ratings)}
+	store, persistent := storage.(pkg.GameStore)
+	if persistent {
+		p2pOpts = append(p2pOpts, p2pgame.WithGameStore(store))
+	}
+	p2pfactory := p2pgame.NewGameFactory(rng, broker, logger.Named("P2P"), p2pOpts...)
+	if _, err := p2pfactory.Restore(context.Background()); err != nil {
+		logger.Error("Failed to restore P2P games", zap.Error(err))
+	}
+	if persistent && *inviteSecret == "" {
+		logger.Warn("Invites to the restored P2P games need the same --invite-secret after a restart")
+	}
 
 	// Create API
-	api := gameapi.NewGameAPI(gameEngine, p2pfactory, storage, logger.Named("GameAPI"))
+	api := gameapi.NewGameAPI(gameEngine, p2pfactory, broker, logger.Named("GameAPI"),
+		gameapi.WithUserHeader(*userHeader),
+		gameapi.WithInviteKey([]byte(*inviteSecret)),
+		gameapi.WithAdminToken(*adminToken),
+		gameapi.WithStats(tracker),
+		gameapi.WithNotifier(broker),
+		gameapi.WithHeartbeat(*pingInterval, *pongTimeout),
+		gameapi.WithRatings(ratings),
+		gameapi.WithMatchmaker(matchmaking.NewQueue(p2pfactory, *matchmakingTimeout, logger.Named("Matchmaking"),
+			matchmaking.WithRatings(ratings),
+		)),
+	)
 
 	if addr == nil {
 		addr = &defaultAddr
 	}
-	srv := server.StartHTTPServer(*addr, api, logger.Named("server"))
+	var allowedOrigins []string
+	if *corsOrigins != "" {
+		allowedOrigins = strings.Split(*corsOrigins, ",")
+	}
+	srv := server.StartHTTPServer(*addr, api, logger.Named("server"), allowedOrigins...)
 
 	// Wait for SIGINT or SIGTERM
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	<-sigCh
 
+	// Let the P2P rounds end before stopping the games
+	logger.Info("Shutting down, draining P2P games", zap.Duration("timeout", *drainTimeout))
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), *drainTimeout)
+	p2pfactory.Drain(drainCtx)
+	cancelDrain()
+
 	// Gracefully shutdown the server
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
+	api.Shutdown(ctx)
 	srv.Shutdown(ctx)
+	if scheduler != nil {
+		scheduler.Stop(ctx)
+	}
+	return nil
 }
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/export"
+	"github.com/complynx/rpssl4bu/backend/pkg/storage"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+)
+
+// runTransfer runs the export or import command with its own flags and returns the exit code.
+// Together they migrate game records between storages:
+//
+//	rpssl export --storage sqlite://old.db | rpssl import --storage sqlite://new.db
+func runTransfer(command string, args []string) int {
+	flags := flag.NewFlagSet("rpssl "+command, flag.ExitOnError)
+	storageURI := flags.String("storage", "", "scores storage (sqlite:///path/to/rpssl.db or eventlog:///path/to/dir)")
+	formatName := flags.String("format", "ndjson", "file format (csv, ndjson or parquet)")
+	file := flags.String("file", "-", "file to export to or import from, - for standard output or input")
+	ownerName := flags.String("owner", "", "export only records of the owner, or import all the records to it (session:<id> or user:<name>)")
+	maxRecords := flags.Int("eventlog-max-records", storage.DefaultMaxRecords, "number of the newest records kept by the event log (0 keeps them all)")
+	flags.Parse(args)
+
+	format, err := export.ParseFormat(*formatName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	owner, err := types.OwnerFromString(*ownerName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if *storageURI == "" {
+		fmt.Fprintln(os.Stderr, "--storage is required")
+		return 2
+	}
+
+	s, err := storage.New(*storageURI, 10, storage.WithMaxRecords(*maxRecords))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open storage: %v\n", err)
+		return 1
+	}
+	if closer, ok := s.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	ctx := context.Background()
+	var n int
+	if command == "export" {
+		out := os.Stdout
+		if *file != "-" {
+			if out, err = os.Create(*file); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to create file: %v\n", err)
+				return 1
+			}
+			defer out.Close()
+		}
+		var w export.Writer
+		if w, err = export.NewWriter(out, format); err == nil {
+			n, err = export.Export(ctx, s, owner, w)
+		}
+	} else {
+		in := os.Stdin
+		if *file != "-" {
+			if in, err = os.Open(*file); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to open file: %v\n", err)
+				return 1
+			}
+			defer in.Close()
+		}
+		var r export.Reader
+		if r, err = export.NewReader(in, format); err == nil {
+			n, err = export.Import(ctx, s, r, owner)
+			r.Close()
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to %s: %v\n", command, err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "%sed %d records\n", command, n)
+	return 0
+}
@@ -0,0 +1,159 @@
+package gameapi
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/complynx/rpssl4bu/backend/pkg"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// tapSide names the admin taps in the logs and their hello
+const tapSide = "tap"
+
+// admin tells if the request carries the admin token, otherwise it writes the error.
+// The admin API is hidden if there's no token.
+func (a *gameAPI) admin(w http.ResponseWriter, r *http.Request) bool {
+	if a.adminToken == "" {
+		httpCode(w, http.StatusNotFound)
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(a.adminToken)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+		httpCode(w, http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// adminGame returns the game of the operator's request, otherwise it writes the error
+func (a *gameAPI) adminGame(w http.ResponseWriter, r *http.Request) (pkg.P2PGame, bool) {
+	if !a.admin(w, r) {
+		return nil, false
+	}
+
+	id, err := types.GameIDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		httpCode(w, http.StatusBadRequest)
+		return nil, false
+	}
+
+	game, found := a.p2pFactory.GetGame(id)
+	if !found {
+		httpCode(w, http.StatusNotFound)
+		return nil, false
+	}
+	return game, true
+}
+
+func (a *gameAPI) AdminGames(w http.ResponseWriter, r *http.Request) {
+	defer a.doRecover(w)
+
+	if !a.admin(w, r) {
+		return
+	}
+	a.marshalAndSend(a.p2pFactory.Games(), nil, w)
+}
+
+func (a *gameAPI) AdminGame(w http.ResponseWriter, r *http.Request) {
+	defer a.doRecover(w)
+
+	game, ok := a.adminGame(w, r)
+	if !ok {
+		return
+	}
+	a.marshalAndSend(game.Status(), nil, w)
+}
+
+func (a *gameAPI) AdminEndGame(w http.ResponseWriter, r *http.Request) {
+	defer a.doRecover(w)
+
+	game, ok := a.adminGame(w, r)
+	if !ok {
+		return
+	}
+	game.End()
+	a.log.Info("game ended by an operator", zap.Any("game_id", game.GetID()))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *gameAPI) AdminKick(w http.ResponseWriter, r *http.Request) {
+	defer a.doRecover(w)
+
+	game, ok := a.adminGame(w, r)
+	if !ok {
+		return
+	}
+	a.kick(w, r, game, "operator")
+}
+
+type noticeRequest struct {
+	Text string `json:"text"`
+}
+
+type noticeResponse struct {
+	// Games is the number of the games the notice was sent to
+	Games int `json:"games"`
+}
+
+func (a *gameAPI) AdminNotice(w http.ResponseWriter, r *http.Request) {
+	defer a.doRecover(w)
+
+	var req noticeRequest
+	var games int
+	var err error
+	if chi.URLParam(r, "id") != "" {
+		game, ok := a.adminGame(w, r)
+		if !ok {
+			return
+		}
+		if err = json.NewDecoder(r.Body).Decode(&req); err == nil {
+			err = game.Notice(req.Text)
+			games = 1
+		}
+	} else {
+		if !a.admin(w, r) {
+			return
+		}
+		if err = json.NewDecoder(r.Body).Decode(&req); err == nil {
+			games, err = a.p2pFactory.Notice(req.Text)
+		}
+	}
+	// the request is malformed or the text is bad
+	if err != nil {
+		httpCode(w, http.StatusBadRequest)
+		return
+	}
+	a.log.Info("notice sent", zap.Int("games", games))
+	a.marshalAndSend(noticeResponse{Games: games}, nil, w)
+}
+
+func (a *gameAPI) AdminTap(w http.ResponseWriter, r *http.Request) {
+	defer a.doRecover(w)
+
+	game, ok := a.adminGame(w, r)
+	if !ok {
+		return
+	}
+	log := a.log.With(
+		zap.Any("game_id", game.GetID()),
+		zap.Any("side", tapSide),
+	)
+
+	ch := game.Tap()
+	defer game.Untap(ch)
+
+	conn, err := a.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		a.sendErr(err, w, http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+
+	a.watch(newP2PConn(conn, tapSide, ""), ch, log)
+}
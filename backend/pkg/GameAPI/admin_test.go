@@ -0,0 +1,153 @@
+package gameapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	p2pgame "github.com/complynx/rpssl4bu/backend/pkg/p2p_game"
+	"github.com/complynx/rpssl4bu/backend/pkg/random"
+	"github.com/complynx/rpssl4bu/backend/pkg/storage"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestAdminAuth(t *testing.T) {
+	api := NewGameAPI(nil, nil, nil, zap.NewNop())
+	w := httptest.NewRecorder()
+	api.AdminGames(w, httptest.NewRequest(http.MethodGet, "/admin/games", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code, "admin API is off")
+
+	api = NewGameAPI(nil, nil, nil, zap.NewNop(), WithAdminToken("secret"))
+	for _, header := range []string{"", "secret", "Bearer nope", "Bearer secret2"} {
+		r := httptest.NewRequest(http.MethodGet, "/admin/games", nil)
+		r.Header.Set("Authorization", header)
+		w = httptest.NewRecorder()
+		api.AdminGames(w, r)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, header)
+	}
+}
+
+type tapUpdate struct {
+	State *struct {
+		LeftPlayerChoice struct {
+			Name string `json:"name"`
+		} `json:"left_player_choice"`
+	} `json:"state"`
+	Chat *types.ChatMessage `json:"chat"`
+}
+
+func TestAdmin(t *testing.T) {
+	factory := p2pgame.NewGameFactory(random.NewSimpleRandom(""), storage.NewSimple(10), zap.NewNop())
+	defer factory.StopGames(context.Background())
+	api := NewGameAPI(nil, factory, nil, zap.NewNop(), WithAdminToken("secret"))
+	router := chi.NewRouter()
+	router.Get("/admin/games", api.AdminGames)
+	router.Get("/admin/games/{id}", api.AdminGame)
+	router.Post("/admin/games/{id}/end", api.AdminEndGame)
+	router.Post("/admin/games/{id}/kick", api.AdminKick)
+	router.Post("/admin/games/{id}/notice", api.AdminNotice)
+	router.Post("/admin/notice", api.AdminNotice)
+	router.HandleFunc("/admin/games/{id}/tap", api.AdminTap)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+	header := http.Header{"Authorization": {"Bearer secret"}}
+	do := func(method, path, body string) *http.Response {
+		r, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		r.Header = header
+		resp, err := http.DefaultClient.Do(r)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	game, err := factory.CreateGame(context.Background(), types.GameSettings{}, types.GameAccess{})
+	require.NoError(t, err)
+	id := game.GetID().String()
+	_, left, _, err := game.AddPlayer("Sheldon", types.SessionOwner("sheldon"))
+	require.NoError(t, err)
+
+	resp := do(http.MethodGet, "/admin/games", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var games []types.GameStatus
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&games))
+	require.Len(t, games, 1)
+	assert.Equal(t, game.GetID(), games[0].ID)
+	require.NotNil(t, games[0].Left)
+	assert.Equal(t, types.PlayerStatus{Name: "Sheldon", Owner: types.SessionOwner("sheldon")}, *games[0].Left)
+	assert.Nil(t, games[0].Right)
+	assert.WithinDuration(t, time.Now(), games[0].LastActivity, time.Second)
+
+	resp = do(http.MethodGet, "/admin/games/"+id, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var status types.GameStatus
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+	assert.Equal(t, 1, status.Players)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/admin/games/"+types.GameID(1).String(), "").StatusCode)
+
+	// the tap sees the choice before the opponent makes theirs
+	tap, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/admin/games/"+id+"/tap", header)
+	require.NoError(t, err)
+	defer tap.Close()
+	readTap := func(match func(tapUpdate) bool) tapUpdate {
+		for {
+			tap.SetReadDeadline(time.Now().Add(time.Second))
+			var update tapUpdate
+			require.NoError(t, tap.ReadJSON(&update))
+			if match(update) {
+				return update
+			}
+		}
+	}
+	_, right, _, err := game.AddPlayer("Penny", types.Global)
+	require.NoError(t, err)
+	require.NoError(t, game.Choice(types.Rock, false))
+	readTap(func(u tapUpdate) bool { return u.State != nil && u.State.LeftPlayerChoice.Name == "rock" })
+
+	resp = do(http.MethodPost, "/admin/games/"+id+"/notice", `{"text": "Server restarts soon"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	notice := readTap(func(u tapUpdate) bool { return u.Chat != nil })
+	assert.Equal(t, types.SideServer, notice.Chat.Side)
+	assert.Equal(t, "Server restarts soon", notice.Chat.Text)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/admin/notice", `{"text": " "}`).StatusCode)
+	resp = do(http.MethodPost, "/admin/notice", `{"text": "Bye"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var sent noticeResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&sent))
+	assert.Equal(t, 1, sent.Games)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPost, "/admin/games/"+id+"/kick?side=right", "").StatusCode)
+	waitUpdatesClosed(t, right)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/admin/games/"+id+"/kick?side=right", "").StatusCode)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPost, "/admin/games/"+id+"/end", "").StatusCode)
+	waitUpdatesClosed(t, left)
+	require.Eventually(t, func() bool {
+		_, found := factory.GetGame(game.GetID())
+		return !found
+	}, time.Second, time.Millisecond)
+}
+
+// waitUpdatesClosed drains the channel until it's closed
+func waitUpdatesClosed(t *testing.T, ch <-chan types.Update) {
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("channel is not closed")
+		}
+	}
+}
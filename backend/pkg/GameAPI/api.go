@@ -1,12 +1,21 @@
 package gameapi
 
 import (
+	"crypto/rand"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"runtime"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/complynx/rpssl4bu/backend/pkg"
+	"github.com/complynx/rpssl4bu/backend/pkg/export"
 	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/go-chi/chi/v5"
 	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
 )
@@ -16,10 +25,28 @@ type gameAPI struct {
 	p2pFactory pkg.P2PGameFactory
 	log        *zap.Logger
 	upgrader   websocket.Upgrader
-	storage    pkg.Storage
+	storage    pkg.StorageV2
+	stats      pkg.StatsProvider
+	notifier   pkg.ScoreNotifier
+	matchmaker pkg.Matchmaker
+	ratings    pkg.RatingStorage
+	userHeader string
+	// inviteKey signs the invites to the P2P games
+	inviteKey []byte
+	// adminToken authenticates the operators, the admin API is off without it
+	adminToken string
+
+	// WebSocket connections, hijacked from the HTTP server which doesn't wait for them
+	connsMu  sync.Mutex
+	conns    map[*p2pConn]struct{}
+	handlers sync.WaitGroup
+	closing  bool
+
+	pingInterval time.Duration
+	pongTimeout  time.Duration
 }
 
-func NewGameAPI(game pkg.Game, p2pFactory pkg.P2PGameFactory, storage pkg.Storage, log *zap.Logger) pkg.GameAPI {
+func NewGameAPI(game pkg.Game, p2pFactory pkg.P2PGameFactory, storage pkg.StorageV2, log *zap.Logger, opts ...Option) pkg.GameAPI {
 	api := &gameAPI{
 		log:        log,
 		game:       game,
@@ -28,13 +55,28 @@ func NewGameAPI(game pkg.Game, p2pFactory pkg.P2PGameFactory, storage pkg.Storag
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024,
 		},
-		storage: storage,
+		storage:      storage,
+		conns:        make(map[*p2pConn]struct{}),
+		pingInterval: DefaultPingInterval,
+		pongTimeout:  DefaultPongTimeout,
 	}
 	api.upgrader.CheckOrigin = func(r *http.Request) bool {
 		return true
 	}
+	// the newest protocol is preferred
 	api.upgrader.Subprotocols = []string{
-		"p2p",
+		protocolV2,
+		protocolV1,
+	}
+	for _, opt := range opts {
+		opt(api)
+	}
+	if len(api.inviteKey) == 0 {
+		// the invites are valid until the restart then
+		api.inviteKey = make([]byte, 32)
+		if _, err := rand.Read(api.inviteKey); err != nil {
+			panic(fmt.Sprintf("invite key: %v", err))
+		}
 	}
 	return api
 }
@@ -102,6 +144,11 @@ func (a *gameAPI) Choice(w http.ResponseWriter, r *http.Request) {
 	a.marshalAndSend(choice, err, w)
 }
 
+const computerName = "Computer"
+
+// number of results on a scoreboard
+const scoreboardSize = 10
+
 type playResult struct {
 	Results  types.Result `json:"results"`
 	Player   int          `json:"player"`
@@ -124,10 +171,27 @@ func (a *gameAPI) Play(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	owner, err := a.ensureOwner(w, r)
+	if err != nil {
+		a.sendErr(err, w, http.StatusInternalServerError)
+		return
+	}
+
 	res, choice, err := a.game.Play(r.Context(), req.Player)
 
 	if err == nil {
-		if err := a.storage.SetLastScore(res); err != nil {
+		record := types.GameRecord{
+			Owner:          owner,
+			Time:           time.Now(),
+			Mode:           types.ModeComputer,
+			OpponentName:   computerName,
+			PlayerChoice:   req.Player,
+			OpponentChoice: choice,
+			Result:         res,
+			Strategy:       a.game.Strategy(),
+			RNGSource:      a.game.RNGSource(),
+		}
+		if err := a.storage.AddRecord(r.Context(), record); err != nil {
 			a.log.Error("Failed to save last score",
 				zap.Error(err),
 			)
@@ -154,7 +218,22 @@ func (a *gameAPI) GetScores(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	scores, err := a.storage.GetLastScores()
+	owner := types.Global
+	if r.URL.Query().Get("scope") != "global" {
+		var err error
+		owner, err = a.ensureOwner(w, r)
+		if err != nil {
+			a.sendErr(err, w, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	records, err := a.storage.GetLastRecords(r.Context(), owner, scoreboardSize)
+
+	scores := make([]types.Result, len(records))
+	for i := range records {
+		scores[i] = records[i].Result
+	}
 
 	a.marshalAndSend(scores, err, w)
 }
@@ -167,7 +246,235 @@ func (a *gameAPI) ClearScores(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	a.marshalAndSend(true, a.storage.ClearScores(), w)
+	owner, err := a.ensureOwner(w, r)
+	if err != nil {
+		a.sendErr(err, w, http.StatusInternalServerError)
+		return
+	}
+
+	a.marshalAndSend(true, a.storage.ClearRecords(r.Context(), owner), w)
+}
+
+func (a *gameAPI) RestoreScores(w http.ResponseWriter, r *http.Request) {
+	defer a.doRecover(w)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	owner, ok := a.owner(r)
+	if !ok {
+		httpCode(w, http.StatusNotFound)
+		return
+	}
+
+	tombstone, err := a.storage.RestoreRecords(r.Context(), owner)
+	if errors.Is(err, types.ErrNoTombstone) {
+		httpCode(w, http.StatusNotFound)
+		return
+	}
+	a.marshalAndSend(tombstone, err, w)
+}
+
+func (a *gameAPI) Audit(w http.ResponseWriter, r *http.Request) {
+	defer a.doRecover(w)
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := defaultHistoryLimit
+	if s := r.URL.Query().Get("limit"); s != "" {
+		var err error
+		limit, err = strconv.Atoi(s)
+		if err != nil || limit < 1 {
+			http.Error(w, fmt.Sprintf("bad limit: %q", s), http.StatusBadRequest)
+			return
+		}
+		if limit > maxHistoryLimit {
+			limit = maxHistoryLimit
+		}
+	}
+
+	owner, ok := a.owner(r)
+	if !ok {
+		a.marshalAndSend([]types.AuditEntry{}, nil, w)
+		return
+	}
+
+	entries, err := a.storage.AuditLog(r.Context(), owner, limit)
+	a.marshalAndSend(entries, err, w)
+}
+
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+)
+
+func (a *gameAPI) History(w http.ResponseWriter, r *http.Request) {
+	defer a.doRecover(w)
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query, err := parseHistoryQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.URL.Query().Get("scope") != "global" {
+		query.Owner, err = a.ensureOwner(w, r)
+		if err != nil {
+			a.sendErr(err, w, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	page, err := a.storage.History(r.Context(), query)
+	for i := range page.Records {
+		page.Records[i] = page.Records[i].Public()
+	}
+	a.marshalAndSend(page, err, w)
+}
+
+func (a *gameAPI) Stats(w http.ResponseWriter, r *http.Request) {
+	defer a.doRecover(w)
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if a.stats == nil {
+		httpCode(w, http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	if query.Has("player") {
+		stats, err := a.stats.GetPlayerStats(r.Context(), query.Get("player"))
+		a.marshalAndSend(stats, err, w)
+		return
+	}
+
+	owner := types.Global
+	if query.Get("scope") != "global" {
+		var err error
+		owner, err = a.ensureOwner(w, r)
+		if err != nil {
+			a.sendErr(err, w, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	stats, err := a.stats.GetStats(r.Context(), owner)
+	a.marshalAndSend(stats, err, w)
+}
+
+func (a *gameAPI) Leaderboard(w http.ResponseWriter, r *http.Request) {
+	defer a.doRecover(w)
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if a.ratings == nil {
+		httpCode(w, http.StatusNotFound)
+		return
+	}
+
+	limit := defaultHistoryLimit
+	if s := r.URL.Query().Get("limit"); s != "" {
+		var err error
+		limit, err = strconv.Atoi(s)
+		if err != nil || limit < 1 {
+			http.Error(w, fmt.Sprintf("bad limit: %q", s), http.StatusBadRequest)
+			return
+		}
+		if limit > maxHistoryLimit {
+			limit = maxHistoryLimit
+		}
+	}
+
+	leaderboard, err := a.ratings.Leaderboard(r.Context(), limit)
+	a.marshalAndSend(leaderboard, err, w)
+}
+
+func (a *gameAPI) Export(w http.ResponseWriter, r *http.Request) {
+	defer a.doRecover(w)
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	format, err := export.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	owner := types.Global
+	if r.URL.Query().Get("scope") != "global" {
+		owner, err = a.ensureOwner(w, r)
+		if err != nil {
+			a.sendErr(err, w, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="history.%s"`, format))
+	writer, err := export.NewWriter(w, format)
+	if err != nil {
+		a.sendErr(err, w, http.StatusInternalServerError)
+		return
+	}
+	// the response is already streaming, errors can only be logged
+	if _, err := export.Export(r.Context(), a.storage, owner, export.Public(writer)); err != nil {
+		a.log.Error("Export failed", zap.Error(err))
+	}
+}
+
+// maxImportSize limits the body of the import request
+const maxImportSize = 64 << 20
+
+func (a *gameAPI) Import(w http.ResponseWriter, r *http.Request) {
+	defer a.doRecover(w)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	format, err := export.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	owner, err := a.ensureOwner(w, r)
+	if err != nil {
+		a.sendErr(err, w, http.StatusInternalServerError)
+		return
+	}
+
+	reader, err := export.NewReader(http.MaxBytesReader(w, r.Body, maxImportSize), format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer reader.Close()
+
+	// records are always imported to the caller's own scoreboard, and P2P ones would forge the player stats
+	n, err := export.Import(r.Context(), a.storage, export.SinglePlayer(reader), owner)
+	if err != nil {
+		a.log.Warn("Import failed", zap.Int("imported", n), zap.Error(err))
+		http.Error(w, fmt.Sprintf("imported %d records: %v", n, err), http.StatusBadRequest)
+		return
+	}
+	a.marshalAndSend(map[string]int{"imported": n}, nil, w)
 }
 
 func (a *gameAPI) CreateP2P(w http.ResponseWriter, r *http.Request) {
@@ -178,7 +485,27 @@ func (a *gameAPI) CreateP2P(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	game, err := a.p2pFactory.CreateGame(r.Context())
+	settings, err := settingsFromQuery(r.URL.Query())
+	if err != nil {
+		httpCode(w, http.StatusBadRequest)
+		return
+	}
+
+	// the creator hosts the game
+	host, err := a.ensureOwner(w, r)
+	if err != nil {
+		a.sendErr(err, w, http.StatusInternalServerError)
+		return
+	}
+
+	game, err := a.p2pFactory.CreateGame(r.Context(), settings, types.GameAccess{
+		Host:     host,
+		Password: r.FormValue("password"),
+	})
+	if errors.Is(err, types.ErrShuttingDown) {
+		httpCode(w, http.StatusServiceUnavailable)
+		return
+	}
 	if err != nil {
 		a.sendErr(err, w, http.StatusInternalServerError)
 		return
@@ -187,19 +514,98 @@ func (a *gameAPI) CreateP2P(w http.ResponseWriter, r *http.Request) {
 	a.marshalAndSend(game.GetID(), err, w)
 }
 
-type messageFromUser struct {
-	Choice types.Choice `json:"choice"`
+type matchmakeRequest struct {
+	Name    string        `json:"name"`
+	Ruleset types.Ruleset `json:"ruleset"`
+	BestOf  int           `json:"best_of"`
+	// ComputerFallback asks to play with the computer if nobody comes
+	ComputerFallback bool `json:"computer_fallback"`
+}
+
+type matchmakeResponse struct {
+	*types.Match
+	// Computer tells that nobody came and the player may play with the computer
+	Computer bool `json:"computer,omitempty"`
+}
+
+func (a *gameAPI) Matchmake(w http.ResponseWriter, r *http.Request) {
+	defer a.doRecover(w)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if a.matchmaker == nil {
+		httpCode(w, http.StatusNotFound)
+		return
+	}
+
+	var req matchmakeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpCode(w, http.StatusBadRequest)
+		return
+	}
+	settings, err := types.GameSettings{Ruleset: req.Ruleset, BestOf: req.BestOf}.Normalize()
+	if err != nil || !types.ValidPlayerName(req.Name) {
+		httpCode(w, http.StatusBadRequest)
+		return
+	}
+	owner, err := a.ensureOwner(w, r)
+	if err != nil {
+		a.sendErr(err, w, http.StatusInternalServerError)
+		return
+	}
+
+	match, err := a.matchmaker.Match(r.Context(), types.MatchRequest{
+		Name:     req.Name,
+		Owner:    owner,
+		Settings: settings,
+	})
+	switch {
+	case errors.Is(err, types.ErrNoMatch) && req.ComputerFallback:
+		a.marshalAndSend(matchmakeResponse{Computer: true}, nil, w)
+	case errors.Is(err, types.ErrNoMatch):
+		httpCode(w, http.StatusRequestTimeout)
+	case errors.Is(err, types.ErrShuttingDown):
+		httpCode(w, http.StatusServiceUnavailable)
+	default:
+		a.marshalAndSend(matchmakeResponse{Match: &match}, err, w)
+	}
+}
+
+// settingsFromQuery reads the ?ruleset= and ?best_of= of the game, the defaults if they are omitted
+func settingsFromQuery(query url.Values) (types.GameSettings, error) {
+	settings := types.GameSettings{
+		Ruleset: types.Ruleset(query.Get("ruleset")),
+	}
+	if bestOf := query.Get("best_of"); bestOf != "" {
+		n, err := strconv.Atoi(bestOf)
+		if err != nil {
+			return settings, fmt.Errorf("best of: %w", err)
+		}
+		settings.BestOf = n
+	}
+	if public := query.Get("public"); public != "" {
+		p, err := strconv.ParseBool(public)
+		if err != nil {
+			return settings, fmt.Errorf("public: %w", err)
+		}
+		settings.Public = p
+	}
+	return settings.Normalize()
 }
 
 func sideString(isRight bool) string {
 	if isRight {
-		return "right"
+		return types.SideRight
 	}
-	return "left"
+	return types.SideLeft
 }
 
 type foundGameResponse struct {
 	IsFull bool `json:"is_full"`
+	// Protected is true if a password is needed to join
+	Protected bool `json:"protected,omitempty"`
 }
 
 func (a *gameAPI) FindP2PGame(w http.ResponseWriter, r *http.Request) {
@@ -226,9 +632,29 @@ func (a *gameAPI) FindP2PGame(w http.ResponseWriter, r *http.Request) {
 
 	a.marshalAndSend(foundGameResponse{
 		IsFull: isFull,
+		// only an unprotected game takes no password
+		Protected: !game.CheckPassword(""),
 	}, err, w)
 }
 
+func (a *gameAPI) P2PScore(w http.ResponseWriter, r *http.Request) {
+	defer a.doRecover(w)
+
+	id, err := types.GameIDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		httpCode(w, http.StatusBadRequest)
+		return
+	}
+
+	game, found := a.p2pFactory.GetGame(id)
+	if !found {
+		httpCode(w, http.StatusNotFound)
+		return
+	}
+
+	a.marshalAndSend(game.Score(), nil, w)
+}
+
 func (a *gameAPI) ConnectP2P(w http.ResponseWriter, r *http.Request) {
 	defer a.doRecover(w)
 
@@ -250,15 +676,105 @@ func (a *gameAPI) ConnectP2P(w http.ResponseWriter, r *http.Request) {
 		zap.Any("name", name),
 	)
 
-	side, ch, err := game.AddPlayer(name)
+	owner, err := a.ensureOwner(w, r)
+	if err != nil {
+		a.sendErr(err, w, http.StatusInternalServerError)
+		return
+	}
+
+	var side bool
+	var ch chan types.Update
+	token := r.URL.Query().Get("resume")
+	switch {
+	case token != "":
+		side, ch, err = game.ResumePlayer(token)
+	case r.URL.Query().Get("invite") != "":
+		side, ch, token, err = a.joinInvited(game, r.URL.Query().Get("invite"), name, owner)
+	case !a.mayJoin(game, r):
+		httpCode(w, http.StatusForbidden)
+		return
+	default:
+		side, ch, token, err = game.AddPlayer(name, owner)
+	}
+	if errors.Is(err, errBadInvite) || errors.Is(err, types.ErrBanned) {
+		log.Info("player refused", zap.Error(err))
+		httpCode(w, http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, types.ErrShuttingDown) {
+		httpCode(w, http.StatusServiceUnavailable)
+		return
+	}
 	if err != nil {
 		httpCode(w, http.StatusNotFound)
 		return
 	}
-	defer game.RemovePlayer(side)
+	// the seat is held for the player to resume with the token
+	defer game.DetachPlayer(ch)
 
 	log = log.With(zap.Any("side", sideString(side)))
 
+	// with the session started for the player
+	conn, err := a.upgrader.Upgrade(w, r, w.Header())
+	if err != nil {
+		a.sendErr(err, w, http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+
+	c := newP2PConn(conn, sideString(side), token)
+	untrack, ok := a.track(c)
+	if !ok {
+		return
+	}
+	defer untrack()
+	if err := c.hello(); err != nil {
+		log.Error("Error while greeting", zap.Error(err))
+		return
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go a.heartbeat(c, done, log)
+	a.keepAlive(c, func() { game.Heartbeat(side) })
+
+	go a.messageWriter(c, log, ch)
+	a.messageReader(c, game, side, log)
+}
+
+// spectatorSide is the side in the messages to spectators
+const spectatorSide = "spectator"
+
+func (a *gameAPI) WatchP2P(w http.ResponseWriter, r *http.Request) {
+	defer a.doRecover(w)
+
+	id, err := types.GameIDFromString(r.URL.Query().Get("g"))
+	if err != nil {
+		httpCode(w, http.StatusBadRequest)
+		return
+	}
+
+	game, found := a.p2pFactory.GetGame(id)
+	if !found {
+		httpCode(w, http.StatusNotFound)
+		return
+	}
+	log := a.log.With(
+		zap.Any("game_id", id),
+		zap.Any("side", spectatorSide),
+	)
+
+	if !a.mayJoin(game, r) {
+		httpCode(w, http.StatusForbidden)
+		return
+	}
+
+	ch, err := game.AddSpectator()
+	if err != nil {
+		httpCode(w, http.StatusServiceUnavailable)
+		return
+	}
+	defer game.RemoveSpectator(ch)
+
 	conn, err := a.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		a.sendErr(err, w, http.StatusInternalServerError)
@@ -266,63 +782,110 @@ func (a *gameAPI) ConnectP2P(w http.ResponseWriter, r *http.Request) {
 	}
 	defer conn.Close()
 
-	go a.messageWriter(conn, side, log, ch)
-	a.messageReader(conn, game, side, log)
+	a.watch(newP2PConn(conn, spectatorSide, ""), ch, log)
 }
 
-type messageToUser struct {
-	State types.Message `json:"state"`
-	Side  string        `json:"side"`
+// watch sends the updates to the connection of a spectator until they leave
+func (a *gameAPI) watch(c *p2pConn, ch <-chan types.Update, log *zap.Logger) {
+	untrack, ok := a.track(c)
+	if !ok {
+		return
+	}
+	defer untrack()
+	if err := c.hello(); err != nil {
+		log.Error("Error while greeting", zap.Error(err))
+		return
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go a.heartbeat(c, done, log)
+	a.keepAlive(c, func() {})
+
+	go a.messageWriter(c, log, ch)
+	c.conn.SetReadLimit(maxMessageSize)
+
+	// spectators don't play, their messages are only read to notice when they leave
+	for {
+		if _, _, err := c.conn.NextReader(); err != nil {
+			log.Info("spectator left", zap.Error(err))
+			return
+		}
+	}
 }
 
-func (a *gameAPI) messageWriter(conn *websocket.Conn, side bool, log *zap.Logger, ch <-chan types.Message) {
+// messageWriter sends the game states and chat to the player or spectator, and closes the connection when
+// the channel is closed, e.g. when the player has resumed on another connection
+func (a *gameAPI) messageWriter(conn *p2pConn, log *zap.Logger, ch <-chan types.Update) {
 	defer log.Info("stopped message writer")
 	defer func() {
 		if r := recover(); r != nil {
 			buf := make([]byte, 1<<16)
 			stackSize := runtime.Stack(buf, false)
 			log.Error("Panic in messageWriter", zap.Any("panic", r), zap.Any("stack_trace", buf[:stackSize]))
-			conn.Close()
+			conn.conn.Close()
 		}
 	}()
 
+	// the game is closed after the restart notice when the server is restarting
+	restarting := false
 	for {
 		msg, ok := <-ch
 		if !ok {
+			if restarting {
+				conn.closeRestart()
+			} else {
+				conn.conn.Close()
+			}
 			return
 		}
+		restarting = restarting || msg.Restart != nil
 
-		msgToUser := messageToUser{
-			State: msg,
-			Side:  sideString(side),
-		}
-		bytes, err := json.Marshal(msgToUser)
-		if err != nil {
-			log.Error("Error while marshalling message to json", zap.Error(err))
-			continue
-		}
-		err = conn.WriteMessage(websocket.TextMessage, bytes)
-		if err != nil {
+		if err := conn.update(msg); err != nil {
 			log.Error("Error while sending message", zap.Error(err))
+			continue
 		}
-		log.Info("sent message to user", zap.Any("message", msgToUser))
+		log.Info("sent message to user", zap.Any("message", msg))
 	}
 }
 
-func (a *gameAPI) messageReader(conn *websocket.Conn, game pkg.P2PGame, side bool, log *zap.Logger) {
+func (a *gameAPI) messageReader(conn *p2pConn, game pkg.P2PGame, side bool, log *zap.Logger) {
 	defer log.Info("stopped message reader")
+	conn.conn.SetReadLimit(maxMessageSize)
+	chat := newRateLimiter(chatBurst, chatEvery)
 	for {
-		_, msg, err := conn.ReadMessage()
+		_, msg, err := conn.conn.ReadMessage()
 		if err != nil {
 			log.Error("Error while receiving message from websocket", zap.Error(err))
 			return
 		}
-		var message messageFromUser
-		if err := json.Unmarshal(msg, &message); err != nil {
-			log.Error("Error while unmarshalling message", zap.Error(err))
-			continue
+		cmd, err := conn.parse(msg)
+		if err == nil {
+			log.Info("message from user", zap.String("type", cmd.kind), zap.Uint64("seq", cmd.seq))
+			err = a.command(game, side, cmd, chat)
 		}
-		log.Info("message from user", zap.Any("incoming_message", message))
-		game.Choice(message.Choice, side)
+		if err != nil {
+			log.Warn("message from user rejected", zap.Error(err))
+		}
+		if err := conn.reply(cmd, err); err != nil {
+			log.Error("Error while replying to message", zap.Error(err))
+		}
+	}
+}
+
+// command applies the command of the player to the game
+func (a *gameAPI) command(game pkg.P2PGame, side bool, cmd command, chat *rateLimiter) error {
+	if cmd.kind == frameChoice {
+		err := game.Choice(cmd.choice, side)
+		if errors.Is(err, types.ErrBadChoice) {
+			return protocolError{codeBadChoice, err}
+		}
+		return err
+	}
+	if !chat.allow() {
+		return protocolError{codeRateLimited, fmt.Errorf("too many chat messages")}
+	}
+	if cmd.kind == frameChat {
+		return game.Chat(side, cmd.text)
 	}
+	return game.Emote(side, cmd.emote)
 }
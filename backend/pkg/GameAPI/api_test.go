@@ -15,6 +15,11 @@ import (
 	"go.uber.org/zap/zaptest/observer"
 )
 
+// matches any anonymous session owner
+var isSession = mock.MatchedBy(func(o types.Owner) bool {
+	return strings.HasPrefix(o.String(), "session:")
+})
+
 func TestHTTPCode(t *testing.T) {
 	// Test cases
 	testCases := []struct {
@@ -199,16 +204,16 @@ func TestGetScores(t *testing.T) {
 			// Setup
 			observedZapCore, observedLogs := observer.New(zap.InfoLevel)
 			observedLogger := zap.New(observedZapCore)
-			storage := mocks.NewStorage(t)
+			storage := mocks.NewStorageV2(t)
 			defer storage.AssertExpectations(t)
 			// Create gameAPI instance
 			api := NewGameAPI(nil, nil, storage, observedLogger)
 
 			// Test
 			w := httptest.NewRecorder()
-			r := storage.EXPECT().GetLastScores().Times(1)
+			r := storage.EXPECT().GetLastRecords(mock.Anything, isSession, 10).Times(1)
 			if tc.name == "success" {
-				r.Return([]types.Result{types.Win, types.Tie}, nil)
+				r.Return([]types.GameRecord{{Result: types.Win}, {Result: types.Tie}}, nil)
 			} else {
 				r.Return(nil, errors.New("test"))
 			}
@@ -262,14 +267,14 @@ func TestClearScores(t *testing.T) {
 			// Setup
 			observedZapCore, observedLogs := observer.New(zap.InfoLevel)
 			observedLogger := zap.New(observedZapCore)
-			storage := mocks.NewStorage(t)
+			storage := mocks.NewStorageV2(t)
 			defer storage.AssertExpectations(t)
 			// Create gameAPI instance
 			api := NewGameAPI(nil, nil, storage, observedLogger)
 
 			// Test
 			w := httptest.NewRecorder()
-			r := storage.EXPECT().ClearScores().Times(1)
+			r := storage.EXPECT().ClearRecords(mock.Anything, isSession).Times(1)
 			if tc.name == "success" {
 				r.Return(nil)
 			} else {
@@ -335,7 +340,7 @@ func TestPlay(t *testing.T) {
 			// Setup
 			observedZapCore, observedLogs := observer.New(zap.InfoLevel)
 			observedLogger := zap.New(observedZapCore)
-			storage := mocks.NewStorage(t)
+			storage := mocks.NewStorageV2(t)
 			defer storage.AssertExpectations(t)
 
 			// Create gameAPI instance
@@ -343,12 +348,26 @@ func TestPlay(t *testing.T) {
 
 			// Test
 			w := httptest.NewRecorder()
+			isRecord := mock.MatchedBy(func(r types.GameRecord) bool {
+				return r.Owner != types.Global &&
+					r.Mode == types.ModeComputer &&
+					r.Result == types.Win &&
+					r.PlayerChoice == types.Lizard &&
+					r.OpponentChoice == types.Lizard &&
+					r.Strategy == "random" &&
+					r.RNGSource == "internal" &&
+					!r.Time.IsZero()
+			})
 			if tc.name == "success" {
 				tc.game.On("Play", mock.Anything, types.Lizard).Return(types.Win, types.Lizard, tc.expectedErr)
-				storage.EXPECT().SetLastScore(types.Win).Times(1).Return(nil)
+				tc.game.On("Strategy").Return("random")
+				tc.game.On("RNGSource").Return("internal")
+				storage.EXPECT().AddRecord(mock.Anything, isRecord).Times(1).Return(nil)
 			} else if tc.name == "score fail" {
 				tc.game.On("Play", mock.Anything, types.Lizard).Return(types.Win, types.Lizard, tc.expectedErr)
-				storage.EXPECT().SetLastScore(types.Win).Times(1).Return(errors.New("test"))
+				tc.game.On("Strategy").Return("random")
+				tc.game.On("RNGSource").Return("internal")
+				storage.EXPECT().AddRecord(mock.Anything, isRecord).Times(1).Return(errors.New("test"))
 			} else {
 				tc.game.On("Play", mock.Anything, types.Lizard).Return(types.Tie, types.Lizard, tc.expectedErr)
 			}
@@ -0,0 +1,47 @@
+package gameapi
+
+import "time"
+
+const (
+	// maxMessageSize limits the messages read from the P2P WebSockets
+	maxMessageSize = 4096
+	// chatBurst is the number of chat messages a connection may send at once,
+	// after that one message per chatEvery
+	chatBurst = 5
+	chatEvery = 2 * time.Second
+)
+
+// rateLimiter is a token bucket, it's used by a single connection reader
+type rateLimiter struct {
+	tokens float64
+	burst  float64
+	every  time.Duration
+	last   time.Time
+	now    func() time.Time
+}
+
+func newRateLimiter(burst int, every time.Duration) *rateLimiter {
+	return &rateLimiter{
+		tokens: float64(burst),
+		burst:  float64(burst),
+		every:  every,
+		now:    time.Now,
+	}
+}
+
+// allow takes a token if there's one
+func (l *rateLimiter) allow() bool {
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens += float64(now.Sub(l.last)) / float64(l.every)
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
@@ -0,0 +1,30 @@
+package gameapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+	l := newRateLimiter(2, time.Second)
+	l.now = func() time.Time { return now }
+
+	assert.True(t, l.allow())
+	assert.True(t, l.allow())
+	assert.False(t, l.allow())
+
+	now = now.Add(500 * time.Millisecond)
+	assert.False(t, l.allow())
+	now = now.Add(500 * time.Millisecond)
+	assert.True(t, l.allow())
+	assert.False(t, l.allow())
+
+	// the burst doesn't grow while idle
+	now = now.Add(time.Hour)
+	assert.True(t, l.allow())
+	assert.True(t, l.allow())
+	assert.False(t, l.allow())
+}
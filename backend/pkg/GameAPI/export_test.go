@@ -0,0 +1,125 @@
+package gameapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/mocks"
+	"github.com/complynx/rpssl4bu/backend/pkg/stats"
+	"github.com/complynx/rpssl4bu/backend/pkg/storage"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestExport(t *testing.T) {
+	storage := mocks.NewStorageV2(t)
+	defer storage.AssertExpectations(t)
+	api := NewGameAPI(nil, nil, storage, zap.NewNop())
+
+	storage.EXPECT().History(mock.Anything, mock.MatchedBy(func(q types.HistoryQuery) bool {
+		return q.Owner == types.SessionOwner(testSession) && q.Ascending && q.After == 0
+	})).Times(1).Return(types.HistoryPage{
+		Records: []types.GameRecord{{
+			ID:           7,
+			Owner:        types.SessionOwner(testSession),
+			Time:         time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC),
+			PlayerChoice: types.Rock,
+			Result:       types.Win,
+		}},
+	}, nil)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/export?format=csv", nil)
+	r.AddCookie(&http.Cookie{Name: sessionCookie, Value: testSession})
+	api.Export(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="history.csv"`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "id,owner,time,mode,game_id,player_name,opponent_name,player_choice,opponent_choice,result,strategy,rng_source,mirror\n"+
+		"7,,2023-04-01T00:00:00Z,computer,,,,rock,,win,,,\n", w.Body.String(), "the session is secret")
+
+	storage.EXPECT().History(mock.Anything, mock.MatchedBy(func(q types.HistoryQuery) bool {
+		return q.Owner == types.Global
+	})).Times(1).Return(types.HistoryPage{Records: []types.GameRecord{}}, nil)
+	w = httptest.NewRecorder()
+	api.Export(w, httptest.NewRequest(http.MethodGet, "/export?format=ndjson&scope=global", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	assert.Empty(t, w.Body.String())
+
+	w = httptest.NewRecorder()
+	api.Export(w, httptest.NewRequest(http.MethodGet, "/export?format=xlsx", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	api.Export(w, httptest.NewRequest(http.MethodPost, "/export?format=csv", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestImport(t *testing.T) {
+	storage := mocks.NewStorageV2(t)
+	defer storage.AssertExpectations(t)
+	api := NewGameAPI(nil, nil, storage, zap.NewNop())
+
+	// records always go to the caller's scoreboard
+	storage.EXPECT().AddRecord(mock.Anything, types.GameRecord{
+		Owner:  types.SessionOwner(testSession),
+		Time:   time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC),
+		Mode:   types.ModeComputer,
+		Result: types.Lose,
+	}).Times(1).Return(nil)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/import?format=ndjson", strings.NewReader(
+		`{"id":3,"owner":"user:penny","time":"2023-04-01T00:00:00Z","mode":"computer","result":"lose"}`+"\n",
+	))
+	r.AddCookie(&http.Cookie{Name: sessionCookie, Value: testSession})
+	api.Import(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"imported":1}`, w.Body.String())
+
+	// bad records are reported after the imported ones
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/import?format=ndjson", strings.NewReader(`{"result":"draw"}`))
+	r.AddCookie(&http.Cookie{Name: sessionCookie, Value: testSession})
+	api.Import(w, r)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "imported 0 records")
+
+	w = httptest.NewRecorder()
+	api.Import(w, httptest.NewRequest(http.MethodPost, "/import", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	api.Import(w, httptest.NewRequest(http.MethodGet, "/import?format=csv", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestImportP2P(t *testing.T) {
+	tracker, err := stats.NewTracker(context.Background(), storage.NewSimple(10))
+	require.NoError(t, err)
+	api := NewGameAPI(nil, nil, tracker, zap.NewNop(), WithStats(tracker), WithUserHeader("X-User"))
+	playerStats := func() string {
+		w := httptest.NewRecorder()
+		api.Stats(w, httptest.NewRequest(http.MethodGet, "/stats?player=penny", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+	before := playerStats()
+
+	// the player can't forge the P2P games
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/import?format=ndjson", strings.NewReader(
+		`{"time":"2023-04-01T00:00:00Z","mode":"p2p","player_name":"Penny","opponent_name":"Sheldon","result":"win"}`+"\n",
+	))
+	r.Header.Set("X-User", "penny")
+	api.Import(w, r)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "P2P records can't be imported")
+	assert.Equal(t, before, playerStats())
+}
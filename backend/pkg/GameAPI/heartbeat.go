@@ -0,0 +1,44 @@
+package gameapi
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultPingInterval is how often the P2P WebSockets are pinged
+	DefaultPingInterval = 15 * time.Second
+	// DefaultPongTimeout is how long a P2P WebSocket may stay silent before it's dropped
+	DefaultPongTimeout = 40 * time.Second
+	// writeTimeout limits every write to a P2P WebSocket
+	writeTimeout = 10 * time.Second
+)
+
+// keepAlive makes the reads of the connection fail when no pong comes within the pong timeout,
+// alive is called with every pong
+func (a *gameAPI) keepAlive(conn *p2pConn, alive func()) {
+	conn.conn.SetReadDeadline(time.Now().Add(a.pongTimeout))
+	conn.conn.SetPongHandler(func(string) error {
+		alive()
+		return conn.conn.SetReadDeadline(time.Now().Add(a.pongTimeout))
+	})
+}
+
+// heartbeat pings the connection every ping interval until done
+func (a *gameAPI) heartbeat(conn *p2pConn, done <-chan struct{}, log *zap.Logger) {
+	ticker := time.NewTicker(a.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := conn.ping(); err != nil {
+				log.Warn("Failed to ping", zap.Error(err))
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package gameapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	p2pgame "github.com/complynx/rpssl4bu/backend/pkg/p2p_game"
+	"github.com/complynx/rpssl4bu/backend/pkg/random"
+	"github.com/complynx/rpssl4bu/backend/pkg/storage"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestP2PHeartbeat(t *testing.T) {
+	factory := p2pgame.NewGameFactory(random.NewSimpleRandom(""), storage.NewSimple(10), zap.NewNop())
+	defer factory.StopGames(context.Background())
+	game, err := factory.CreateGame(context.Background(), types.GameSettings{}, types.GameAccess{})
+	require.NoError(t, err)
+	api := NewGameAPI(nil, factory, nil, zap.NewNop(), WithHeartbeat(10*time.Millisecond, 50*time.Millisecond))
+	srv := httptest.NewServer(http.HandlerFunc(api.ConnectP2P))
+	defer srv.Close()
+
+	// the client answers pings only while it reads
+	dialP2P(t, srv, "g="+game.GetID().String()+"&name=Sheldon", protocolV2)
+	penny := dialP2P(t, srv, "g="+game.GetID().String()+"&name=Penny", protocolV2)
+
+	// Penny keeps reading and stays connected past the pong timeout
+	var state struct {
+		LeftPlayerName         string `json:"left_player_name"`
+		LeftPlayerDisconnected bool   `json:"left_player_disconnected"`
+	}
+	for !state.LeftPlayerDisconnected {
+		readFrame(t, penny, frameState, &state)
+	}
+	assert.Equal(t, "Sheldon", state.LeftPlayerName)
+	assert.True(t, game.IsFull(context.Background()), "the seat is held")
+}
@@ -0,0 +1,86 @@
+package gameapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+)
+
+// parseHistoryQuery reads history filters from the request parameters, the owner is left global.
+func parseHistoryQuery(params url.Values) (types.HistoryQuery, error) {
+	q := types.HistoryQuery{
+		Limit: defaultHistoryLimit,
+	}
+
+	if s := params.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 {
+			return q, fmt.Errorf("bad limit: %q", s)
+		}
+		if limit > maxHistoryLimit {
+			limit = maxHistoryLimit
+		}
+		q.Limit = limit
+	}
+	if s := params.Get("cursor"); s != "" {
+		before, err := types.ParseHistoryCursor(s)
+		if err != nil {
+			return q, fmt.Errorf("bad cursor: %w", err)
+		}
+		q.Before = before
+	}
+	if s := params.Get("result"); s != "" {
+		var result types.Result
+		if err := json.Unmarshal([]byte(strconv.Quote(s)), &result); err != nil || result == types.Unknown {
+			return q, fmt.Errorf("bad result: %q", s)
+		}
+		q.Result = &result
+	}
+	for name, filter := range map[string]**types.Choice{
+		"choice":          &q.Choice,
+		"opponent_choice": &q.OpponentChoice,
+	} {
+		if s := params.Get(name); s != "" {
+			choice, err := parseChoice(s)
+			if err != nil {
+				return q, fmt.Errorf("bad %s: %w", name, err)
+			}
+			*filter = &choice
+		}
+	}
+	if s := params.Get("mode"); s != "" {
+		mode, err := types.GameModeFromString(s)
+		if err != nil {
+			return q, fmt.Errorf("bad mode: %w", err)
+		}
+		q.Mode = &mode
+	}
+	for name, bound := range map[string]*time.Time{
+		"since": &q.Since,
+		"until": &q.Until,
+	} {
+		if s := params.Get(name); s != "" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return q, fmt.Errorf("bad %s: %w", name, err)
+			}
+			*bound = t
+		}
+	}
+	return q, nil
+}
+
+// choice is accepted either by name or by ID
+func parseChoice(s string) (types.Choice, error) {
+	var choice types.Choice
+	data := []byte(s)
+	if _, err := strconv.Atoi(s); err != nil {
+		data = []byte(strconv.Quote(s))
+	}
+	err := json.Unmarshal(data, &choice)
+	return choice, err
+}
@@ -0,0 +1,137 @@
+package gameapi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/mocks"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestParseHistoryQuery(t *testing.T) {
+	lose := types.Lose
+	spock := types.Spock
+	paper := types.Paper
+	p2p := types.ModeP2P
+
+	testCases := []struct {
+		name     string
+		query    string
+		expected types.HistoryQuery
+		err      bool
+	}{
+		{
+			name:     "defaults",
+			expected: types.HistoryQuery{Limit: defaultHistoryLimit},
+		},
+		{
+			name:  "all filters",
+			query: "limit=5&cursor=" + types.HistoryCursor(42) + "&result=lose&choice=2&opponent_choice=spock&mode=p2p&since=2023-04-01T00:00:00Z&until=2023-04-08T00:00:00Z",
+			expected: types.HistoryQuery{
+				Limit:          5,
+				Before:         42,
+				Result:         &lose,
+				Choice:         &paper,
+				OpponentChoice: &spock,
+				Mode:           &p2p,
+				Since:          time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC),
+				Until:          time.Date(2023, 4, 8, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "limit is capped",
+			query:    "limit=1000",
+			expected: types.HistoryQuery{Limit: maxHistoryLimit},
+		},
+		{name: "bad limit", query: "limit=0", err: true},
+		{name: "bad cursor", query: "cursor=42", err: true},
+		{name: "bad result", query: "result=unknown", err: true},
+		{name: "bad choice", query: "choice=6", err: true},
+		{name: "bad opponent choice", query: "opponent_choice=sheldon", err: true},
+		{name: "bad mode", query: "mode=solo", err: true},
+		{name: "bad since", query: "since=yesterday", err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			params, err := url.ParseQuery(tc.query)
+			assert.NoError(t, err)
+
+			q, err := parseHistoryQuery(params)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, q)
+		})
+	}
+}
+
+func TestHistory(t *testing.T) {
+	storage := mocks.NewStorageV2(t)
+	defer storage.AssertExpectations(t)
+	api := NewGameAPI(nil, nil, storage, zap.NewNop())
+
+	// own history
+	storage.EXPECT().History(mock.Anything, types.HistoryQuery{
+		Owner: types.SessionOwner(testSession),
+		Limit: 2,
+	}).Times(1).Return(types.HistoryPage{
+		Records: []types.GameRecord{{ID: 7, Result: types.Win, Time: time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)}},
+		Cursor:  "next",
+	}, nil)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/history?limit=2", nil)
+	r.AddCookie(&http.Cookie{Name: sessionCookie, Value: testSession})
+	api.History(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"records":[{
+		"id":7,
+		"time":"2023-04-01T00:00:00Z",
+		"mode":"computer",
+		"player_name":"",
+		"opponent_name":"",
+		"result":"win"
+	}],"cursor":"next"}`, w.Body.String())
+
+	// everyone's history, without the sessions of the others
+	storage.EXPECT().History(mock.Anything, types.HistoryQuery{
+		Owner: types.Global,
+		Limit: defaultHistoryLimit,
+	}).Times(1).Return(types.HistoryPage{
+		Records: []types.GameRecord{
+			{ID: 9, Owner: types.SessionOwner(testSession), Result: types.Win},
+			{ID: 8, Owner: types.UserOwner("sheldon"), Result: types.Lose},
+		},
+	}, nil)
+	w = httptest.NewRecorder()
+	api.History(w, httptest.NewRequest(http.MethodGet, "/history?scope=global", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), testSession)
+	assert.Contains(t, w.Body.String(), `"owner":"user:sheldon"`)
+
+	storage.EXPECT().History(mock.Anything, types.HistoryQuery{
+		Owner: types.Global,
+		Limit: defaultHistoryLimit,
+	}).Times(1).Return(types.HistoryPage{}, errors.New("test"))
+	w = httptest.NewRecorder()
+	api.History(w, httptest.NewRequest(http.MethodGet, "/history?scope=global", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	// bad request never reaches the storage
+	w = httptest.NewRecorder()
+	api.History(w, httptest.NewRequest(http.MethodGet, "/history?mode=solo", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	api.History(w, httptest.NewRequest(http.MethodPost, "/history", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -0,0 +1,207 @@
+package gameapi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	// inviteAnySide is the side of the invites to whichever seat is free
+	inviteAnySide = "any"
+
+	defaultInviteTTL = time.Hour
+	maxInviteTTL     = 7 * 24 * time.Hour
+)
+
+var errBadInvite = fmt.Errorf("bad invite")
+
+// signInvite returns the invite to the side of the game, valid until it expires:
+// the game ID, the side and the expiry time, signed with the key.
+func signInvite(key []byte, id types.GameID, side string, expires time.Time) string {
+	payload := fmt.Sprintf("%s.%s.%d", id, side, expires.Unix())
+	return payload + "." + base64.RawURLEncoding.EncodeToString(inviteMAC(key, payload))
+}
+
+// verifyInvite checks the invite to the game and returns its side
+func verifyInvite(key []byte, invite string, id types.GameID, now time.Time) (string, error) {
+	i := strings.LastIndexByte(invite, '.')
+	if i < 0 {
+		return "", errBadInvite
+	}
+	payload := invite[:i]
+	mac, err := base64.RawURLEncoding.DecodeString(invite[i+1:])
+	if err != nil || !hmac.Equal(mac, inviteMAC(key, payload)) {
+		return "", errBadInvite
+	}
+
+	parts := strings.Split(payload, ".")
+	if len(parts) != 3 || parts[0] != id.String() {
+		return "", errBadInvite
+	}
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || now.Unix() > expires {
+		return "", errBadInvite
+	}
+	return parts[1], nil
+}
+
+func inviteMAC(key []byte, payload string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
+
+// joinInvited seats the player on the side of the invite
+func (a *gameAPI) joinInvited(game pkg.P2PGame, invite, name string, owner types.Owner) (bool, chan types.Update, string, error) {
+	side, err := verifyInvite(a.inviteKey, invite, game.GetID(), time.Now())
+	if err != nil {
+		return false, nil, "", err
+	}
+	if side == inviteAnySide {
+		return game.AddPlayer(name, owner)
+	}
+	rightSide := side == types.SideRight
+	ch, token, err := game.AddPlayerOnSide(name, owner, rightSide)
+	return rightSide, ch, token, err
+}
+
+// mayJoin tells if the request knows the password of the game or has an invite to it, the host needs neither
+func (a *gameAPI) mayJoin(game pkg.P2PGame, r *http.Request) bool {
+	if owner, _ := a.owner(r); isHost(game, owner) {
+		return true
+	}
+	if invite := r.URL.Query().Get("invite"); invite != "" {
+		_, err := verifyInvite(a.inviteKey, invite, game.GetID(), time.Now())
+		return err == nil
+	}
+	return game.CheckPassword(r.URL.Query().Get("password"))
+}
+
+func isHost(game pkg.P2PGame, owner types.Owner) bool {
+	return owner != types.Global && game.Host() == owner
+}
+
+// hostGame returns the game of the request if it's sent by the host, otherwise it writes the error
+func (a *gameAPI) hostGame(w http.ResponseWriter, r *http.Request) (pkg.P2PGame, bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+
+	id, err := types.GameIDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		httpCode(w, http.StatusBadRequest)
+		return nil, false
+	}
+
+	game, found := a.p2pFactory.GetGame(id)
+	if !found {
+		httpCode(w, http.StatusNotFound)
+		return nil, false
+	}
+
+	if owner, _ := a.owner(r); !isHost(game, owner) {
+		httpCode(w, http.StatusForbidden)
+		return nil, false
+	}
+	return game, true
+}
+
+// sideFromQuery parses the side of the seat, defaulting to the given one
+func sideFromQuery(r *http.Request, def string) (string, error) {
+	side := r.URL.Query().Get("side")
+	switch side {
+	case "":
+		return def, nil
+	case types.SideLeft, types.SideRight, inviteAnySide:
+		return side, nil
+	}
+	return "", fmt.Errorf("unknown side %q", side)
+}
+
+type inviteResponse struct {
+	Invite  string    `json:"invite"`
+	Expires time.Time `json:"expires"`
+}
+
+func (a *gameAPI) Invite(w http.ResponseWriter, r *http.Request) {
+	defer a.doRecover(w)
+
+	game, ok := a.hostGame(w, r)
+	if !ok {
+		return
+	}
+
+	side, err := sideFromQuery(r, inviteAnySide)
+	if err != nil {
+		httpCode(w, http.StatusBadRequest)
+		return
+	}
+	ttl := defaultInviteTTL
+	if s := r.URL.Query().Get("ttl"); s != "" {
+		ttl, err = time.ParseDuration(s)
+		if err != nil || ttl <= 0 {
+			httpCode(w, http.StatusBadRequest)
+			return
+		}
+		ttl = min(ttl, maxInviteTTL)
+	}
+
+	expires := time.Now().Add(ttl).Truncate(time.Second)
+	a.log.Info("invite issued", zap.Any("game_id", game.GetID()), zap.String("side", side), zap.Time("expires", expires))
+	a.marshalAndSend(inviteResponse{
+		Invite:  signInvite(a.inviteKey, game.GetID(), side, expires),
+		Expires: expires,
+	}, nil, w)
+}
+
+func (a *gameAPI) Kick(w http.ResponseWriter, r *http.Request) {
+	defer a.doRecover(w)
+
+	game, ok := a.hostGame(w, r)
+	if !ok {
+		return
+	}
+	a.kick(w, r, game, "host")
+}
+
+// kick removes the player from the side in the query of the host's or operator's request
+func (a *gameAPI) kick(w http.ResponseWriter, r *http.Request, game pkg.P2PGame, by string) {
+	side, err := sideFromQuery(r, "")
+	if err != nil || side == "" || side == inviteAnySide {
+		httpCode(w, http.StatusBadRequest)
+		return
+	}
+	ban := false
+	if s := r.URL.Query().Get("ban"); s != "" {
+		ban, err = strconv.ParseBool(s)
+		if err != nil {
+			httpCode(w, http.StatusBadRequest)
+			return
+		}
+	}
+
+	err = game.Kick(side == types.SideRight, ban)
+	if errors.Is(err, types.ErrNoPlayer) {
+		httpCode(w, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		a.sendErr(err, w, http.StatusInternalServerError)
+		return
+	}
+	a.log.Info("player kicked", zap.Any("game_id", game.GetID()), zap.String("by", by), zap.String("side", side), zap.Bool("ban", ban))
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,155 @@
+package gameapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/mocks"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const hostSession = "0123456789abcdef0123456789abcdef"
+
+// hostRequest posts to the path of the game as routed by chi, from the session
+func hostRequest(path string, id types.GameID, session string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, strings.ReplaceAll(path, "{id}", id.String()), nil)
+	if session != "" {
+		r.Header.Set(sessionHeader, session)
+	}
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id.String())
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestVerifyInvite(t *testing.T) {
+	key := []byte("secret")
+	id := types.GameID(0x1234)
+	now := time.Now()
+	invite := signInvite(key, id, types.SideRight, now.Add(time.Minute))
+
+	side, err := verifyInvite(key, invite, id, now)
+	require.NoError(t, err)
+	assert.Equal(t, types.SideRight, side)
+
+	_, err = verifyInvite(key, invite, id, now.Add(2*time.Minute))
+	assert.ErrorIs(t, err, errBadInvite, "expired")
+	_, err = verifyInvite(key, invite, types.GameID(0x5678), now)
+	assert.ErrorIs(t, err, errBadInvite, "other game")
+	_, err = verifyInvite([]byte("other"), invite, id, now)
+	assert.ErrorIs(t, err, errBadInvite, "other key")
+	_, err = verifyInvite(key, strings.Replace(invite, types.SideRight, types.SideLeft, 1), id, now)
+	assert.ErrorIs(t, err, errBadInvite, "tampered")
+	_, err = verifyInvite(key, "nope", id, now)
+	assert.ErrorIs(t, err, errBadInvite)
+}
+
+func TestInvite(t *testing.T) {
+	factory := mocks.NewP2PGameFactory(t)
+	game := mocks.NewP2PGame(t)
+	api := NewGameAPI(nil, factory, nil, zap.NewNop(), WithInviteKey([]byte("secret")))
+	id := types.GameID(0x1234)
+	factory.EXPECT().GetGame(id).Return(game, true)
+	game.EXPECT().GetID().Return(id)
+	game.EXPECT().Host().Return(types.SessionOwner(hostSession))
+
+	w := httptest.NewRecorder()
+	api.Invite(w, hostRequest("/p2p/{id}/invite?side=left&ttl=30m", id, hostSession))
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp inviteResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.WithinDuration(t, time.Now().Add(30*time.Minute), resp.Expires, 2*time.Second)
+	side, err := verifyInvite([]byte("secret"), resp.Invite, id, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, types.SideLeft, side)
+
+	// only the host invites
+	w = httptest.NewRecorder()
+	api.Invite(w, hostRequest("/p2p/{id}/invite", id, "fedcba9876543210fedcba9876543210"))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = httptest.NewRecorder()
+	api.Invite(w, hostRequest("/p2p/{id}/invite", id, ""))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	api.Invite(w, hostRequest("/p2p/{id}/invite?side=top", id, hostSession))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = httptest.NewRecorder()
+	api.Invite(w, hostRequest("/p2p/{id}/invite?ttl=-1h", id, hostSession))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestKick(t *testing.T) {
+	factory := mocks.NewP2PGameFactory(t)
+	game := mocks.NewP2PGame(t)
+	api := NewGameAPI(nil, factory, nil, zap.NewNop())
+	id := types.GameID(0x1234)
+	factory.EXPECT().GetGame(id).Return(game, true)
+	game.EXPECT().GetID().Return(id).Maybe()
+	game.EXPECT().Host().Return(types.SessionOwner(hostSession))
+
+	game.EXPECT().Kick(true, true).Times(1).Return(nil)
+	w := httptest.NewRecorder()
+	api.Kick(w, hostRequest("/p2p/{id}/kick?side=right&ban=true", id, hostSession))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	game.EXPECT().Kick(false, false).Times(1).Return(types.ErrNoPlayer)
+	w = httptest.NewRecorder()
+	api.Kick(w, hostRequest("/p2p/{id}/kick?side=left", id, hostSession))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	api.Kick(w, hostRequest("/p2p/{id}/kick", id, hostSession))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = httptest.NewRecorder()
+	api.Kick(w, hostRequest("/p2p/{id}/kick?side=left", id, "fedcba9876543210fedcba9876543210"))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestConnectProtected(t *testing.T) {
+	factory := mocks.NewP2PGameFactory(t)
+	game := mocks.NewP2PGame(t)
+	api := NewGameAPI(nil, factory, nil, zap.NewNop(), WithInviteKey([]byte("secret")))
+	id := types.GameID(0x1234)
+	factory.EXPECT().GetGame(id).Return(game, true)
+	game.EXPECT().GetID().Return(id)
+	game.EXPECT().CheckPassword("").Return(false)
+
+	w := httptest.NewRecorder()
+	api.ConnectP2P(w, httptest.NewRequest(http.MethodGet, "/connect_p2p?g="+id.String()+"&name=Penny", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = httptest.NewRecorder()
+	api.WatchP2P(w, httptest.NewRequest(http.MethodGet, "/watch_p2p?g="+id.String(), nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	invite := signInvite([]byte("secret"), types.GameID(0x5678), inviteAnySide, time.Now().Add(time.Hour))
+	w = httptest.NewRecorder()
+	api.ConnectP2P(w, httptest.NewRequest(http.MethodGet, "/connect_p2p?g="+id.String()+"&name=Penny&invite="+invite, nil))
+	assert.Equal(t, http.StatusForbidden, w.Code, "invite to another game")
+
+	invite = signInvite([]byte("secret"), id, types.SideRight, time.Now().Add(time.Hour))
+	session := "00000000000000000000000000000001"
+	game.EXPECT().AddPlayerOnSide("Penny", types.SessionOwner(session), true).Times(1).Return(nil, "", types.ErrBanned)
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/connect_p2p?g="+id.String()+"&name=Penny&invite="+invite, nil)
+	r.Header.Set(sessionHeader, session)
+	api.ConnectP2P(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Code, "banned")
+
+	// the players without a session get a new one
+	game.EXPECT().AddPlayerOnSide("Howard", mock.MatchedBy(func(o types.Owner) bool { return o != types.Global }), true).
+		Times(1).Return(nil, "", types.ErrSeatTaken)
+	w = httptest.NewRecorder()
+	api.ConnectP2P(w, httptest.NewRequest(http.MethodGet, "/connect_p2p?g="+id.String()+"&name=Howard&invite="+invite, nil))
+	assert.Equal(t, http.StatusNotFound, w.Code, "seat taken")
+	assert.Regexp(t, sessionRe, w.Header().Get(sessionHeader))
+}
@@ -0,0 +1,89 @@
+package gameapi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/mocks"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestLeaderboard(t *testing.T) {
+	leaderboard := []types.LeaderboardEntry{{
+		Rank: 1,
+		Rating: types.Rating{
+			Owner:      types.UserOwner("sheldon"),
+			Player:     "sheldon",
+			Rating:     1662.5,
+			Deviation:  290,
+			Volatility: 0.06,
+			Games:      1,
+		},
+	}}
+
+	testCases := []struct {
+		name           string
+		url            string
+		setup          func(*mocks.RatingStorage)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "default limit",
+			url:  "/leaderboard",
+			setup: func(s *mocks.RatingStorage) {
+				s.EXPECT().Leaderboard(mock.Anything, defaultHistoryLimit).Times(1).Return(leaderboard, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `[{"rank":1,"player":"sheldon","rating":1662.5,"deviation":290,` +
+				`"volatility":0.06,"games":1}]`,
+		},
+		{
+			name: "limit",
+			url:  "/leaderboard?limit=1000",
+			setup: func(s *mocks.RatingStorage) {
+				s.EXPECT().Leaderboard(mock.Anything, maxHistoryLimit).Times(1).Return([]types.LeaderboardEntry{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[]`,
+		},
+		{
+			name:           "bad limit",
+			url:            "/leaderboard?limit=0",
+			setup:          func(s *mocks.RatingStorage) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "error",
+			url:  "/leaderboard",
+			setup: func(s *mocks.RatingStorage) {
+				s.EXPECT().Leaderboard(mock.Anything, mock.Anything).Times(1).Return(nil, errors.New("test"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ratings := mocks.NewRatingStorage(t)
+			tc.setup(ratings)
+			api := NewGameAPI(nil, nil, nil, zap.NewNop(), WithRatings(ratings))
+
+			w := httptest.NewRecorder()
+			api.Leaderboard(w, httptest.NewRequest(http.MethodGet, tc.url, nil))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedBody != "" {
+				assert.Equal(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	NewGameAPI(nil, nil, nil, zap.NewNop()).Leaderboard(w, httptest.NewRequest(http.MethodGet, "/leaderboard", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,91 @@
+package gameapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"go.uber.org/zap"
+)
+
+// lobbySide names the lobby connections in the logs
+const lobbySide = "lobby"
+
+// lobbyPayload is the list of the open public games, the whole message for p2p v1
+type lobbyPayload struct {
+	Games []types.GameInfo `json:"games"`
+}
+
+// lobby sends the list of the open public games
+func (c *p2pConn) lobby(games []types.GameInfo) error {
+	if c.v2 {
+		return c.write(frameLobby, lobbyPayload{Games: games})
+	}
+
+	data, err := json.Marshal(lobbyPayload{Games: games})
+	if err != nil {
+		return fmt.Errorf("marshal lobby: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.send(data)
+}
+
+func (a *gameAPI) P2PGames(w http.ResponseWriter, r *http.Request) {
+	defer a.doRecover(w)
+
+	a.marshalAndSend(a.p2pFactory.Lobby(), nil, w)
+}
+
+func (a *gameAPI) Lobby(w http.ResponseWriter, r *http.Request) {
+	defer a.doRecover(w)
+
+	conn, err := a.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		a.sendErr(err, w, http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+	log := a.log.With(zap.String("side", lobbySide))
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	changed := a.p2pFactory.WatchLobby(ctx)
+
+	c := newP2PConn(conn, lobbySide, "")
+	untrack, ok := a.track(c)
+	if !ok {
+		return
+	}
+	defer untrack()
+	done := make(chan struct{})
+	defer close(done)
+	go a.heartbeat(c, done, log)
+	a.keepAlive(c, func() {})
+	conn.SetReadLimit(maxMessageSize)
+
+	// the lobby is only watched, the messages are read to notice when the client leaves
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				log.Info("lobby watcher left", zap.Error(err))
+				return
+			}
+		}
+	}()
+
+	for {
+		if err := c.lobby(a.p2pFactory.Lobby()); err != nil {
+			log.Error("Error while sending lobby", zap.Error(err))
+			return
+		}
+		if _, ok := <-changed; !ok {
+			return
+		}
+	}
+}
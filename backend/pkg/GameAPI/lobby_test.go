@@ -0,0 +1,79 @@
+package gameapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	p2pgame "github.com/complynx/rpssl4bu/backend/pkg/p2p_game"
+	"github.com/complynx/rpssl4bu/backend/pkg/random"
+	"github.com/complynx/rpssl4bu/backend/pkg/storage"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestLobby(t *testing.T) {
+	factory := p2pgame.NewGameFactory(random.NewSimpleRandom(""), storage.NewSimple(10), zap.NewNop())
+	defer factory.StopGames(context.Background())
+	api := NewGameAPI(nil, factory, nil, zap.NewNop())
+	mux := http.NewServeMux()
+	mux.HandleFunc("/create_p2p", api.CreateP2P)
+	mux.HandleFunc("/p2p/games", api.P2PGames)
+	mux.HandleFunc("/p2p/lobby", api.Lobby)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/p2p/lobby", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	readLobby := func() []types.GameInfo {
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		var msg lobbyPayload
+		require.NoError(t, conn.ReadJSON(&msg))
+		return msg.Games
+	}
+	assert.Empty(t, readLobby())
+
+	resp, err := http.Post(srv.URL+"/create_p2p?public=yes", "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp, err = http.Post(srv.URL+"/create_p2p?public=true&ruleset=classic&best_of=3", "", nil)
+	require.NoError(t, err)
+	var id types.GameID
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&id))
+	resp.Body.Close()
+
+	games := readLobby()
+	require.Len(t, games, 1)
+	assert.Equal(t, id, games[0].ID)
+	assert.Equal(t, types.GameSettings{Ruleset: types.RulesetClassic, BestOf: 3, Public: true}, games[0].GameSettings)
+
+	game, ok := factory.GetGame(id)
+	require.True(t, ok)
+	_, _, _, err = game.AddPlayer("Sheldon", types.Global)
+	require.NoError(t, err)
+	games = readLobby()
+	require.Len(t, games, 1)
+	assert.Equal(t, "Sheldon", games[0].Creator)
+	assert.Equal(t, 1, games[0].Players)
+
+	resp, err = http.Get(srv.URL + "/p2p/games")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var listed []types.GameInfo
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&listed))
+	assert.Equal(t, games, listed)
+
+	// the game is gone once it's full
+	_, _, _, err = game.AddPlayer("Penny", types.Global)
+	require.NoError(t, err)
+	assert.Empty(t, readLobby())
+}
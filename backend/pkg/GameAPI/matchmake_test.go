@@ -0,0 +1,68 @@
+package gameapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/mocks"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestMatchmake(t *testing.T) {
+	matchmaker := mocks.NewMatchmaker(t)
+	api := NewGameAPI(nil, nil, nil, zap.NewNop(), WithMatchmaker(matchmaker))
+	session := "00000000000000000000000000000001"
+	matchmake := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/matchmake", strings.NewReader(body))
+		r.Header.Set(sessionHeader, session)
+		api.Matchmake(w, r)
+		return w
+	}
+	classic := types.GameSettings{Ruleset: types.RulesetClassic, BestOf: 3}
+
+	matchmaker.EXPECT().Match(mock.Anything, types.MatchRequest{
+		Name:     "Sheldon",
+		Owner:    types.SessionOwner(session),
+		Settings: classic,
+	}).Times(1).Return(types.Match{
+		GameID:   0x1234,
+		Side:     types.SideRight,
+		Resume:   "token",
+		Opponent: "Penny",
+		Settings: classic,
+	}, nil)
+	w := matchmake(`{"name":"Sheldon","ruleset":"classic","best_of":3}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"game_id":"0000000000001234","side":"right","resume":"token","opponent":"Penny",`+
+		`"settings":{"ruleset":"classic","best_of":3}}`, w.Body.String())
+
+	matchmaker.EXPECT().Match(mock.Anything, mock.Anything).Times(2).Return(types.Match{}, types.ErrNoMatch)
+	w = matchmake(`{"name":"Sheldon","computer_fallback":true}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"computer":true}`, w.Body.String())
+	w = matchmake(`{"name":"Sheldon"}`)
+	assert.Equal(t, http.StatusRequestTimeout, w.Code)
+
+	// the players without a session get a new one, not the global scoreboard
+	matchmaker.EXPECT().Match(mock.Anything, mock.MatchedBy(func(r types.MatchRequest) bool {
+		return r.Owner != types.Global
+	})).Times(1).Return(types.Match{}, types.ErrNoMatch)
+	w = httptest.NewRecorder()
+	api.Matchmake(w, httptest.NewRequest(http.MethodPost, "/matchmake", strings.NewReader(`{"name":"Penny"}`)))
+	assert.Equal(t, http.StatusRequestTimeout, w.Code)
+	assert.Regexp(t, sessionRe, w.Header().Get(sessionHeader))
+
+	for _, body := range []string{`{"name":"Dr. Cooper"}`, `{"best_of":2}`, `{"ruleset":"chess"}`, `{`} {
+		assert.Equal(t, http.StatusBadRequest, matchmake(body).Code, body)
+	}
+
+	w = httptest.NewRecorder()
+	NewGameAPI(nil, nil, nil, zap.NewNop()).Matchmake(w, httptest.NewRequest(http.MethodPost, "/matchmake", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
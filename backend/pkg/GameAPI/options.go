@@ -0,0 +1,71 @@
+package gameapi
+
+import (
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg"
+)
+
+// Option configures the game API.
+type Option func(*gameAPI)
+
+// WithUserHeader makes the API trust the given request header to carry the
+// name of the authenticated user, e.g. set by an authenticating reverse proxy.
+// Requests with the header get the user's scoreboard instead of the session one.
+func WithUserHeader(header string) Option {
+	return func(a *gameAPI) {
+		a.userHeader = header
+	}
+}
+
+// WithHeartbeat pings the P2P WebSockets every interval and drops the connections
+// which don't answer within the timeout, it must be longer than the interval.
+func WithHeartbeat(interval, timeout time.Duration) Option {
+	return func(a *gameAPI) {
+		a.pingInterval = interval
+		a.pongTimeout = timeout
+	}
+}
+
+// WithInviteKey signs the invites to the P2P games with the key, so that they stay valid
+// across restarts and on every server sharing it. A random key is used by default or if it's empty.
+func WithInviteKey(key []byte) Option {
+	return func(a *gameAPI) {
+		a.inviteKey = key
+	}
+}
+
+// WithAdminToken enables the admin API for the requests with the bearer token.
+func WithAdminToken(token string) Option {
+	return func(a *gameAPI) {
+		a.adminToken = token
+	}
+}
+
+// WithMatchmaker enables the matchmaking API backed by the given matchmaker.
+func WithMatchmaker(matchmaker pkg.Matchmaker) Option {
+	return func(a *gameAPI) {
+		a.matchmaker = matchmaker
+	}
+}
+
+// WithRatings enables the leaderboard of the registered players.
+func WithRatings(ratings pkg.RatingStorage) Option {
+	return func(a *gameAPI) {
+		a.ratings = ratings
+	}
+}
+
+// WithStats enables the statistics API backed by the given provider.
+func WithStats(stats pkg.StatsProvider) Option {
+	return func(a *gameAPI) {
+		a.stats = stats
+	}
+}
+
+// WithNotifier enables streaming of the scoreboard changes from the given notifier.
+func WithNotifier(notifier pkg.ScoreNotifier) Option {
+	return func(a *gameAPI) {
+		a.notifier = notifier
+	}
+}
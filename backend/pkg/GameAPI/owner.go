@@ -0,0 +1,68 @@
+package gameapi
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+)
+
+const (
+	sessionCookie   = "rpssl_session"
+	sessionHeader   = "X-Session-ID"
+	sessionLifetime = 365 * 24 * time.Hour
+)
+
+var sessionRe = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// owner returns the scoreboard owner of the request, if the request has one.
+// Authenticated user takes precedence over the anonymous session.
+func (a *gameAPI) owner(r *http.Request) (types.Owner, bool) {
+	if a.userHeader != "" {
+		if user := r.Header.Get(a.userHeader); user != "" {
+			return types.UserOwner(user), true
+		}
+	}
+	if id := r.Header.Get(sessionHeader); sessionRe.MatchString(id) {
+		return types.SessionOwner(id), true
+	}
+	if c, err := r.Cookie(sessionCookie); err == nil && sessionRe.MatchString(c.Value) {
+		return types.SessionOwner(c.Value), true
+	}
+	return types.Global, false
+}
+
+// ensureOwner returns the scoreboard owner of the request, starting a new
+// anonymous session if there is none.
+func (a *gameAPI) ensureOwner(w http.ResponseWriter, r *http.Request) (types.Owner, error) {
+	if owner, ok := a.owner(r); ok {
+		return owner, nil
+	}
+
+	id, err := newSessionID()
+	if err != nil {
+		return types.Global, fmt.Errorf("new session: %w", err)
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   int(sessionLifetime.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	w.Header().Set(sessionHeader, id)
+	return types.SessionOwner(id), nil
+}
+
+func newSessionID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
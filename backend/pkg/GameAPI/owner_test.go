@@ -0,0 +1,139 @@
+package gameapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/mocks"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+const testSession = "0123456789abcdef0123456789abcdef"
+
+func TestOwner(t *testing.T) {
+	testCases := []struct {
+		name       string
+		userHeader string
+		headers    map[string]string
+		cookie     string
+		expected   types.Owner
+		found      bool
+	}{
+		{
+			name:     "no session",
+			expected: types.Global,
+		},
+		{
+			name:     "cookie",
+			cookie:   testSession,
+			expected: types.SessionOwner(testSession),
+			found:    true,
+		},
+		{
+			name:     "bad cookie",
+			cookie:   "../../etc/passwd",
+			expected: types.Global,
+		},
+		{
+			name:     "session header",
+			headers:  map[string]string{"X-Session-ID": testSession},
+			expected: types.SessionOwner(testSession),
+			found:    true,
+		},
+		{
+			name:       "user header",
+			userHeader: "X-Remote-User",
+			headers:    map[string]string{"X-Remote-User": "sheldon"},
+			cookie:     testSession,
+			expected:   types.UserOwner("sheldon"),
+			found:      true,
+		},
+		{
+			name:     "untrusted user header",
+			headers:  map[string]string{"X-Remote-User": "sheldon"},
+			cookie:   testSession,
+			expected: types.SessionOwner(testSession),
+			found:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			api := NewGameAPI(nil, nil, nil, zap.NewNop(), WithUserHeader(tc.userHeader)).(*gameAPI)
+
+			r := httptest.NewRequest(http.MethodGet, "/get_scores", nil)
+			for k, v := range tc.headers {
+				r.Header.Set(k, v)
+			}
+			if tc.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: sessionCookie, Value: tc.cookie})
+			}
+
+			owner, found := api.owner(r)
+			assert.Equal(t, tc.expected, owner)
+			assert.Equal(t, tc.found, found)
+		})
+	}
+}
+
+func TestEnsureOwner(t *testing.T) {
+	api := NewGameAPI(nil, nil, nil, zap.NewNop()).(*gameAPI)
+
+	// new session is started
+	w := httptest.NewRecorder()
+	owner, err := api.ensureOwner(w, httptest.NewRequest(http.MethodGet, "/get_scores", nil))
+	assert.NoError(t, err)
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, sessionCookie, cookies[0].Name)
+		assert.Equal(t, types.SessionOwner(cookies[0].Value), owner)
+		assert.Equal(t, cookies[0].Value, w.Header().Get(sessionHeader))
+	}
+
+	// existing session is kept
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/get_scores", nil)
+	r.AddCookie(&http.Cookie{Name: sessionCookie, Value: testSession})
+	owner, err = api.ensureOwner(w, r)
+	assert.NoError(t, err)
+	assert.Equal(t, types.SessionOwner(testSession), owner)
+	assert.Len(t, w.Result().Cookies(), 0)
+}
+
+func TestScoreboardScopes(t *testing.T) {
+	storage := mocks.NewStorageV2(t)
+	defer storage.AssertExpectations(t)
+	api := NewGameAPI(nil, nil, storage, zap.NewNop())
+
+	// own scoreboard
+	storage.EXPECT().GetLastRecords(mock.Anything, types.SessionOwner(testSession), 10).Times(1).
+		Return([]types.GameRecord{{Result: types.Lose}}, nil)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/get_scores", nil)
+	r.AddCookie(&http.Cookie{Name: sessionCookie, Value: testSession})
+	api.GetScores(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `["lose"]`, w.Body.String())
+
+	// aggregate of everyone
+	storage.EXPECT().GetLastRecords(mock.Anything, types.Global, 10).Times(1).
+		Return([]types.GameRecord{{Result: types.Win}, {Result: types.Lose}}, nil)
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/get_scores?scope=global", nil)
+	r.AddCookie(&http.Cookie{Name: sessionCookie, Value: testSession})
+	api.GetScores(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `["win","lose"]`, w.Body.String())
+
+	// clearing is always limited to own scoreboard
+	storage.EXPECT().ClearRecords(mock.Anything, types.SessionOwner(testSession)).Times(1).Return(nil)
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/clear_scores?scope=global", nil)
+	r.AddCookie(&http.Cookie{Name: sessionCookie, Value: testSession})
+	api.ClearScores(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
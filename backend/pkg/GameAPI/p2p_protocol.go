@@ -0,0 +1,295 @@
+package gameapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket subprotocols of the P2P games. The first version exchanges bare JSON messages,
+// the second one wraps every frame into an envelope and reports the fate of every client frame.
+const (
+	protocolV1 = "p2p"
+	protocolV2 = "p2p.v2"
+)
+
+// Types of the p2p.v2 frames
+const (
+	// server frames
+	frameHello = "hello"
+	frameState = "state"
+	frameChat  = "chat"
+	frameAck   = "ack"
+	frameError = "error"
+	frameLobby = "lobby"
+	// frameRestart tells that the server is restarting, the connection is closed with CloseServiceRestart then
+	frameRestart = "restart"
+	// client frames, besides chat
+	frameChoice = "choice"
+	frameEmote  = "emote"
+)
+
+// Codes of the p2p.v2 error frames
+const (
+	codeBadFrame    = "bad_frame"
+	codeUnknownType = "unknown_type"
+	codeBadChoice   = "bad_choice"
+	codeBadChat     = "bad_chat"
+	codeBadEmote    = "bad_emote"
+	codeNoPlayer    = "no_player"
+	codeRateLimited = "rate_limited"
+	codeRestarting  = "restarting"
+	codeInternal    = "internal"
+)
+
+// envelope is a p2p.v2 frame. Seq grows by one with every frame of its sender.
+type envelope struct {
+	Type    string          `json:"type"`
+	Seq     uint64          `json:"seq"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// helloPayload is sent first on a p2p.v2 connection
+type helloPayload struct {
+	Side string `json:"side"`
+	// Resume is the token to reconnect with to the same seat, spectators have none
+	Resume string `json:"resume,omitempty"`
+}
+
+// replyPayload is the payload of the ack and error frames, Seq is of the client frame
+type replyPayload struct {
+	Seq     uint64 `json:"seq"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type choicePayload struct {
+	Choice types.Choice `json:"choice"`
+}
+
+type chatPayload struct {
+	Text string `json:"text"`
+}
+
+type emotePayload struct {
+	Emote types.Emote `json:"emote"`
+}
+
+// messageToUser is a p2p v1 message, it has either the State of the game, a Chat message or the Restart notice
+type messageToUser struct {
+	Seq     uint64             `json:"seq"`
+	State   *types.Message     `json:"state,omitempty"`
+	Chat    *types.ChatMessage `json:"chat,omitempty"`
+	Restart *types.Restart     `json:"restart,omitempty"`
+	Side    string             `json:"side"`
+	// Resume is the token to reconnect with to the same seat, spectators have none
+	Resume string `json:"resume,omitempty"`
+}
+
+// messageFromUser is a p2p v1 message, it has either the Choice of the player, or their Chat text or Emote
+type messageFromUser struct {
+	Choice types.Choice `json:"choice"`
+	Chat   string       `json:"chat,omitempty"`
+	Emote  types.Emote  `json:"emote,omitempty"`
+}
+
+// command is a frame of the player in either protocol
+type command struct {
+	seq    uint64
+	kind   string
+	choice types.Choice
+	text   string
+	emote  types.Emote
+}
+
+// protocolError is reported to the client in an error frame
+type protocolError struct {
+	code string
+	err  error
+}
+
+func (e protocolError) Error() string {
+	return fmt.Sprintf("%s: %v", e.code, e.err)
+}
+
+func (e protocolError) Unwrap() error {
+	return e.err
+}
+
+// errorCode returns the code of the error frame for the error of handling a command
+func errorCode(err error) string {
+	var perr protocolError
+	switch {
+	case errors.As(err, &perr):
+		return perr.code
+	case errors.Is(err, types.ErrBadChat):
+		return codeBadChat
+	case errors.Is(err, types.ErrBadEmote):
+		return codeBadEmote
+	case errors.Is(err, types.ErrNoPlayer):
+		return codeNoPlayer
+	case errors.Is(err, types.ErrShuttingDown):
+		return codeRestarting
+	}
+	return codeInternal
+}
+
+// p2pConn speaks the negotiated protocol over a P2P WebSocket. Writes are safe for concurrent use.
+type p2pConn struct {
+	conn  *websocket.Conn
+	v2    bool
+	side  string
+	token string
+
+	mu  sync.Mutex
+	seq uint64
+}
+
+func newP2PConn(conn *websocket.Conn, side, token string) *p2pConn {
+	return &p2pConn{
+		conn:  conn,
+		v2:    conn.Subprotocol() == protocolV2,
+		side:  side,
+		token: token,
+	}
+}
+
+// write sends a p2p.v2 frame
+func (c *p2pConn) write(typ string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", typ, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.seq++
+	frame, err := json.Marshal(envelope{Type: typ, Seq: c.seq, Payload: data})
+	if err != nil {
+		return fmt.Errorf("marshal envelope: %w", err)
+	}
+	return c.send(frame)
+}
+
+// send writes the message, must be called with the lock held
+func (c *p2pConn) send(data []byte) error {
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// closeRestart tells the client the server is restarting and closes the connection
+func (c *p2pConn) closeRestart() {
+	msg := websocket.FormatCloseMessage(websocket.CloseServiceRestart, "server restarting")
+	c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeTimeout))
+	c.conn.Close()
+}
+
+// ping can be sent along with the other writes
+func (c *p2pConn) ping() error {
+	return c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout))
+}
+
+// hello introduces the connection to a p2p.v2 client
+func (c *p2pConn) hello() error {
+	if !c.v2 {
+		return nil
+	}
+	return c.write(frameHello, helloPayload{Side: c.side, Resume: c.token})
+}
+
+// update sends the state of the game or the chat message
+func (c *p2pConn) update(u types.Update) error {
+	if c.v2 {
+		if u.Chat != nil {
+			return c.write(frameChat, u.Chat)
+		}
+		if u.Restart != nil {
+			return c.write(frameRestart, u.Restart)
+		}
+		return c.write(frameState, u.State)
+	}
+
+	data, err := json.Marshal(messageToUser{
+		Seq:     u.Seq,
+		State:   u.State,
+		Chat:    u.Chat,
+		Restart: u.Restart,
+		Side:    c.side,
+		Resume:  c.token,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.send(data)
+}
+
+// reply acknowledges the command or reports its error, p2p v1 clients aren't told
+func (c *p2pConn) reply(cmd command, err error) error {
+	if !c.v2 {
+		return nil
+	}
+	if err != nil {
+		return c.write(frameError, replyPayload{Seq: cmd.seq, Code: errorCode(err), Message: err.Error()})
+	}
+	return c.write(frameAck, replyPayload{Seq: cmd.seq})
+}
+
+// parse reads the command from the frame of the negotiated protocol
+func (c *p2pConn) parse(data []byte) (command, error) {
+	if !c.v2 {
+		var message messageFromUser
+		if err := json.Unmarshal(data, &message); err != nil {
+			return command{}, protocolError{codeBadFrame, err}
+		}
+		switch {
+		case message.Chat != "":
+			return command{kind: frameChat, text: message.Chat}, nil
+		case message.Emote != "":
+			return command{kind: frameEmote, emote: message.Emote}, nil
+		}
+		return command{kind: frameChoice, choice: message.Choice}, nil
+	}
+
+	var frame envelope
+	if err := json.Unmarshal(data, &frame); err != nil {
+		return command{}, protocolError{codeBadFrame, err}
+	}
+	cmd := command{seq: frame.Seq, kind: frame.Type}
+	var err error
+	switch frame.Type {
+	case frameChoice:
+		var p choicePayload
+		if err = json.Unmarshal(frame.Payload, &p); err == nil && p.Choice == types.Undefined {
+			err = fmt.Errorf("no choice")
+		}
+		if err != nil {
+			return cmd, protocolError{codeBadChoice, err}
+		}
+		cmd.choice = p.Choice
+	case frameChat:
+		var p chatPayload
+		if err = json.Unmarshal(frame.Payload, &p); err != nil {
+			return cmd, protocolError{codeBadChat, err}
+		}
+		cmd.text = p.Text
+	case frameEmote:
+		var p emotePayload
+		if err = json.Unmarshal(frame.Payload, &p); err != nil {
+			return cmd, protocolError{codeBadEmote, err}
+		}
+		cmd.emote = p.Emote
+	default:
+		return cmd, protocolError{codeUnknownType, fmt.Errorf("unknown frame type %q", frame.Type)}
+	}
+	return cmd, nil
+}
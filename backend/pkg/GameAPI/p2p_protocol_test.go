@@ -0,0 +1,139 @@
+package gameapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	p2pgame "github.com/complynx/rpssl4bu/backend/pkg/p2p_game"
+	"github.com/complynx/rpssl4bu/backend/pkg/random"
+	"github.com/complynx/rpssl4bu/backend/pkg/storage"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// dialP2P connects to the game with the subprotocol
+func dialP2P(t *testing.T, srv *httptest.Server, query, protocol string) *websocket.Conn {
+	dialer := websocket.Dialer{Subprotocols: []string{protocol}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/connect_p2p?"+query, nil)
+	require.NoError(t, err)
+	require.Equal(t, protocol, conn.Subprotocol())
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readFrame reads p2p.v2 frames until one of the type
+func readFrame(t *testing.T, conn *websocket.Conn, typ string, payload any) envelope {
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		var frame envelope
+		require.NoError(t, conn.ReadJSON(&frame))
+		if frame.Type == typ {
+			if payload != nil {
+				require.NoError(t, json.Unmarshal(frame.Payload, payload))
+			}
+			return frame
+		}
+	}
+}
+
+// testState is the part of the state the test needs, undefined choices don't unmarshal
+type testState struct {
+	RightPlayerName string       `json:"right_player_name"`
+	Result          types.Result `json:"result"`
+}
+
+func TestP2PProtocol(t *testing.T) {
+	factory := p2pgame.NewGameFactory(random.NewSimpleRandom(""), storage.NewSimple(10), zap.NewNop())
+	defer factory.StopGames(context.Background())
+	game, err := factory.CreateGame(context.Background(), types.GameSettings{}, types.GameAccess{})
+	require.NoError(t, err)
+	api := NewGameAPI(nil, factory, nil, zap.NewNop())
+	srv := httptest.NewServer(http.HandlerFunc(api.ConnectP2P))
+	defer srv.Close()
+
+	v2 := dialP2P(t, srv, "g="+game.GetID().String()+"&name=Sheldon", protocolV2)
+	var hello helloPayload
+	frame := readFrame(t, v2, frameHello, &hello)
+	assert.Equal(t, uint64(1), frame.Seq)
+	assert.Equal(t, types.SideLeft, hello.Side)
+	assert.NotEmpty(t, hello.Resume)
+
+	// the current client keeps the bare messages
+	v1 := dialP2P(t, srv, "g="+game.GetID().String()+"&name=Penny", protocolV1)
+	var state testState
+	readFrame(t, v2, frameState, &state)
+	for state.RightPlayerName != "Penny" {
+		readFrame(t, v2, frameState, &state)
+	}
+
+	var reply replyPayload
+	require.NoError(t, v2.WriteMessage(websocket.TextMessage, []byte(`{"type":"choice","seq":1,"payload":{"choice":"rock"}}`)))
+	readFrame(t, v2, frameAck, &reply)
+	assert.Equal(t, replyPayload{Seq: 1}, reply)
+
+	for _, tc := range []struct {
+		frame string
+		reply replyPayload
+	}{
+		{`{"type":"choice",`, replyPayload{Code: codeBadFrame}},
+		{`{"type":"choice","seq":2,"payload":{"choice":9}}`, replyPayload{Seq: 2, Code: codeBadChoice}},
+		{`{"type":"choice","seq":3}`, replyPayload{Seq: 3, Code: codeBadChoice}},
+		{`{"type":"dance","seq":4}`, replyPayload{Seq: 4, Code: codeUnknownType}},
+		{`{"type":"emote","seq":5,"payload":{"emote":"dance"}}`, replyPayload{Seq: 5, Code: codeBadEmote}},
+		{`{"type":"chat","seq":6,"payload":{"text":" "}}`, replyPayload{Seq: 6, Code: codeBadChat}},
+	} {
+		require.NoError(t, v2.WriteMessage(websocket.TextMessage, []byte(tc.frame)))
+		reply = replyPayload{}
+		readFrame(t, v2, frameError, &reply)
+		assert.NotEmpty(t, reply.Message, tc.frame)
+		reply.Message = ""
+		assert.Equal(t, tc.reply, reply, tc.frame)
+	}
+
+	require.NoError(t, v2.WriteMessage(websocket.TextMessage, []byte(`{"type":"chat","seq":7,"payload":{"text":"Bazinga"}}`)))
+	// the relayed message and the ack come in any order
+	var chat types.ChatMessage
+	reply = replyPayload{}
+	for chat.Text == "" || reply.Seq == 0 {
+		var frame envelope
+		require.NoError(t, v2.ReadJSON(&frame))
+		switch frame.Type {
+		case frameChat:
+			require.NoError(t, json.Unmarshal(frame.Payload, &chat))
+		case frameAck:
+			require.NoError(t, json.Unmarshal(frame.Payload, &reply))
+		}
+	}
+	assert.Equal(t, "Bazinga", chat.Text)
+	assert.Equal(t, uint64(7), reply.Seq)
+
+	require.NoError(t, v1.WriteJSON(map[string]any{"choice": "paper"}))
+	v1.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		var msg struct {
+			State  *testState `json:"state"`
+			Side   string     `json:"side"`
+			Resume string     `json:"resume"`
+		}
+		require.NoError(t, v1.ReadJSON(&msg))
+		assert.Equal(t, types.SideRight, msg.Side)
+		assert.NotEmpty(t, msg.Resume)
+		if msg.State != nil && msg.State.Result != types.Unknown {
+			assert.Equal(t, types.Win, msg.State.Result)
+			break
+		}
+	}
+	readFrame(t, v2, frameState, &state)
+	for state.Result == types.Unknown {
+		readFrame(t, v2, frameState, &state)
+	}
+	assert.Equal(t, types.Lose, state.Result)
+}
@@ -0,0 +1,52 @@
+package gameapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/mocks"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// scoreRequest requests the score of the game as routed by chi
+func scoreRequest(id string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/p2p/"+id+"/score", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestP2PScore(t *testing.T) {
+	factory := mocks.NewP2PGameFactory(t)
+	game := mocks.NewP2PGame(t)
+	api := NewGameAPI(nil, factory, nil, zap.NewNop())
+	id := types.GameID(0x1234)
+
+	factory.EXPECT().GetGame(id).Times(1).Return(game, true)
+	game.EXPECT().Score().Times(1).Return(types.MatchScore{
+		Round:  4,
+		Left:   types.Tally{Win: 2, Tie: 1},
+		Right:  types.Tally{Lose: 2, Tie: 1},
+		Winner: types.SideLeft,
+	})
+	w := httptest.NewRecorder()
+	api.P2PScore(w, scoreRequest(id.String()))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"round":4,"left":{"win":2,"lose":0,"tie":1},"right":{"win":0,"lose":2,"tie":1},"winner":"left",`+
+		`"best_of":0,"over":false}`,
+		w.Body.String())
+
+	factory.EXPECT().GetGame(types.GameID(0x5678)).Times(1).Return(nil, false)
+	w = httptest.NewRecorder()
+	api.P2PScore(w, scoreRequest(types.GameID(0x5678).String()))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	api.P2PScore(w, scoreRequest("nope"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,86 @@
+package gameapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/mocks"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestRestoreScores(t *testing.T) {
+	storage := mocks.NewStorageV2(t)
+	defer storage.AssertExpectations(t)
+	api := NewGameAPI(nil, nil, storage, zap.NewNop())
+	owner := types.SessionOwner(testSession)
+	cleared := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	storage.EXPECT().RestoreRecords(mock.Anything, owner).Times(1).Return(types.Tombstone{
+		ID:      3,
+		Owner:   owner,
+		Time:    cleared,
+		Expires: cleared.Add(time.Hour),
+		Records: 5,
+	}, nil)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/scores/restore", nil)
+	r.AddCookie(&http.Cookie{Name: sessionCookie, Value: testSession})
+	api.RestoreScores(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"id":3,"owner":"session:`+testSession+`","time":"2023-04-01T00:00:00Z",`+
+		`"expires":"2023-04-01T01:00:00Z","records":5}`, w.Body.String())
+
+	storage.EXPECT().RestoreRecords(mock.Anything, owner).Times(1).Return(types.Tombstone{}, types.ErrNoTombstone)
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/scores/restore", nil)
+	r.AddCookie(&http.Cookie{Name: sessionCookie, Value: testSession})
+	api.RestoreScores(w, r)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// nothing was cleared without a session
+	w = httptest.NewRecorder()
+	api.RestoreScores(w, httptest.NewRequest(http.MethodPost, "/scores/restore", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	api.RestoreScores(w, httptest.NewRequest(http.MethodGet, "/scores/restore", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestAudit(t *testing.T) {
+	storage := mocks.NewStorageV2(t)
+	defer storage.AssertExpectations(t)
+	api := NewGameAPI(nil, nil, storage, zap.NewNop())
+	owner := types.SessionOwner(testSession)
+
+	storage.EXPECT().AuditLog(mock.Anything, owner, 5).Times(1).Return([]types.AuditEntry{{
+		ID:        2,
+		Time:      time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC),
+		Action:    types.AuditScoresCleared,
+		Owner:     owner,
+		Tombstone: 1,
+		Records:   5,
+	}}, nil)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/scores/audit?limit=5", nil)
+	r.AddCookie(&http.Cookie{Name: sessionCookie, Value: testSession})
+	api.Audit(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `[{"id":2,"time":"2023-04-01T00:00:00Z","action":"scores_cleared",`+
+		`"owner":"session:`+testSession+`","tombstone":1,"records":5}]`, w.Body.String())
+
+	// the log of everyone is never shown, it contains the sessions
+	w = httptest.NewRecorder()
+	api.Audit(w, httptest.NewRequest(http.MethodGet, "/scores/audit?scope=global", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `[]`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	api.Audit(w, httptest.NewRequest(http.MethodGet, "/scores/audit?limit=0", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,55 @@
+package gameapi
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// track registers the WebSocket connection to be closed on shutdown, untrack is to be called when its
+// handler returns. If the API is shutting down already the connection is closed and ok is false.
+func (a *gameAPI) track(c *p2pConn) (untrack func(), ok bool) {
+	a.connsMu.Lock()
+	if a.closing {
+		a.connsMu.Unlock()
+		c.closeRestart()
+		return nil, false
+	}
+	a.conns[c] = struct{}{}
+	a.handlers.Add(1)
+	a.connsMu.Unlock()
+
+	return func() {
+		a.connsMu.Lock()
+		delete(a.conns, c)
+		a.connsMu.Unlock()
+		a.handlers.Done()
+	}, true
+}
+
+func (a *gameAPI) Shutdown(ctx context.Context) {
+	a.connsMu.Lock()
+	a.closing = true
+	conns := make([]*p2pConn, 0, len(a.conns))
+	for c := range a.conns {
+		conns = append(conns, c)
+	}
+	a.connsMu.Unlock()
+
+	// the connections to the drained games are closed already, the rest are told about the restart now
+	for _, c := range conns {
+		c.closeRestart()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		a.handlers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		a.log.Info("WebSocket connections closed", zap.Int("connections", len(conns)))
+	case <-ctx.Done():
+		a.log.Warn("WebSocket handlers still running", zap.Error(ctx.Err()))
+	}
+}
@@ -0,0 +1,72 @@
+package gameapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	p2pgame "github.com/complynx/rpssl4bu/backend/pkg/p2p_game"
+	"github.com/complynx/rpssl4bu/backend/pkg/random"
+	"github.com/complynx/rpssl4bu/backend/pkg/storage"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// readClose reads the frames until the connection is closed and returns the close code
+func readClose(t *testing.T, conn *websocket.Conn) int {
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			var closeErr *websocket.CloseError
+			require.ErrorAs(t, err, &closeErr)
+			return closeErr.Code
+		}
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	factory := p2pgame.NewGameFactory(random.NewSimpleRandom(""), storage.NewSimple(10), zap.NewNop())
+	api := NewGameAPI(nil, factory, nil, zap.NewNop())
+	mux := http.NewServeMux()
+	mux.HandleFunc("/create_p2p", api.CreateP2P)
+	mux.HandleFunc("/connect_p2p", api.ConnectP2P)
+	mux.HandleFunc("/p2p/lobby", api.Lobby)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	game, err := factory.CreateGame(context.Background(), types.GameSettings{}, types.GameAccess{})
+	require.NoError(t, err)
+	player := dialP2P(t, srv, "g="+game.GetID().String()+"&name=Sheldon", protocolV2)
+	readFrame(t, player, frameState, nil)
+	lobby, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/p2p/lobby", nil)
+	require.NoError(t, err)
+	defer lobby.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	factory.Drain(ctx)
+	var restart types.Restart
+	readFrame(t, player, frameRestart, &restart)
+	deadline, _ := ctx.Deadline()
+	assert.WithinDuration(t, deadline, restart.Deadline, time.Millisecond)
+	assert.Equal(t, websocket.CloseServiceRestart, readClose(t, player))
+
+	resp, err := http.Post(srv.URL+"/create_p2p", "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	// the connections left are closed
+	api.Shutdown(ctx)
+	assert.Equal(t, websocket.CloseServiceRestart, readClose(t, lobby))
+	lobby, _, err = websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/p2p/lobby", nil)
+	require.NoError(t, err)
+	defer lobby.Close()
+	assert.Equal(t, websocket.CloseServiceRestart, readClose(t, lobby), "new connections are refused")
+}
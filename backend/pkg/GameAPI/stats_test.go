@@ -0,0 +1,93 @@
+package gameapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/mocks"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestStats(t *testing.T) {
+	stats := types.Stats{}
+	stats.Add(types.GameRecord{PlayerChoice: types.Rock, OpponentChoice: types.Lizard, Result: types.Win})
+
+	testCases := []struct {
+		name           string
+		url            string
+		setup          func(*mocks.StatsProvider)
+		expectedStatus int
+	}{
+		{
+			name: "own",
+			url:  "/stats",
+			setup: func(p *mocks.StatsProvider) {
+				p.EXPECT().GetStats(mock.Anything, types.SessionOwner(testSession)).Times(1).Return(stats, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "global",
+			url:  "/stats?scope=global",
+			setup: func(p *mocks.StatsProvider) {
+				p.EXPECT().GetStats(mock.Anything, types.Global).Times(1).Return(stats, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "player",
+			url:  "/stats?player=sheldon",
+			setup: func(p *mocks.StatsProvider) {
+				p.EXPECT().GetPlayerStats(mock.Anything, "sheldon").Times(1).Return(stats, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "error",
+			url:  "/stats",
+			setup: func(p *mocks.StatsProvider) {
+				p.EXPECT().GetStats(mock.Anything, mock.Anything).Times(1).Return(types.Stats{}, errors.New("test"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			provider := mocks.NewStatsProvider(t)
+			defer provider.AssertExpectations(t)
+			tc.setup(provider)
+			api := NewGameAPI(nil, nil, nil, zap.NewNop(), WithStats(provider))
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, tc.url, nil)
+			r.AddCookie(&http.Cookie{Name: sessionCookie, Value: testSession})
+			api.Stats(w, r)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusOK {
+				var got types.Stats
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+				assert.Equal(t, stats, got)
+			}
+		})
+	}
+}
+
+func TestStatsDisabled(t *testing.T) {
+	api := NewGameAPI(nil, nil, nil, zap.NewNop())
+
+	w := httptest.NewRecorder()
+	api.Stats(w, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	api.Stats(w, httptest.NewRequest(http.MethodPost, "/stats", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -0,0 +1,96 @@
+package gameapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"go.uber.org/zap"
+)
+
+const (
+	// streamRetry tells the browser how soon to reconnect
+	streamRetry = 3 * time.Second
+	// streamHeartbeat keeps idle streams from being closed by proxies
+	streamHeartbeat = 30 * time.Second
+)
+
+func (a *gameAPI) StreamScores(w http.ResponseWriter, r *http.Request) {
+	defer a.doRecover(w)
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if a.notifier == nil {
+		httpCode(w, http.StatusNotFound)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		a.sendErr(fmt.Errorf("streaming is not supported"), w, http.StatusInternalServerError)
+		return
+	}
+
+	var lastID uint64
+	if s := r.Header.Get("Last-Event-ID"); s != "" {
+		var err error
+		lastID, err = strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("bad Last-Event-ID: %q", s), http.StatusBadRequest)
+			return
+		}
+	}
+	owner := types.Global
+	if r.URL.Query().Get("scope") != "global" {
+		var err error
+		owner, err = a.ensureOwner(w, r)
+		if err != nil {
+			a.sendErr(err, w, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	events, missed := a.notifier.Subscribe(r.Context(), owner, lastID)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+	if missed {
+		// some changes are lost, the scoreboard has to be fetched again
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				// dropped as too slow, the browser reconnects with the last ID it got
+				return
+			}
+			data, err := json.Marshal(ev.Public())
+			if err != nil {
+				a.log.Error("Failed to marshal score event", zap.Error(err))
+				return
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, data); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
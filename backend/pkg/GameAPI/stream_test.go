@@ -0,0 +1,72 @@
+package gameapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/mocks"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestStreamScores(t *testing.T) {
+	notifier := mocks.NewScoreNotifier(t)
+	defer notifier.AssertExpectations(t)
+	api := NewGameAPI(nil, nil, nil, zap.NewNop(), WithNotifier(notifier))
+
+	// the stream ends when the notifier closes the channel
+	events := make(chan types.ScoreEvent, 2)
+	events <- types.ScoreEvent{
+		ID:     5,
+		Type:   types.ScorePlayed,
+		Owner:  types.SessionOwner(testSession),
+		Record: &types.GameRecord{ID: 3, Owner: types.SessionOwner(testSession), Result: types.Win},
+	}
+	events <- types.ScoreEvent{ID: 6, Type: types.ScoresCleared}
+	close(events)
+	notifier.EXPECT().Subscribe(mock.Anything, types.SessionOwner(testSession), uint64(4)).
+		Times(1).Return((<-chan types.ScoreEvent)(events), true)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/scores/stream", nil)
+	r.AddCookie(&http.Cookie{Name: sessionCookie, Value: testSession})
+	r.Header.Set("Last-Event-ID", "4")
+	api.StreamScores(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	// the sessions are secret, even the own one
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "retry: 3000\n\n"+
+		"event: reset\ndata: {}\n\n"+
+		"id: 5\nevent: played\ndata: "+
+		`{"id":5,"type":"played","record":{"id":3,"time":"0001-01-01T00:00:00Z","mode":"computer","player_name":"","opponent_name":"","result":"win"}}`+"\n\n"+
+		"id: 6\nevent: cleared\ndata: "+`{"id":6,"type":"cleared"}`+"\n\n", w.Body.String())
+
+	empty := make(chan types.ScoreEvent)
+	close(empty)
+	notifier.EXPECT().Subscribe(mock.Anything, types.Global, uint64(0)).
+		Times(1).Return((<-chan types.ScoreEvent)(empty), false)
+	w = httptest.NewRecorder()
+	api.StreamScores(w, httptest.NewRequest(http.MethodGet, "/scores/stream?scope=global", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "retry: 3000\n\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/scores/stream", nil)
+	r.Header.Set("Last-Event-ID", "nope")
+	api.StreamScores(w, r)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	api.StreamScores(w, httptest.NewRequest(http.MethodPost, "/scores/stream", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestStreamScoresDisabled(t *testing.T) {
+	api := NewGameAPI(nil, nil, nil, zap.NewNop())
+	w := httptest.NewRecorder()
+	api.StreamScores(w, httptest.NewRequest(http.MethodGet, "/scores/stream", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,53 @@
+package eventlog
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Every entry of the log and every snapshot is a frame: a header of the payload
+// length and its CRC-32C checksum, both little endian uint32, then the payload.
+const headerSize = 8
+
+// maxFrameSize guards against allocating garbage lengths of corrupted headers
+const maxFrameSize = 1 << 30
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrCorrupt is returned for frames with wrong checksums or lengths.
+var ErrCorrupt = errors.New("corrupt frame")
+
+func writeFrame(w io.Writer, payload []byte) error {
+	buf := make([]byte, headerSize+len(payload))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buf[4:8], crc32.Checksum(payload, crcTable))
+	copy(buf[headerSize:], payload)
+	_, err := w.Write(buf)
+	return err
+}
+
+// readFrame returns io.EOF if there are no more frames, io.ErrUnexpectedEOF for a torn frame
+func readFrame(r io.Reader) ([]byte, error) {
+	var header [headerSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	size := binary.LittleEndian.Uint32(header[0:4])
+	if size > maxFrameSize {
+		return nil, fmt.Errorf("%w: length %d", ErrCorrupt, size)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if crc32.Checksum(payload, crcTable) != binary.LittleEndian.Uint32(header[4:8]) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrCorrupt)
+	}
+	return payload, nil
+}
@@ -0,0 +1,131 @@
+package eventlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	snapshotPrefix = "snapshot-"
+	snapshotExt    = ".snap"
+	// besides the newest snapshot, older ones are kept as a fallback in case it's corrupt
+	keepSnapshots = 2
+)
+
+// WriteSnapshot stores the state as of the event seq to dir. The snapshot file appears
+// atomically, so a crash never leaves a partial one. Older snapshots are removed,
+// except for the previous one.
+func WriteSnapshot(dir string, seq uint64, state any) error {
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, snapshotPrefix+"*.tmp")
+	if err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	err = writeFrame(w, payload)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, snapshotName(seq))); err != nil {
+		return fmt.Errorf("rename: %w", err)
+	}
+	if err := syncDir(dir); err != nil {
+		return err
+	}
+
+	seqs, err := snapshots(dir)
+	if err != nil {
+		return err
+	}
+	for i := keepSnapshots; i < len(seqs); i++ {
+		if err := os.Remove(filepath.Join(dir, snapshotName(seqs[i]))); err != nil {
+			return fmt.Errorf("remove old snapshot: %w", err)
+		}
+	}
+	return nil
+}
+
+// ReadSnapshot loads the newest intact snapshot of dir into the state and returns
+// the sequence number of the last event it includes, zero if there are no snapshots.
+func ReadSnapshot(dir string, state any) (uint64, error) {
+	seqs, err := snapshots(dir)
+	if err != nil {
+		return 0, err
+	}
+	var errs []string
+	for _, seq := range seqs {
+		err := readSnapshot(filepath.Join(dir, snapshotName(seq)), state)
+		if err == nil {
+			return seq, nil
+		}
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return 0, fmt.Errorf("no intact snapshots: %s", strings.Join(errs, "; "))
+	}
+	return 0, nil
+}
+
+func readSnapshot(path string, state any) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	payload, err := readFrame(bufio.NewReader(f))
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if err := json.Unmarshal(payload, state); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+func snapshotName(seq uint64) string {
+	return fmt.Sprintf("%s%020d%s", snapshotPrefix, seq, snapshotExt)
+}
+
+// snapshots lists sequence numbers of the snapshots in dir, newest first
+func snapshots(dir string) ([]uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read directory: %w", err)
+	}
+	var ret []uint64
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, snapshotPrefix) || !strings.HasSuffix(name, snapshotExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, snapshotPrefix), snapshotExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		ret = append(ret, seq)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i] > ret[j] })
+	return ret, nil
+}
@@ -0,0 +1,270 @@
+package eventlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/complynx/rpssl4bu/backend/pkg"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+)
+
+// DefaultSegmentSize is the size after which the log starts a new segment file.
+const DefaultSegmentSize = 16 << 20
+
+const segmentExt = ".wal"
+
+// segment file is named by the sequence number of its first event
+type segment struct {
+	first uint64
+	path  string
+}
+
+type wal struct {
+	dir         string
+	segmentSize int64
+
+	mu       sync.Mutex
+	segments []segment
+	// last segment, open for appending
+	file    *os.File
+	size    int64
+	lastSeq uint64
+}
+
+// Open opens the log in dir, creating the directory if needed. Events are appended to
+// segment files, a new one is started when the current one grows over segmentSize.
+// A torn or corrupt tail of the last segment, left by a crash in the middle of an append,
+// is truncated. Corruption anywhere else is an error.
+func Open(dir string, segmentSize int64) (pkg.EventLog, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create directory: %w", err)
+	}
+	l := &wal{
+		dir:         dir,
+		segmentSize: segmentSize,
+	}
+	if err := l.load(); err != nil {
+		if l.file != nil {
+			l.file.Close()
+		}
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *wal) load() error {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return fmt.Errorf("read directory: %w", err)
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		first, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			return fmt.Errorf("bad segment name %q", name)
+		}
+		l.segments = append(l.segments, segment{first: first, path: filepath.Join(l.dir, name)})
+	}
+	sort.Slice(l.segments, func(i, j int) bool {
+		return l.segments[i].first < l.segments[j].first
+	})
+
+	for i, seg := range l.segments {
+		last := i == len(l.segments)-1
+		if i > 0 && seg.first != l.lastSeq+1 {
+			return fmt.Errorf("segment %s: starts at %d after event %d", seg.path, seg.first, l.lastSeq)
+		}
+		l.lastSeq = seg.first - 1
+
+		var good int64
+		err := scanSegment(seg, func(ev types.Event, end int64) error {
+			if ev.Seq != l.lastSeq+1 {
+				return fmt.Errorf("%w: event %d after %d", ErrCorrupt, ev.Seq, l.lastSeq)
+			}
+			l.lastSeq = ev.Seq
+			good = end
+			return nil
+		})
+		if err != nil && !(last && (errors.Is(err, ErrCorrupt) || errors.Is(err, io.ErrUnexpectedEOF))) {
+			return fmt.Errorf("segment %s: %w", seg.path, err)
+		}
+		if !last {
+			continue
+		}
+
+		l.file, err = os.OpenFile(seg.path, os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			return fmt.Errorf("open segment: %w", err)
+		}
+		// drops the torn tail, if there is one
+		if err := l.file.Truncate(good); err != nil {
+			return fmt.Errorf("truncate segment: %w", err)
+		}
+		l.size = good
+	}
+	return nil
+}
+
+// scanSegment calls fn with every event of the segment and the offset right after it
+func scanSegment(seg segment, fn func(ev types.Event, end int64) error) error {
+	f, err := os.Open(seg.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var offset int64
+	for {
+		payload, err := readFrame(r)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("at offset %d: %w", offset, err)
+		}
+		var ev types.Event
+		if err := json.Unmarshal(payload, &ev); err != nil {
+			return fmt.Errorf("at offset %d: %w: %v", offset, ErrCorrupt, err)
+		}
+		offset += int64(headerSize + len(payload))
+		if err := fn(ev, offset); err != nil {
+			return err
+		}
+	}
+}
+
+func (l *wal) Append(ev types.Event) (types.Event, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ev.Seq = l.lastSeq + 1
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return ev, fmt.Errorf("marshal: %w", err)
+	}
+	frameSize := int64(headerSize + len(payload))
+	if l.file == nil || l.size > 0 && l.size+frameSize > l.segmentSize {
+		if err := l.rotate(ev.Seq); err != nil {
+			return ev, fmt.Errorf("rotate: %w", err)
+		}
+	}
+
+	if err := writeFrame(l.file, payload); err != nil {
+		// so that the next event isn't appended after a partial one
+		l.file.Truncate(l.size)
+		return ev, fmt.Errorf("write: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		l.file.Truncate(l.size)
+		return ev, fmt.Errorf("sync: %w", err)
+	}
+	l.size += frameSize
+	l.lastSeq = ev.Seq
+	return ev, nil
+}
+
+// rotate starts a new segment with the event first
+func (l *wal) rotate(first uint64) error {
+	if l.file != nil {
+		if err := l.file.Close(); err != nil {
+			return fmt.Errorf("close segment: %w", err)
+		}
+		l.file = nil
+	}
+	seg := segment{
+		first: first,
+		path:  filepath.Join(l.dir, fmt.Sprintf("%020d%s", first, segmentExt)),
+	}
+	f, err := os.OpenFile(seg.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("create segment: %w", err)
+	}
+	if err := syncDir(l.dir); err != nil {
+		f.Close()
+		return err
+	}
+	l.file = f
+	l.size = 0
+	l.segments = append(l.segments, seg)
+	return nil
+}
+
+func (l *wal) Replay(from uint64, fn func(types.Event) error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i, seg := range l.segments {
+		if i+1 < len(l.segments) && l.segments[i+1].first <= from {
+			continue
+		}
+		err := scanSegment(seg, func(ev types.Event, end int64) error {
+			if ev.Seq < from || ev.Seq > l.lastSeq {
+				return nil
+			}
+			return fn(ev)
+		})
+		if err != nil {
+			return fmt.Errorf("segment %s: %w", seg.path, err)
+		}
+	}
+	return nil
+}
+
+func (l *wal) LastSeq() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.lastSeq
+}
+
+func (l *wal) Prune(before uint64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// the last segment is never removed, it keeps the sequence going
+	for len(l.segments) > 1 && l.segments[1].first <= before {
+		if err := os.Remove(l.segments[0].path); err != nil {
+			return fmt.Errorf("remove segment: %w", err)
+		}
+		l.segments = l.segments[1:]
+	}
+	return nil
+}
+
+func (l *wal) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// syncDir makes created and renamed files of the directory durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("open directory: %w", err)
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("sync directory: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package eventlog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func played(result types.Result) types.Event {
+	return types.Event{
+		Type:   types.EventGamePlayed,
+		Time:   time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC),
+		Record: &types.GameRecord{Result: result},
+	}
+}
+
+func seqs(t *testing.T, l pkg.EventLog, from uint64) []uint64 {
+	var ret []uint64
+	require.NoError(t, l.Replay(from, func(ev types.Event) error {
+		ret = append(ret, ev.Seq)
+		return nil
+	}))
+	return ret
+}
+
+func segmentFiles(t *testing.T, dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	require.NoError(t, err)
+	return files
+}
+
+func TestWAL(t *testing.T) {
+	dir := t.TempDir()
+	// every segment fits two events
+	l, err := Open(dir, 400)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), l.LastSeq())
+
+	for i := 1; i <= 5; i++ {
+		ev, err := l.Append(played(types.Win))
+		require.NoError(t, err)
+		assert.Equal(t, uint64(i), ev.Seq)
+	}
+	assert.Equal(t, uint64(5), l.LastSeq())
+	assert.Len(t, segmentFiles(t, dir), 3)
+
+	var events []types.Event
+	require.NoError(t, l.Replay(4, func(ev types.Event) error {
+		events = append(events, ev)
+		return nil
+	}))
+	assert.Equal(t, []types.Event{
+		{Seq: 4, Type: types.EventGamePlayed, Time: played(types.Win).Time, Record: &types.GameRecord{Result: types.Win}},
+		{Seq: 5, Type: types.EventGamePlayed, Time: played(types.Win).Time, Record: &types.GameRecord{Result: types.Win}},
+	}, events)
+	require.NoError(t, l.Close())
+
+	// the sequence continues after reopening
+	l, err = Open(dir, 400)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), l.LastSeq())
+	ev, err := l.Append(played(types.Lose))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(6), ev.Seq)
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5, 6}, seqs(t, l, 0))
+
+	// only whole segments older than the event are pruned
+	require.NoError(t, l.Prune(4))
+	assert.Equal(t, []uint64{3, 4, 5, 6}, seqs(t, l, 0))
+	require.NoError(t, l.Prune(100))
+	assert.Equal(t, []uint64{5, 6}, seqs(t, l, 0))
+	require.NoError(t, l.Close())
+
+	l, err = Open(dir, 400)
+	require.NoError(t, err)
+	defer l.Close()
+	assert.Equal(t, uint64(6), l.LastSeq())
+	assert.Equal(t, []uint64{5, 6}, seqs(t, l, 0))
+}
+
+func TestWALTornTail(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, DefaultSegmentSize)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err := l.Append(played(types.Tie))
+		require.NoError(t, err)
+	}
+	require.NoError(t, l.Close())
+
+	// crash in the middle of the last append
+	path := segmentFiles(t, dir)[0]
+	stat, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, stat.Size()-5))
+
+	l, err = Open(dir, DefaultSegmentSize)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), l.LastSeq())
+	ev, err := l.Append(played(types.Win))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), ev.Seq)
+	assert.Equal(t, []uint64{1, 2, 3}, seqs(t, l, 0))
+	require.NoError(t, l.Close())
+
+	// a flipped byte is caught by the checksum, the tail is dropped from there
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[len(data)-2] ^= 0xff
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	l, err = Open(dir, DefaultSegmentSize)
+	require.NoError(t, err)
+	defer l.Close()
+	assert.Equal(t, uint64(2), l.LastSeq())
+}
+
+func TestWALCorruptSegment(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, 400)
+	require.NoError(t, err)
+	for i := 0; i < 4; i++ {
+		_, err := l.Append(played(types.Win))
+		require.NoError(t, err)
+	}
+	require.NoError(t, l.Close())
+
+	// only the tail of the last segment may be lost, older events can't be skipped
+	path := segmentFiles(t, dir)[0]
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[headerSize+1] ^= 0xff
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	_, err = Open(dir, 400)
+	assert.ErrorIs(t, err, ErrCorrupt)
+}
+
+func TestSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	var state []int
+
+	seq, err := ReadSnapshot(dir, &state)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), seq)
+
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, WriteSnapshot(dir, uint64(i*10), []int{i}))
+	}
+	files, err := filepath.Glob(filepath.Join(dir, snapshotPrefix+"*"))
+	require.NoError(t, err)
+	assert.Len(t, files, keepSnapshots)
+
+	seq, err = ReadSnapshot(dir, &state)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(30), seq)
+	assert.Equal(t, []int{3}, state)
+
+	// corrupt snapshot falls back to the previous one
+	path := filepath.Join(dir, snapshotName(30))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[len(data)-1] ^= 0xff
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	seq, err = ReadSnapshot(dir, &state)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(20), seq)
+	assert.Equal(t, []int{2}, state)
+
+	require.NoError(t, os.Truncate(filepath.Join(dir, snapshotName(20)), 3))
+	_, err = ReadSnapshot(dir, &state)
+	assert.Error(t, err)
+}
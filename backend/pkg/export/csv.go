@@ -0,0 +1,96 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+)
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	ret := &csvWriter{w: csv.NewWriter(w)}
+	if err := ret.w.Write(columns); err != nil {
+		return nil, fmt.Errorf("write header: %w", err)
+	}
+	return ret, nil
+}
+
+func (w *csvWriter) Write(r types.GameRecord) error {
+	return w.w.Write(toRow(r).fields())
+}
+
+func (w *csvWriter) Flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func (w *csvWriter) Close() error {
+	return w.Flush()
+}
+
+type csvReader struct {
+	r *csv.Reader
+	// index of every known column in the file, so that columns may come in any order
+	index []int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	ret := &csvReader{
+		r:     csv.NewReader(r),
+		index: make([]int, len(columns)),
+	}
+	ret.r.FieldsPerRecord = -1
+
+	header, err := ret.r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	for i, column := range columns {
+		ret.index[i] = -1
+		for j, name := range header {
+			if name == column {
+				ret.index[i] = j
+				break
+			}
+		}
+	}
+	for _, required := range []int{2, 3, 9} { // time, mode, result
+		if ret.index[required] < 0 {
+			return nil, fmt.Errorf("missing column %q", columns[required])
+		}
+	}
+	return ret, nil
+}
+
+func (r *csvReader) Read() (types.GameRecord, error) {
+	line, err := r.r.Read()
+	if err != nil {
+		return types.GameRecord{}, err
+	}
+	fields := make([]string, len(columns))
+	for i, j := range r.index {
+		if j >= 0 && j < len(line) {
+			fields[i] = line[j]
+		}
+	}
+	row, err := rowFromFields(fields)
+	if err != nil {
+		line, _ := r.r.FieldPos(0)
+		return types.GameRecord{}, fmt.Errorf("line %d: %w", line, err)
+	}
+	record, err := row.record()
+	if err != nil {
+		line, _ := r.r.FieldPos(0)
+		return types.GameRecord{}, fmt.Errorf("line %d: %w", line, err)
+	}
+	return record, nil
+}
+
+func (r *csvReader) Close() error {
+	return nil
+}
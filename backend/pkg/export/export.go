@@ -0,0 +1,72 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/complynx/rpssl4bu/backend/pkg"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+)
+
+// records are read from the storage in pages of this size, so memory stays bounded
+const pageSize = 500
+
+// Export writes all the owner's records, oldest first, and closes the writer.
+// Global owner exports the records of everyone, with the mirror records of the P2P rounds.
+func Export(ctx context.Context, storage pkg.StorageV2, owner types.Owner, w Writer) (int, error) {
+	n := 0
+	q := types.HistoryQuery{
+		Owner:     owner,
+		Limit:     pageSize,
+		Ascending: true,
+		Mirrors:   true,
+	}
+	for {
+		page, err := storage.History(ctx, q)
+		if err != nil {
+			return n, fmt.Errorf("history: %w", err)
+		}
+		for _, r := range page.Records {
+			if err := w.Write(r); err != nil {
+				return n, fmt.Errorf("write record: %w", err)
+			}
+			n++
+		}
+		if page.Cursor == "" {
+			break
+		}
+		if err := w.Flush(); err != nil {
+			return n, fmt.Errorf("flush: %w", err)
+		}
+		q.After = page.Records[len(page.Records)-1].ID
+	}
+	if err := w.Close(); err != nil {
+		return n, fmt.Errorf("close: %w", err)
+	}
+	return n, nil
+}
+
+// Import adds all the records of the reader to the storage, which assigns them new IDs.
+// Unless the owner is global, it replaces the owners of the records.
+func Import(ctx context.Context, storage pkg.StorageV2, r Reader, owner types.Owner) (int, error) {
+	n := 0
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return n, nil
+		}
+		if err != nil {
+			return n, fmt.Errorf("read record %d: %w", n+1, err)
+		}
+		record.ID = 0
+		if owner != types.Global {
+			record.Owner = owner
+		}
+		if err := storage.AddRecord(ctx, record); err != nil {
+			return n, fmt.Errorf("add record: %w", err)
+		}
+		n++
+	}
+}
@@ -0,0 +1,217 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/storage"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFormat(t *testing.T) {
+	for _, f := range []Format{CSV, NDJSON, Parquet} {
+		parsed, err := ParseFormat(string(f))
+		assert.NoError(t, err)
+		assert.Equal(t, f, parsed)
+	}
+	_, err := ParseFormat("xlsx")
+	assert.Error(t, err)
+}
+
+func testRecords(n int) []types.GameRecord {
+	sheldon := types.SessionOwner("00000000000000000000000000000001")
+	penny := types.UserOwner("penny")
+	start := time.Date(2023, 4, 1, 0, 0, 0, 123, time.UTC)
+
+	records := make([]types.GameRecord, n)
+	for i := range records {
+		records[i] = types.GameRecord{
+			Owner:          sheldon,
+			Time:           start.Add(time.Duration(i) * time.Minute),
+			Mode:           types.ModeComputer,
+			PlayerChoice:   types.Choice(i%5 + 1),
+			OpponentChoice: types.Choice((i+2)%5 + 1),
+			Result:         types.Result(i % 3),
+			Strategy:       "random",
+			RNGSource:      "internal",
+		}
+		if i%2 == 1 {
+			records[i].Owner = penny
+			records[i].Mode = types.ModeP2P
+			records[i].GameID = types.GameID(0xdead)
+			records[i].PlayerName = "Penny, \"the neighbour\""
+			records[i].OpponentName = "Sheldon"
+			records[i].Mirror = i%4 == 3
+		}
+	}
+	// legacy record without choices and owner
+	records[n-1].Owner = types.Global
+	records[n-1].PlayerChoice = types.Undefined
+	records[n-1].OpponentChoice = types.Undefined
+	return records
+}
+
+func TestRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	// more than a page, so that export continues after the first one
+	records := testRecords(pageSize + 3)
+
+	for _, format := range []Format{CSV, NDJSON, Parquet} {
+		t.Run(string(format), func(t *testing.T) {
+			src := storage.NewSimple(len(records))
+			for _, r := range records {
+				require.NoError(t, src.AddRecord(ctx, r))
+			}
+
+			buf := &bytes.Buffer{}
+			w, err := NewWriter(buf, format)
+			require.NoError(t, err)
+			n, err := Export(ctx, src, types.Global, w)
+			require.NoError(t, err)
+			assert.Equal(t, len(records), n)
+
+			dst := storage.NewSimple(len(records))
+			r, err := NewReader(buf, format)
+			require.NoError(t, err)
+			defer r.Close()
+			n, err = Import(ctx, dst, r, types.Global)
+			require.NoError(t, err)
+			assert.Equal(t, len(records), n)
+
+			expected, err := src.History(ctx, types.HistoryQuery{Limit: len(records)})
+			require.NoError(t, err)
+			imported, err := dst.History(ctx, types.HistoryQuery{Limit: len(records)})
+			require.NoError(t, err)
+			require.Len(t, imported.Records, len(expected.Records))
+			for i := range expected.Records {
+				assert.True(t, expected.Records[i].Time.Equal(imported.Records[i].Time))
+				imported.Records[i].Time = expected.Records[i].Time
+			}
+			assert.Equal(t, expected.Records, imported.Records)
+		})
+	}
+}
+
+func TestExportOwner(t *testing.T) {
+	ctx := context.Background()
+	records := testRecords(5)
+	s := storage.NewSimple(10)
+	for _, r := range records {
+		require.NoError(t, s.AddRecord(ctx, r))
+	}
+
+	buf := &bytes.Buffer{}
+	n, err := Export(ctx, s, types.UserOwner("penny"), newNDJSONWriter(buf))
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 2, strings.Count(buf.String(), "\n"))
+	assert.Equal(t, 2, strings.Count(buf.String(), `"owner":"user:penny"`))
+}
+
+func TestImportOwner(t *testing.T) {
+	ctx := context.Background()
+	owner := types.UserOwner("amy")
+	s := storage.NewSimple(10)
+	r, err := NewReader(strings.NewReader(
+		"result,time,mode,owner\n"+
+			"win,2023-04-01T00:00:00Z,computer,user:penny\n"+
+			"tie,2023-04-01T00:01:00Z,p2p,\n",
+	), CSV)
+	require.NoError(t, err)
+
+	n, err := Import(ctx, s, r, owner)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	records, err := s.GetLastRecords(ctx, owner, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []types.GameRecord{
+		{ID: 2, Owner: owner, Time: time.Date(2023, 4, 1, 0, 1, 0, 0, time.UTC), Mode: types.ModeP2P, Result: types.Tie},
+		{ID: 1, Owner: owner, Time: time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC), Mode: types.ModeComputer, Result: types.Win},
+	}, records)
+}
+
+func TestImportErrors(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name   string
+		format Format
+		data   string
+	}{
+		{"csv missing column", CSV, "time,mode\n2023-04-01T00:00:00Z,computer\n"},
+		{"csv bad time", CSV, "time,mode,result\nyesterday,computer,win\n"},
+		{"csv bad choice", CSV, "time,mode,result,player_choice\n2023-04-01T00:00:00Z,computer,win,sheldon\n"},
+		{"csv bad result", CSV, "time,mode,result\n2023-04-01T00:00:00Z,computer,draw\n"},
+		{"ndjson", NDJSON, `{"time":"2023-04-01T00:00:00Z","result":"draw"}`},
+		{"parquet", Parquet, "not a parquet file"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewReader(strings.NewReader(tc.data), tc.format)
+			if err != nil {
+				return
+			}
+			defer r.Close()
+			_, err = Import(ctx, storage.NewSimple(10), r, types.Global)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParquetFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.parquet")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	w, err := NewWriter(f, Parquet)
+	require.NoError(t, err)
+	for _, r := range testRecords(3) {
+		require.NoError(t, w.Write(r))
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+
+	f, err = os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	r, err := NewReader(f, Parquet)
+	require.NoError(t, err)
+	defer r.Close()
+	assert.Nil(t, r.(*parquetReader).tmp, "files are read in place")
+
+	for i := 0; i < 3; i++ {
+		_, err := r.Read()
+		require.NoError(t, err)
+	}
+	_, err = r.Read()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestParquetWithoutMirror(t *testing.T) {
+	// files exported before the mirror column was added
+	type legacyRow struct {
+		Time   time.Time `parquet:"time,timestamp(nanosecond)"`
+		Mode   string    `parquet:"mode"`
+		Result string    `parquet:"result"`
+	}
+	buf := &bytes.Buffer{}
+	w := parquet.NewGenericWriter[legacyRow](buf)
+	_, err := w.Write([]legacyRow{{Time: time.Unix(42, 0).UTC(), Mode: "p2p", Result: "win"}})
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	r, err := NewReader(buf, Parquet)
+	require.NoError(t, err)
+	defer r.Close()
+	record, err := r.Read()
+	require.NoError(t, err)
+	assert.Equal(t, types.ModeP2P, record.Mode)
+	assert.False(t, record.Mirror)
+}
@@ -0,0 +1,111 @@
+package export
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+)
+
+// Format is a file format of exported game records.
+type Format string
+
+const (
+	CSV     Format = "csv"
+	NDJSON  Format = "ndjson"
+	Parquet Format = "parquet"
+)
+
+// ParseFormat returns the format by its name.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case CSV, NDJSON, Parquet:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown format: %q", s)
+}
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case CSV:
+		return "text/csv"
+	case NDJSON:
+		return "application/x-ndjson"
+	case Parquet:
+		return "application/vnd.apache.parquet"
+	}
+	return "application/octet-stream"
+}
+
+// Writer encodes game records one by one.
+type Writer interface {
+	Write(types.GameRecord) error
+	// Flush writes buffered records to the underlying writer.
+	Flush() error
+	// Close flushes the records and finishes the file, the underlying writer is left open.
+	Close() error
+}
+
+// Public returns the writer of the records as they may be shown to others, without the sessions of the owners.
+func Public(w Writer) Writer {
+	return publicWriter{w}
+}
+
+type publicWriter struct {
+	Writer
+}
+
+func (w publicWriter) Write(r types.GameRecord) error {
+	return w.Writer.Write(r.Public())
+}
+
+// SinglePlayer returns the reader refusing the P2P records, they can be trusted only as stored by the server.
+func SinglePlayer(r Reader) Reader {
+	return singlePlayerReader{r}
+}
+
+type singlePlayerReader struct {
+	Reader
+}
+
+func (r singlePlayerReader) Read() (types.GameRecord, error) {
+	record, err := r.Reader.Read()
+	if err == nil && record.Mode == types.ModeP2P {
+		return types.GameRecord{}, fmt.Errorf("P2P records can't be imported")
+	}
+	return record, err
+}
+
+// Reader decodes game records one by one, Read returns io.EOF after the last record.
+type Reader interface {
+	Read() (types.GameRecord, error)
+	Close() error
+}
+
+// NewWriter returns a writer of the records in the format.
+func NewWriter(w io.Writer, format Format) (Writer, error) {
+	switch format {
+	case CSV:
+		return newCSVWriter(w)
+	case NDJSON:
+		return newNDJSONWriter(w), nil
+	case Parquet:
+		return newParquetWriter(w), nil
+	}
+	return nil, fmt.Errorf("unknown format: %q", format)
+}
+
+// NewReader returns a reader of the records in the format.
+// Parquet needs random access, so unless r is a file it is copied to a temporary one first.
+func NewReader(r io.Reader, format Format) (Reader, error) {
+	switch format {
+	case CSV:
+		return newCSVReader(r)
+	case NDJSON:
+		return newNDJSONReader(r), nil
+	case Parquet:
+		return newParquetReader(r)
+	}
+	return nil, fmt.Errorf("unknown format: %q", format)
+}
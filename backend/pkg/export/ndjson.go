@@ -0,0 +1,53 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+)
+
+// NDJSON records are encoded as they are returned by the history API.
+type ndjsonWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	buf := bufio.NewWriter(w)
+	return &ndjsonWriter{
+		buf: buf,
+		enc: json.NewEncoder(buf),
+	}
+}
+
+func (w *ndjsonWriter) Write(r types.GameRecord) error {
+	return w.enc.Encode(r)
+}
+
+func (w *ndjsonWriter) Flush() error {
+	return w.buf.Flush()
+}
+
+func (w *ndjsonWriter) Close() error {
+	return w.Flush()
+}
+
+type ndjsonReader struct {
+	dec *json.Decoder
+}
+
+func newNDJSONReader(r io.Reader) *ndjsonReader {
+	return &ndjsonReader{dec: json.NewDecoder(r)}
+}
+
+func (r *ndjsonReader) Read() (types.GameRecord, error) {
+	var record types.GameRecord
+	err := r.dec.Decode(&record)
+	return record, err
+}
+
+func (r *ndjsonReader) Close() error {
+	return nil
+}
@@ -0,0 +1,100 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetWriter writes a row group on every flush, so only the rows since
+// the last flush are kept in memory.
+type parquetWriter struct {
+	w *parquet.GenericWriter[row]
+}
+
+func newParquetWriter(w io.Writer) *parquetWriter {
+	return &parquetWriter{w: parquet.NewGenericWriter[row](w)}
+}
+
+func (w *parquetWriter) Write(r types.GameRecord) error {
+	_, err := w.w.Write([]row{toRow(r)})
+	return err
+}
+
+func (w *parquetWriter) Flush() error {
+	return w.w.Flush()
+}
+
+func (w *parquetWriter) Close() error {
+	return w.w.Close()
+}
+
+type parquetReader struct {
+	r   *parquet.GenericReader[row]
+	buf []row
+	// temporary copy of the input, if it wasn't a file
+	tmp *os.File
+}
+
+func newParquetReader(r io.Reader) (ret *parquetReader, err error) {
+	ret = &parquetReader{
+		buf: make([]row, 1),
+	}
+	file, ok := r.(*os.File)
+	if !ok {
+		file, err = os.CreateTemp("", "rpssl-import-*.parquet")
+		if err != nil {
+			return nil, fmt.Errorf("create temporary file: %w", err)
+		}
+		ret.tmp = file
+		if _, err := io.Copy(file, r); err != nil {
+			ret.Close()
+			return nil, fmt.Errorf("copy to temporary file: %w", err)
+		}
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		ret.Close()
+		return nil, fmt.Errorf("stat: %w", err)
+	}
+	pf, err := parquet.OpenFile(file, stat.Size())
+	if err != nil {
+		ret.Close()
+		return nil, fmt.Errorf("open parquet: %w", err)
+	}
+	// the reader panics on schema it can't convert to the row
+	defer func() {
+		if p := recover(); p != nil {
+			ret.Close()
+			ret, err = nil, fmt.Errorf("open parquet: %v", p)
+		}
+	}()
+	ret.r = parquet.NewGenericReader[row](pf)
+	return ret, nil
+}
+
+func (r *parquetReader) Read() (types.GameRecord, error) {
+	n, err := r.r.Read(r.buf)
+	if n == 0 {
+		if err == nil {
+			err = io.EOF
+		}
+		return types.GameRecord{}, err
+	}
+	return r.buf[0].record()
+}
+
+func (r *parquetReader) Close() error {
+	var err error
+	if r.r != nil {
+		err = r.r.Close()
+	}
+	if r.tmp != nil {
+		r.tmp.Close()
+		os.Remove(r.tmp.Name())
+	}
+	return err
+}
@@ -0,0 +1,160 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+)
+
+// row is a flat game record, with enums by name, as it's stored in CSV and Parquet.
+type row struct {
+	ID             uint64    `parquet:"id"`
+	Owner          string    `parquet:"owner"`
+	Time           time.Time `parquet:"time,timestamp(nanosecond)"`
+	Mode           string    `parquet:"mode"`
+	GameID         string    `parquet:"game_id"`
+	PlayerName     string    `parquet:"player_name"`
+	OpponentName   string    `parquet:"opponent_name"`
+	PlayerChoice   string    `parquet:"player_choice"`
+	OpponentChoice string    `parquet:"opponent_choice"`
+	Result         string    `parquet:"result"`
+	Strategy       string    `parquet:"strategy"`
+	RNGSource      string    `parquet:"rng_source"`
+	Mirror         bool      `parquet:"mirror"`
+}
+
+// columns of the CSV header, in the order of row fields
+var columns = []string{
+	"id",
+	"owner",
+	"time",
+	"mode",
+	"game_id",
+	"player_name",
+	"opponent_name",
+	"player_choice",
+	"opponent_choice",
+	"result",
+	"strategy",
+	"rng_source",
+	"mirror",
+}
+
+func toRow(r types.GameRecord) row {
+	ret := row{
+		ID:             r.ID,
+		Owner:          r.Owner.String(),
+		Time:           r.Time.UTC(),
+		Mode:           r.Mode.String(),
+		PlayerName:     r.PlayerName,
+		OpponentName:   r.OpponentName,
+		PlayerChoice:   r.PlayerChoice.String(),
+		OpponentChoice: r.OpponentChoice.String(),
+		Result:         r.Result.String(),
+		Strategy:       r.Strategy,
+		RNGSource:      r.RNGSource,
+		Mirror:         r.Mirror,
+	}
+	if r.GameID != 0 {
+		ret.GameID = r.GameID.String()
+	}
+	return ret
+}
+
+func (r row) record() (types.GameRecord, error) {
+	ret := types.GameRecord{
+		ID:           r.ID,
+		Time:         r.Time,
+		PlayerName:   r.PlayerName,
+		OpponentName: r.OpponentName,
+		Strategy:     r.Strategy,
+		RNGSource:    r.RNGSource,
+		Mirror:       r.Mirror,
+	}
+	var err error
+	if ret.Owner, err = types.OwnerFromString(r.Owner); err != nil {
+		return ret, err
+	}
+	if ret.Mode, err = types.GameModeFromString(r.Mode); err != nil {
+		return ret, err
+	}
+	if r.GameID != "" {
+		if ret.GameID, err = types.GameIDFromString(r.GameID); err != nil {
+			return ret, fmt.Errorf("game_id: %w", err)
+		}
+	}
+	if ret.PlayerChoice, err = parseChoice(r.PlayerChoice); err != nil {
+		return ret, fmt.Errorf("player_choice: %w", err)
+	}
+	if ret.OpponentChoice, err = parseChoice(r.OpponentChoice); err != nil {
+		return ret, fmt.Errorf("opponent_choice: %w", err)
+	}
+	if err = json.Unmarshal([]byte(strconv.Quote(r.Result)), &ret.Result); err != nil {
+		return ret, fmt.Errorf("result: %w", err)
+	}
+	return ret, nil
+}
+
+// empty choice is left undefined, as in records stored through the legacy API
+func parseChoice(s string) (types.Choice, error) {
+	var choice types.Choice
+	if s == "" {
+		return choice, nil
+	}
+	err := json.Unmarshal([]byte(strconv.Quote(s)), &choice)
+	return choice, err
+}
+
+func (r row) fields() []string {
+	mirror := ""
+	if r.Mirror {
+		mirror = "true"
+	}
+	return []string{
+		strconv.FormatUint(r.ID, 10),
+		r.Owner,
+		r.Time.Format(time.RFC3339Nano),
+		r.Mode,
+		r.GameID,
+		r.PlayerName,
+		r.OpponentName,
+		r.PlayerChoice,
+		r.OpponentChoice,
+		r.Result,
+		r.Strategy,
+		r.RNGSource,
+		mirror,
+	}
+}
+
+func rowFromFields(fields []string) (row, error) {
+	var r row
+	var err error
+	if fields[0] != "" {
+		if r.ID, err = strconv.ParseUint(fields[0], 10, 64); err != nil {
+			return r, fmt.Errorf("id: %w", err)
+		}
+	}
+	if r.Time, err = time.Parse(time.RFC3339Nano, fields[2]); err != nil {
+		return r, fmt.Errorf("time: %w", err)
+	}
+	r.Owner = fields[1]
+	r.Mode = fields[3]
+	r.GameID = fields[4]
+	r.PlayerName = fields[5]
+	r.OpponentName = fields[6]
+	r.PlayerChoice = fields[7]
+	r.OpponentChoice = fields[8]
+	r.Result = fields[9]
+	r.Strategy = fields[10]
+	r.RNGSource = fields[11]
+	if fields[12] != "" {
+		if r.Mirror, err = strconv.ParseBool(fields[12]); err != nil {
+			return r, fmt.Errorf("mirror: %w", err)
+		}
+	}
+	return r, nil
+}
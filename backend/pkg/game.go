@@ -3,6 +3,7 @@ package pkg
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/complynx/rpssl4bu/backend/pkg/types"
 )
@@ -16,11 +17,20 @@ type Server interface {
 	Shutdown(ctx context.Context)
 }
 
+// Scheduler runs background jobs periodically.
+type Scheduler interface {
+	// Stop stops scheduling new runs and waits for the current one to finish
+	// or the provided context to be done.
+	Stop(ctx context.Context)
+}
+
 // RandomProvider is an interface that represents a provider of random numbers.
 type RandomProvider interface {
 	// Rand returns a random number from 0 to 99
 	// The provided context is used to cancel the request if it takes too long.
 	Rand(ctx context.Context) (int, error)
+	// Source returns a short description of where the numbers come from.
+	Source() string
 }
 
 // Game is an interface that represents a game.
@@ -32,6 +42,10 @@ type Game interface {
 	// Play runs the game based on users choice and returns the game result and
 	// the choice made by the the computer.
 	Play(context.Context, types.Choice) (types.Result, types.Choice, error)
+	// Strategy returns the name of the strategy the computer uses for its choices.
+	Strategy() string
+	// RNGSource returns the source of random numbers used by the computer.
+	RNGSource() string
 }
 
 // GameAPI is an interface that represents the API of the game.
@@ -49,19 +63,88 @@ type GameAPI interface {
 
 	// Scoreboard API
 
-	// GetScores handles the GET /get_scores request and returns the list of all scores.
+	// GetScores handles the GET /get_scores request and returns the list of last scores of the caller's
+	// session or user. With ?scope=global it returns the last scores of everyone.
 	GetScores(w http.ResponseWriter, r *http.Request)
-	// ClearScores handles the POST /clear_scores request and clears the list of scores.
+	// ClearScores handles the POST /clear_scores request and clears the caller's list of scores.
+	// The scores can be restored for a while.
 	ClearScores(w http.ResponseWriter, r *http.Request)
+	// RestoreScores handles the POST /scores/restore request and brings back the caller's
+	// last cleared scores, if they haven't expired yet.
+	RestoreScores(w http.ResponseWriter, r *http.Request)
+	// Audit handles the GET /scores/audit request and returns who cleared and restored
+	// the caller's scores and when.
+	Audit(w http.ResponseWriter, r *http.Request)
+	// History handles the GET /history request and returns a page of the caller's game records, newest first.
+	// Records can be filtered by result, choice, opponent_choice, mode and time (since, until).
+	History(w http.ResponseWriter, r *http.Request)
+	// Stats handles the GET /stats request and returns statistics of the caller's games.
+	// With ?scope=global it returns statistics of everyone, with ?player=name — of the P2P games of the registered user.
+	Stats(w http.ResponseWriter, r *http.Request)
+	// Leaderboard handles the GET /leaderboard request and returns the best rated registered players
+	// with their rank, rating, its deviation and the number of rated matches played, up to ?limit.
+	Leaderboard(w http.ResponseWriter, r *http.Request)
+	// StreamScores handles the GET /scores/stream request and streams changes of the caller's scoreboard,
+	// with their statistics, as Server-Sent Events. With ?scope=global it streams changes of everyone.
+	// Reconnecting browsers resume after the Last-Event-ID.
+	StreamScores(w http.ResponseWriter, r *http.Request)
+	// Export handles the GET /export?format=csv|ndjson|parquet request and streams all the caller's
+	// game records, oldest first. With ?scope=global it exports the records of everyone.
+	Export(w http.ResponseWriter, r *http.Request)
+	// Import handles the POST /import?format=csv|ndjson|parquet request and adds the records
+	// from the payload to the caller's scoreboard.
+	Import(w http.ResponseWriter, r *http.Request)
 
 	// P2P API
 
-	// CreateP2P handles the POST /create_p2p request and creates a new peer-to-peer game.
+	// CreateP2P handles the POST /create_p2p request and creates a new peer-to-peer game hosted by the caller.
+	// With ?public=true the game is listed in the lobby, with a password only those knowing it may join.
 	CreateP2P(w http.ResponseWriter, r *http.Request)
 	// ConnectP2P handles WebSocket connect request /connect_p2p with an existing peer-to-peer game.
+	// It speaks the p2p subprotocol with bare JSON messages, or p2p.v2 with typed frames, if the client asks for it.
+	// The player needs the password of a protected game or an invite, which may seat them on a given side.
 	ConnectP2P(w http.ResponseWriter, r *http.Request)
+	// Invite handles the POST /p2p/{id}/invite request of the host and returns a signed invite to the game,
+	// to the given side or any, expiring after the ttl.
+	Invite(w http.ResponseWriter, r *http.Request)
+	// Kick handles the POST /p2p/{id}/kick request of the host and removes the player from the given side,
+	// with ban=true they can't join the game again.
+	Kick(w http.ResponseWriter, r *http.Request)
+	// Matchmake handles the POST /matchmake request with the player name and the wanted ruleset and best_of,
+	// and waits for an opponent to seat both in a new game. It returns the game ID, side and resume token
+	// to connect with, or with computer_fallback asked it tells to play with the computer if nobody comes.
+	Matchmake(w http.ResponseWriter, r *http.Request)
+	// WatchP2P handles WebSocket connect request /watch_p2p to watch an existing peer-to-peer game.
+	WatchP2P(w http.ResponseWriter, r *http.Request)
 	// FindP2PGame handles the GET /find_p2p request and returns the game status: full or not, if it is found.
 	FindP2PGame(w http.ResponseWriter, r *http.Request)
+	// P2PScore handles the GET /p2p/{id}/score request and returns the score of the game's match.
+	P2PScore(w http.ResponseWriter, r *http.Request)
+	// P2PGames handles the GET /p2p/games request and returns the lobby: the public games with a free seat,
+	// with their creator, settings, age and occupancy.
+	P2PGames(w http.ResponseWriter, r *http.Request)
+	// Lobby handles WebSocket connect request /p2p/lobby and sends the lobby again every time it changes.
+	Lobby(w http.ResponseWriter, r *http.Request)
+	// Shutdown closes the WebSocket connections, telling the clients the server is restarting, and waits for
+	// their handlers to return until the context is done. New connections are refused since.
+	// The HTTP server doesn't wait for the WebSockets, as they are hijacked from it.
+	Shutdown(ctx context.Context)
+	// AdminGames handles the GET /admin/games request of an operator and returns the status of every running game.
+	// The admin requests are authenticated with the bearer token.
+	AdminGames(w http.ResponseWriter, r *http.Request)
+	// AdminGame handles the GET /admin/games/{id} request of an operator and returns the status of the game.
+	AdminGame(w http.ResponseWriter, r *http.Request)
+	// AdminEndGame handles the POST /admin/games/{id}/end request of an operator and ends the game.
+	AdminEndGame(w http.ResponseWriter, r *http.Request)
+	// AdminKick handles the POST /admin/games/{id}/kick request of an operator and removes the player
+	// from the given side, like Kick of the host.
+	AdminKick(w http.ResponseWriter, r *http.Request)
+	// AdminNotice handles the POST /admin/notice and /admin/games/{id}/notice requests of an operator
+	// and sends the text to every running game or to the given one.
+	AdminNotice(w http.ResponseWriter, r *http.Request)
+	// AdminTap handles WebSocket connect request /admin/games/{id}/tap of an operator, which gets
+	// everything happening in the game like a spectator, with the choices as soon as they are made.
+	AdminTap(w http.ResponseWriter, r *http.Request)
 }
 
 // Storage is an interface that represents the storage of game results.
@@ -74,16 +157,135 @@ type Storage interface {
 	ClearScores() error
 }
 
+// StorageV2 is an interface that represents the storage of full game records.
+// Records are namespaced by their owner, types.Global is the aggregate view over all owners.
+// Storage methods operate on the global view.
+type StorageV2 interface {
+	Storage
+	// AddRecord stores a played game on its owner scoreboard.
+	AddRecord(ctx context.Context, record types.GameRecord) error
+	// GetLastRecords returns up to limit of the newest records of the owner, newest first.
+	GetLastRecords(ctx context.Context, owner types.Owner, limit int) ([]types.GameRecord, error)
+	// ClearRecords removes the records of the owner under a tombstone, they can be restored until it expires.
+	// Clearing types.Global removes all records. The change is audited as made by the owner.
+	ClearRecords(ctx context.Context, owner types.Owner) error
+	// RestoreRecords brings back the records removed by the owner's last unexpired tombstone and returns it,
+	// types.ErrNoTombstone if there's nothing to restore. The change is audited as made by the owner.
+	RestoreRecords(ctx context.Context, owner types.Owner) (types.Tombstone, error)
+	// AuditLog returns up to limit of the newest audit entries of the owner, newest first.
+	// types.Global returns the entries of everyone.
+	AuditLog(ctx context.Context, owner types.Owner, limit int) ([]types.AuditEntry, error)
+	// History returns a page of the owner's records matching the query, newest first.
+	History(ctx context.Context, query types.HistoryQuery) (types.HistoryPage, error)
+}
+
+// EventLog is an append-only durable log of domain events.
+type EventLog interface {
+	// Append assigns the next sequence number to the event and stores it, returning the stored event.
+	Append(event types.Event) (types.Event, error)
+	// Replay calls fn with every stored event from the given sequence number on, in order.
+	Replay(from uint64, fn func(types.Event) error) error
+	// LastSeq returns the sequence number of the last stored event, zero if there are none.
+	LastSeq() uint64
+	// Prune removes stored events older than the given sequence number, at least the ones
+	// not needed anymore. Events are removed in whole segments, so some may be kept.
+	Prune(before uint64) error
+	// Close closes the log files.
+	Close() error
+}
+
+// EventRecorder is a storage which keeps the domain events besides the game records.
+type EventRecorder interface {
+	// RecordEvent stores the event. Game records and cleared scores produce their own events.
+	RecordEvent(ctx context.Context, event types.Event) error
+}
+
+// ScoreNotifier streams changes of the scoreboards.
+type ScoreNotifier interface {
+	// Subscribe returns a channel of changes of the owner's scoreboard, of everyone's for types.Global.
+	// Changes after the lastID are sent first, missed is true if some of them aren't available anymore
+	// and the scoreboard has to be fetched again. The channel is closed when the context is done,
+	// or if the subscriber falls too far behind, then it may subscribe again from the last event it got.
+	Subscribe(ctx context.Context, owner types.Owner, lastID uint64) (events <-chan types.ScoreEvent, missed bool)
+}
+
+// Compactor is a storage which rolls up old records into daily aggregates before deleting them.
+type Compactor interface {
+	// Compact rolls up the records expired by the policy at the given time into daily aggregates
+	// of their owners and deletes them. It returns the number of deleted records.
+	Compact(ctx context.Context, policy types.RetentionPolicy, now time.Time) (int, error)
+	// GetAggregates returns the daily aggregates of the owner, oldest first.
+	// types.Global returns the aggregates of every owner.
+	GetAggregates(ctx context.Context, owner types.Owner) ([]types.DailyAggregate, error)
+}
+
+// RatingStorage keeps the ratings of the registered players.
+type RatingStorage interface {
+	// GetRating returns the rating of the player, the initial one if they haven't played rated matches yet.
+	GetRating(ctx context.Context, owner types.Owner) (types.Rating, error)
+	// RateMatch updates the ratings of both players with the result of the match.
+	// A match which has been rated already is ignored.
+	RateMatch(ctx context.Context, match types.MatchResult) error
+	// Leaderboard returns up to limit of the best rated players, best first.
+	Leaderboard(ctx context.Context, limit int) ([]types.LeaderboardEntry, error)
+}
+
+// GameStore keeps the snapshots of the running P2P games to restore them after a restart.
+type GameStore interface {
+	// SaveGame stores the snapshot of the game, replacing the previous one.
+	SaveGame(ctx context.Context, game types.GameSnapshot) error
+	// DeleteGame removes the snapshot of the game which has ended.
+	DeleteGame(ctx context.Context, id types.GameID) error
+	// LoadGames returns the snapshots of all the games, oldest first.
+	LoadGames(ctx context.Context) ([]types.GameSnapshot, error)
+}
+
+// StatsProvider is an interface that represents aggregate statistics of the stored game records.
+type StatsProvider interface {
+	// GetStats returns statistics of the owner's games, or of everyone's for types.Global.
+	GetStats(ctx context.Context, owner types.Owner) (types.Stats, error)
+	// GetPlayerStats returns statistics of the P2P games of the registered user with the given name.
+	GetPlayerStats(ctx context.Context, name string) (types.Stats, error)
+}
+
 // The P2PGameFactory interface is for creating and managing peer-to-peer games. It has the following methods:
 type P2PGameFactory interface {
-	// CreateGame: This method creates a new peer-to-peer game with a given context and returns
-	// the game object and an error if one occurred.
-	CreateGame(ctx context.Context) (P2PGame, error)
-	// StopGames: This method stops all games created by the factory.
+	// CreateGame: This method creates a new peer-to-peer game with a given context and settings,
+	// the zero settings are the defaults. The access sets the host of the game and its password,
+	// the zero one is an open game without a host. It returns the game object and an error if one occurred.
+	CreateGame(ctx context.Context, settings types.GameSettings, access types.GameAccess) (P2PGame, error)
+	// StopGames: This method stops all games created by the factory, keeping their snapshots in the game store
+	// to restore them on the next start. It waits for the games to stop until the context is done.
 	StopGames(ctx context.Context)
+	// Restore brings back the games from the snapshots in the game store, with the players seated in them
+	// until the grace period for resuming runs out. The expired games are dropped. Returns the number of
+	// the restored games.
+	Restore(ctx context.Context) (int, error)
+	// Drain stops creating games and seating new players, tells everyone in the games that the server is restarting,
+	// and waits for the rounds being played to end until the context is done. Then it stops all the games.
+	// Creating a game or seating a player returns types.ErrShuttingDown since.
+	Drain(ctx context.Context)
 	// GetGame: This method retrieves a peer-to-peer game with a given ID. It returns
 	// the game object and a boolean value indicating whether the game was found or not.
 	GetGame(id types.GameID) (P2PGame, bool)
+	// Games returns the status of every running game, oldest first.
+	Games() []types.GameStatus
+	// Notice sends the text of the operators to every running game as a chat message of the server side.
+	// Returns the number of the games, or types.ErrBadChat if the text is empty or too long.
+	Notice(text string) (int, error)
+	// Lobby returns the open public games, the ones with a free seat, oldest first.
+	Lobby() []types.GameInfo
+	// WatchLobby returns a channel signalled when the lobby may have changed, the lobby is to be fetched
+	// again then. The channel is closed when the context is done.
+	WatchLobby(ctx context.Context) <-chan struct{}
+}
+
+// Matchmaker pairs the players looking for a P2P game.
+type Matchmaker interface {
+	// Match waits for another player asking for the same settings, first come first served, and seats both
+	// in a new game, where they connect with the resume tokens of the matches. Returns types.ErrNoMatch
+	// if nobody comes in time or the context is done.
+	Match(ctx context.Context, request types.MatchRequest) (types.Match, error)
 }
 
 // P2PGame is an interface that represents a game played between two players.
@@ -92,22 +294,73 @@ type P2PGame interface {
 	GetID() types.GameID
 	// AddPlayer adds a player to the game with the given name. If no name is provided, the player will be called "Anonymous".
 	// The name must contain only characters from the Latin alphabet and spaces, and must not be more than 20 characters long.
+	// Rounds played by the player are recorded on the owner's scoreboard.
 	// The function returns the side of the player (true = right side, false = left side) and a channel for receiving messages.
 	//
 	// The function will also send a signal to other player if one already joined
 	//
 	// Returns:
 	// - side of the new player
-	// - channel for current game state for the player and game results
+	// - channel for current game state for the player and game results, and the chat messages
+	// - token to resume the game with after losing the connection
 	// - error if something is wrong
-	AddPlayer(name string) (bool, chan types.Message, error)
+	AddPlayer(name string, owner types.Owner) (bool, chan types.Update, string, error)
+	// AddPlayerOnSide adds a player to the given side of the game like AddPlayer.
+	// Returns types.ErrSeatTaken if the side is taken already.
+	AddPlayerOnSide(name string, owner types.Owner, rightSide bool) (chan types.Update, string, error)
 	// RemovePlayer removes the player from the given side of the game.
 	// The function will also send a signal to other player if one already joined
 	RemovePlayer(rightSide bool)
+	// DetachPlayer is called when the connection of the player receiving from the channel is lost.
+	// The channel is closed, and the seat is held for a while for the player to resume,
+	// after that the player is removed.
+	DetachPlayer(ch chan types.Update)
+	// ResumePlayer reattaches the player with the given resume token to their seat, replacing
+	// the previous connection if it's still there. The chat history and the latest state are sent to the new channel first.
+	// Returns the side of the player and the channel for the game state.
+	ResumePlayer(token string) (bool, chan types.Update, error)
+	// AddSpectator adds a spectator to the game and returns the channel for the game state and the chat messages.
+	// Spectators see the choices of the players only with the result of the round.
+	// Returns an error if the game has too many spectators.
+	AddSpectator() (chan types.Update, error)
+	// RemoveSpectator removes the spectator receiving from the channel and closes it.
+	RemoveSpectator(ch chan types.Update)
 	// Choice sets players choice on the given side of the game.
 	// Sends the players the signal of current situation.
 	// If both made choices, calculates result and sends it to the players.
-	Choice(choice types.Choice, rightSide bool)
+	// The first choice after the match is over starts the next one.
+	// Returns types.ErrBadChoice if the choice is not in the ruleset of the game. While the server is restarting
+	// only the round being played may be finished, starting a new one returns types.ErrShuttingDown.
+	Choice(choice types.Choice, rightSide bool) error
+	// Chat relays the text of the player on the given side to everyone in the game and keeps it in the chat history.
+	// The text is limited in length and its profanity is hidden.
+	Chat(rightSide bool, text string) error
+	// Emote relays the emote of the player on the given side like a chat message.
+	Emote(rightSide bool, emote types.Emote) error
+	// Heartbeat tells that the connection of the player on the given side is alive, it keeps the game going.
+	Heartbeat(rightSide bool)
+	// Score returns the score of the match between the players currently in the game.
+	// It starts anew when a player leaves.
+	Score() types.MatchScore
 	// IsFull returns true if both players have joined the game.
 	IsFull(ctx context.Context) bool
+	// Host returns the owner who created the game, types.Global if it has no host.
+	Host() types.Owner
+	// CheckPassword returns true if the password is the one of the game, or if the game has none.
+	CheckPassword(password string) bool
+	// Kick removes the player from the given side of the game, banning their owner from joining
+	// it again if ban is true. The unfinished match is void, it isn't rated. Returns types.ErrNoPlayer if the side is free.
+	Kick(rightSide bool, ban bool) error
+	// Status describes the game with its players and their owners, the score and the last activity.
+	Status() types.GameStatus
+	// End finishes the game right away, everyone in it is disconnected.
+	End()
+	// Notice sends the text of the operators to everyone in the game as a chat message of the server side.
+	// Returns types.ErrBadChat if the text is empty or too long.
+	Notice(text string) error
+	// Tap returns a channel of everything happening in the game, with the choices as soon as they are made,
+	// for debugging. The tap isn't counted as a spectator.
+	Tap() chan types.Update
+	// Untap removes the tap receiving from the channel and closes it.
+	Untap(ch chan types.Update)
 }
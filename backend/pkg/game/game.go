@@ -8,6 +8,8 @@ import (
 	"github.com/complynx/rpssl4bu/backend/pkg/types"
 )
 
+const strategy = "random"
+
 type game struct {
 	rng pkg.RandomProvider
 }
@@ -100,3 +102,11 @@ func (g *game) Play(ctx context.Context, player types.Choice) (types.Result, typ
 
 	return GameResult(player, computerChoice), computerChoice, nil
 }
+
+func (g *game) Strategy() string {
+	return strategy
+}
+
+func (g *game) RNGSource() string {
+	return g.rng.Source()
+}
@@ -199,3 +199,15 @@ func (s *gameTestSuite) TestPlay() {
 		s.Equal(types.Lose, res)
 	})
 }
+
+func (s *gameTestSuite) TestDescription() {
+	rng := mocks.NewRandomProvider(s.T())
+	defer rng.AssertExpectations(s.T())
+
+	rng.EXPECT().Source().Times(1).Return("http://example.com")
+
+	game := NewGame(rng)
+
+	s.Equal("random", game.Strategy())
+	s.Equal("http://example.com", game.RNGSource())
+}
@@ -0,0 +1,284 @@
+package matchmaking
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"go.uber.org/zap"
+)
+
+// DefaultTimeout is how long a player waits for an opponent
+const DefaultTimeout = 30 * time.Second
+
+const (
+	// ratingWindow is the difference of the ratings of the players paired right away
+	ratingWindow = 100.
+	// windowGrowth widens the window of a player with every second of waiting
+	windowGrowth = 20.
+	// rescanEvery is how often a waiting player looks for an opponent in their widened window
+	rescanEvery = time.Second
+)
+
+// waiter is a player waiting for an opponent, the match is sent to them once they are seated
+type waiter struct {
+	// ctx is done when the player has gone
+	ctx     context.Context
+	request types.MatchRequest
+	rating  float64
+	since   time.Time
+	match   chan seated
+}
+
+type seated struct {
+	match types.Match
+	err   error
+}
+
+// queue keeps the waiting players by the settings they want, oldest first
+type queue struct {
+	factory pkg.P2PGameFactory
+	timeout time.Duration
+	log     *zap.Logger
+	ratings pkg.RatingStorage
+
+	window float64
+	growth float64
+	rescan time.Duration
+
+	mu      sync.Mutex
+	waiting map[types.GameSettings][]*waiter
+}
+
+// Option configures the queue.
+type Option func(*queue)
+
+// WithRatings pairs the players with close ratings, the window of the difference widens while they wait.
+// Everyone has the same rating without it.
+func WithRatings(ratings pkg.RatingStorage) Option {
+	return func(q *queue) {
+		q.ratings = ratings
+	}
+}
+
+// NewQueue creates the matchmaker pairing the players in the games of the factory,
+// a player waits for an opponent up to timeout.
+func NewQueue(factory pkg.P2PGameFactory, timeout time.Duration, log *zap.Logger, opts ...Option) pkg.Matchmaker {
+	q := &queue{
+		factory: factory,
+		timeout: timeout,
+		log:     log,
+		window:  ratingWindow,
+		growth:  windowGrowth,
+		rescan:  rescanEvery,
+		waiting: make(map[types.GameSettings][]*waiter),
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q
+}
+
+func (q *queue) Match(ctx context.Context, request types.MatchRequest) (types.Match, error) {
+	if !types.ValidPlayerName(request.Name) {
+		return types.Match{}, types.ErrBadName
+	}
+	settings, err := request.Settings.Normalize()
+	if err != nil {
+		return types.Match{}, fmt.Errorf("settings: %w", err)
+	}
+	request.Settings = settings
+
+	w := &waiter{
+		ctx:     ctx,
+		request: request,
+		rating:  q.rating(ctx, request.Owner),
+		since:   time.Now(),
+		match:   make(chan seated, 1),
+	}
+	q.mu.Lock()
+	opponent := q.pop(w, w.since)
+	if opponent == nil {
+		q.push(w)
+	}
+	q.mu.Unlock()
+	if opponent != nil {
+		q.seatBoth(opponent, w)
+	}
+
+	timer := time.NewTimer(q.timeout)
+	defer timer.Stop()
+	ticker := time.NewTicker(q.rescan)
+	defer ticker.Stop()
+
+wait:
+	for {
+		select {
+		case s := <-w.match:
+			return s.match, s.err
+		case now := <-ticker.C:
+			q.mu.Lock()
+			var opponent *waiter
+			// unless an opponent has come in the meantime and is seating both
+			if q.remove(w) {
+				if opponent = q.pop(w, now); opponent == nil {
+					q.push(w)
+				}
+			}
+			q.mu.Unlock()
+			if opponent != nil {
+				q.seatBoth(opponent, w)
+			}
+		case <-timer.C:
+			break wait
+		case <-ctx.Done():
+			break wait
+		}
+	}
+	q.mu.Lock()
+	removed := q.remove(w)
+	q.mu.Unlock()
+	if removed {
+		return types.Match{}, types.ErrNoMatch
+	}
+	// the opponent has come in the meantime and is seating both
+	s := <-w.match
+	return s.match, s.err
+}
+
+// rating of the owner for pairing, the registered players have their own
+func (q *queue) rating(ctx context.Context, owner types.Owner) float64 {
+	if q.ratings == nil || !owner.IsUser() {
+		return types.InitialRating
+	}
+	r, err := q.ratings.GetRating(ctx, owner)
+	if err != nil {
+		q.log.Warn("Failed to get rating", zap.Stringer("owner", owner), zap.Error(err))
+		return types.InitialRating
+	}
+	return r.Rating
+}
+
+// fits tells if the ratings of the players are close enough at the time,
+// the wider window of the two counts
+func (q *queue) fits(a, b *waiter, now time.Time) bool {
+	waited := now.Sub(a.since)
+	if d := now.Sub(b.since); d > waited {
+		waited = d
+	}
+	return math.Abs(a.rating-b.rating) <= q.window+q.growth*waited.Seconds()
+}
+
+// pop takes the oldest player waiting for the same settings whose rating fits the player's,
+// must be called with the lock held
+func (q *queue) pop(w *waiter, now time.Time) *waiter {
+	for _, opponent := range q.waiting[w.request.Settings] {
+		if q.fits(w, opponent, now) {
+			q.remove(opponent)
+			return opponent
+		}
+	}
+	return nil
+}
+
+// push puts the player back to their place in the queue, must be called with the lock held
+func (q *queue) push(w *waiter) {
+	waiting := q.waiting[w.request.Settings]
+	i := len(waiting)
+	for i > 0 && waiting[i-1].since.After(w.since) {
+		i--
+	}
+	q.waiting[w.request.Settings] = append(waiting[:i:i], append([]*waiter{w}, waiting[i:]...)...)
+}
+
+// seatBoth seats the players, the one waiting longer on the left, and sends them the matches
+func (q *queue) seatBoth(a, b *waiter) {
+	if b.since.Before(a.since) {
+		a, b = b, a
+	}
+	if q.requeue(a, b) {
+		return
+	}
+	left, right, err := q.seat(a.request, b.request)
+	a.match <- seated{left, err}
+	b.match <- seated{right, err}
+}
+
+// requeue tells if a player has gone before being seated, the other one is put back to the queue then
+func (q *queue) requeue(players ...*waiter) bool {
+	live := make([]*waiter, 0, len(players))
+	for _, w := range players {
+		if err := w.ctx.Err(); err != nil {
+			w.match <- seated{err: err}
+		} else {
+			live = append(live, w)
+		}
+	}
+	if len(live) == len(players) {
+		return false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, w := range live {
+		q.push(w)
+	}
+	return true
+}
+
+// remove takes the player out of the queue if they are still there, must be called with the lock held
+func (q *queue) remove(w *waiter) bool {
+	waiting := q.waiting[w.request.Settings]
+	for i := range waiting {
+		if waiting[i] == w {
+			waiting = append(waiting[:i:i], waiting[i+1:]...)
+			if len(waiting) == 0 {
+				delete(q.waiting, w.request.Settings)
+			} else {
+				q.waiting[w.request.Settings] = waiting
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// seat creates the game and seats the players, their seats are held for them to connect.
+// The game outlives the requests of the players, and it's ended if they can't be seated.
+func (q *queue) seat(left, right types.MatchRequest) (types.Match, types.Match, error) {
+	game, err := q.factory.CreateGame(context.Background(), left.Settings, types.GameAccess{})
+	if err != nil {
+		return types.Match{}, types.Match{}, fmt.Errorf("create game: %w", err)
+	}
+
+	matches := make([]types.Match, 2)
+	for i, request := range []types.MatchRequest{left, right} {
+		side, ch, token, err := game.AddPlayer(request.Name, request.Owner)
+		if err != nil {
+			game.End()
+			return types.Match{}, types.Match{}, fmt.Errorf("add player: %w", err)
+		}
+		game.DetachPlayer(ch)
+
+		matches[i] = types.Match{
+			GameID:   game.GetID(),
+			Side:     types.SideLeft,
+			Resume:   token,
+			Settings: left.Settings,
+		}
+		if side {
+			matches[i].Side = types.SideRight
+		}
+	}
+	matches[0].Opponent, matches[1].Opponent = right.Name, left.Name
+
+	q.log.Info("players matched",
+		zap.Stringer("game_id", game.GetID()),
+		zap.String("left", left.Name),
+		zap.String("right", right.Name),
+	)
+	return matches[0], matches[1], nil
+}
@@ -0,0 +1,188 @@
+package matchmaking
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg"
+	"github.com/complynx/rpssl4bu/backend/pkg/mocks"
+	p2pgame "github.com/complynx/rpssl4bu/backend/pkg/p2p_game"
+	"github.com/complynx/rpssl4bu/backend/pkg/random"
+	"github.com/complynx/rpssl4bu/backend/pkg/storage"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type result struct {
+	match types.Match
+	err   error
+}
+
+// match asks for the match in the background
+func match(q pkg.Matchmaker, ctx context.Context, name string, settings types.GameSettings) <-chan result {
+	ch := make(chan result, 1)
+	go func() {
+		m, err := q.Match(ctx, types.MatchRequest{Name: name, Owner: types.Global, Settings: settings})
+		ch <- result{m, err}
+	}()
+	return ch
+}
+
+// waitQueued waits until n players are in the queue
+func waitQueued(t *testing.T, q pkg.Matchmaker, n int) {
+	require.Eventually(t, func() bool {
+		q := q.(*queue)
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		queued := 0
+		for _, w := range q.waiting {
+			queued += len(w)
+		}
+		return queued == n
+	}, time.Second, time.Millisecond)
+}
+
+func TestQueue(t *testing.T) {
+	factory := p2pgame.NewGameFactory(random.NewSimpleRandom(""), storage.NewSimple(10), zap.NewNop())
+	defer factory.StopGames(context.Background())
+	q := NewQueue(factory, time.Minute, zap.NewNop())
+	ctx := context.Background()
+	classic := types.GameSettings{Ruleset: types.RulesetClassic, BestOf: 3}
+
+	sheldon := match(q, ctx, "Sheldon", classic)
+	waitQueued(t, q, 1)
+	// the players asking for other settings wait for their own opponents
+	leonard := match(q, ctx, "Leonard", types.GameSettings{})
+	waitQueued(t, q, 2)
+	penny := match(q, ctx, "Penny", classic)
+
+	left, right := <-sheldon, <-penny
+	require.NoError(t, left.err)
+	require.NoError(t, right.err)
+	assert.Equal(t, left.match.GameID, right.match.GameID)
+	assert.Equal(t, types.SideLeft, left.match.Side)
+	assert.Equal(t, types.SideRight, right.match.Side)
+	assert.Equal(t, "Penny", left.match.Opponent)
+	assert.Equal(t, "Sheldon", right.match.Opponent)
+	assert.Equal(t, classic, right.match.Settings)
+	waitQueued(t, q, 1)
+
+	// the seats are held for the players to connect
+	game, ok := factory.GetGame(left.match.GameID)
+	require.True(t, ok)
+	assert.True(t, game.IsFull(ctx))
+	side, _, err := game.ResumePlayer(right.match.Resume)
+	require.NoError(t, err)
+	assert.True(t, side)
+	assert.Equal(t, classic.BestOf, game.Score().BestOf)
+
+	raj := match(q, ctx, "Raj", types.GameSettings{Ruleset: types.RulesetRPSSL})
+	require.NoError(t, (<-leonard).err)
+	require.NoError(t, (<-raj).err)
+	waitQueued(t, q, 0)
+}
+
+func TestQueueNoMatch(t *testing.T) {
+	factory := p2pgame.NewGameFactory(random.NewSimpleRandom(""), storage.NewSimple(10), zap.NewNop())
+	defer factory.StopGames(context.Background())
+	q := NewQueue(factory, 10*time.Millisecond, zap.NewNop())
+
+	_, err := q.Match(context.Background(), types.MatchRequest{Name: "Sheldon"})
+	assert.ErrorIs(t, err, types.ErrNoMatch)
+	waitQueued(t, q, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = q.Match(ctx, types.MatchRequest{Name: "Sheldon"})
+	assert.ErrorIs(t, err, types.ErrNoMatch)
+
+	_, err = q.Match(context.Background(), types.MatchRequest{Name: "Dr. Cooper"})
+	assert.ErrorIs(t, err, types.ErrBadName)
+	_, err = q.Match(context.Background(), types.MatchRequest{Settings: types.GameSettings{BestOf: 2}})
+	assert.Error(t, err)
+	waitQueued(t, q, 0)
+}
+
+func TestQueueGone(t *testing.T) {
+	factory := p2pgame.NewGameFactory(random.NewSimpleRandom(""), storage.NewSimple(10), zap.NewNop())
+	defer factory.StopGames(context.Background())
+	q := NewQueue(factory, time.Minute, zap.NewNop())
+
+	// the player who has gone but is still in the queue isn't seated
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	settings, err := types.GameSettings{}.Normalize()
+	require.NoError(t, err)
+	ghost := &waiter{
+		ctx:     ctx,
+		request: types.MatchRequest{Name: "Sheldon", Settings: settings},
+		rating:  types.InitialRating,
+		since:   time.Now(),
+		match:   make(chan seated, 1),
+	}
+	q.(*queue).mu.Lock()
+	q.(*queue).push(ghost)
+	q.(*queue).mu.Unlock()
+
+	penny := match(q, context.Background(), "Penny", types.GameSettings{})
+	assert.ErrorIs(t, (<-ghost.match).err, context.Canceled)
+	// the other one keeps waiting
+	waitQueued(t, q, 1)
+	leonard := match(q, context.Background(), "Leonard", types.GameSettings{})
+	m := <-penny
+	require.NoError(t, m.err)
+	assert.Equal(t, "Leonard", m.match.Opponent)
+	require.NoError(t, (<-leonard).err)
+}
+
+func TestQueueRatings(t *testing.T) {
+	factory := p2pgame.NewGameFactory(random.NewSimpleRandom(""), storage.NewSimple(10), zap.NewNop())
+	defer factory.StopGames(context.Background())
+	ratings := mocks.NewRatingStorage(t)
+	for name, rating := range map[string]float64{"sheldon": 2000, "penny": 1950, "leonard": 1500, "raj": 1000} {
+		owner := types.UserOwner(name)
+		ratings.EXPECT().GetRating(mock.Anything, owner).Return(types.Rating{Owner: owner, Rating: rating}, nil)
+	}
+	q := NewQueue(factory, time.Minute, zap.NewNop(), WithRatings(ratings))
+	q.(*queue).growth = 1000
+	q.(*queue).rescan = 10 * time.Millisecond
+	ctx := context.Background()
+	request := func(name string) types.MatchRequest {
+		return types.MatchRequest{Name: name, Owner: types.UserOwner(strings.ToLower(name))}
+	}
+	match := func(name string) <-chan result {
+		ch := make(chan result, 1)
+		go func() {
+			m, err := q.Match(ctx, request(name))
+			ch <- result{m, err}
+		}()
+		return ch
+	}
+
+	sheldon := match("Sheldon")
+	waitQueued(t, q, 1)
+	leonard := match("Leonard")
+	waitQueued(t, q, 2)
+	// the closest rating, not the first in the queue
+	penny := match("Penny")
+	m := <-penny
+	require.NoError(t, m.err)
+	assert.Equal(t, "Sheldon", m.match.Opponent)
+	assert.Equal(t, types.SideRight, m.match.Side)
+	require.NoError(t, (<-sheldon).err)
+
+	// the window widens while they wait
+	start := time.Now()
+	raj := match("Raj")
+	m = <-raj
+	require.NoError(t, m.err)
+	assert.Equal(t, "Leonard", m.match.Opponent)
+	assert.Equal(t, types.SideRight, m.match.Side)
+	assert.Greater(t, time.Since(start), 300*time.Millisecond)
+	require.NoError(t, (<-leonard).err)
+}
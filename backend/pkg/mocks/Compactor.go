@@ -0,0 +1,134 @@
+// Code generated by mockery v2.15.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+
+	types "github.com/complynx/rpssl4bu/backend/pkg/types"
+)
+
+// Compactor is an autogenerated mock type for the Compactor type
+type Compactor struct {
+	mock.Mock
+}
+
+type Compactor_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Compactor) EXPECT() *Compactor_Expecter {
+	return &Compactor_Expecter{mock: &_m.Mock}
+}
+
+// Compact provides a mock function with given fields: ctx, policy, now
+func (_m *Compactor) Compact(ctx context.Context, policy types.RetentionPolicy, now time.Time) (int, error) {
+	ret := _m.Called(ctx, policy, now)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, types.RetentionPolicy, time.Time) int); ok {
+		r0 = rf(ctx, policy, now)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, types.RetentionPolicy, time.Time) error); ok {
+		r1 = rf(ctx, policy, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Compactor_Compact_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Compact'
+type Compactor_Compact_Call struct {
+	*mock.Call
+}
+
+// Compact is a helper method to define mock.On call
+//   - ctx context.Context
+//   - policy types.RetentionPolicy
+//   - now time.Time
+func (_e *Compactor_Expecter) Compact(ctx interface{}, policy interface{}, now interface{}) *Compactor_Compact_Call {
+	return &Compactor_Compact_Call{Call: _e.mock.On("Compact", ctx, policy, now)}
+}
+
+func (_c *Compactor_Compact_Call) Run(run func(ctx context.Context, policy types.RetentionPolicy, now time.Time)) *Compactor_Compact_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(types.RetentionPolicy), args[2].(time.Time))
+	})
+	return _c
+}
+
+func (_c *Compactor_Compact_Call) Return(_a0 int, _a1 error) *Compactor_Compact_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+// GetAggregates provides a mock function with given fields: ctx, owner
+func (_m *Compactor) GetAggregates(ctx context.Context, owner types.Owner) ([]types.DailyAggregate, error) {
+	ret := _m.Called(ctx, owner)
+
+	var r0 []types.DailyAggregate
+	if rf, ok := ret.Get(0).(func(context.Context, types.Owner) []types.DailyAggregate); ok {
+		r0 = rf(ctx, owner)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.DailyAggregate)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, types.Owner) error); ok {
+		r1 = rf(ctx, owner)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Compactor_GetAggregates_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAggregates'
+type Compactor_GetAggregates_Call struct {
+	*mock.Call
+}
+
+// GetAggregates is a helper method to define mock.On call
+//   - ctx context.Context
+//   - owner types.Owner
+func (_e *Compactor_Expecter) GetAggregates(ctx interface{}, owner interface{}) *Compactor_GetAggregates_Call {
+	return &Compactor_GetAggregates_Call{Call: _e.mock.On("GetAggregates", ctx, owner)}
+}
+
+func (_c *Compactor_GetAggregates_Call) Run(run func(ctx context.Context, owner types.Owner)) *Compactor_GetAggregates_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(types.Owner))
+	})
+	return _c
+}
+
+func (_c *Compactor_GetAggregates_Call) Return(_a0 []types.DailyAggregate, _a1 error) *Compactor_GetAggregates_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+type mockConstructorTestingTNewCompactor interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewCompactor creates a new instance of Compactor. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewCompactor(t mockConstructorTestingTNewCompactor) *Compactor {
+	mock := &Compactor{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,228 @@
+// Code generated by mockery v2.15.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	mock "github.com/stretchr/testify/mock"
+
+	types "github.com/complynx/rpssl4bu/backend/pkg/types"
+)
+
+// EventLog is an autogenerated mock type for the EventLog type
+type EventLog struct {
+	mock.Mock
+}
+
+type EventLog_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *EventLog) EXPECT() *EventLog_Expecter {
+	return &EventLog_Expecter{mock: &_m.Mock}
+}
+
+// Append provides a mock function with given fields: event
+func (_m *EventLog) Append(event types.Event) (types.Event, error) {
+	ret := _m.Called(event)
+
+	var r0 types.Event
+	if rf, ok := ret.Get(0).(func(types.Event) types.Event); ok {
+		r0 = rf(event)
+	} else {
+		r0 = ret.Get(0).(types.Event)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(types.Event) error); ok {
+		r1 = rf(event)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EventLog_Append_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Append'
+type EventLog_Append_Call struct {
+	*mock.Call
+}
+
+// Append is a helper method to define mock.On call
+//   - event types.Event
+func (_e *EventLog_Expecter) Append(event interface{}) *EventLog_Append_Call {
+	return &EventLog_Append_Call{Call: _e.mock.On("Append", event)}
+}
+
+func (_c *EventLog_Append_Call) Run(run func(event types.Event)) *EventLog_Append_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(types.Event))
+	})
+	return _c
+}
+
+func (_c *EventLog_Append_Call) Return(_a0 types.Event, _a1 error) *EventLog_Append_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+// Close provides a mock function with given fields:
+func (_m *EventLog) Close() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EventLog_Close_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Close'
+type EventLog_Close_Call struct {
+	*mock.Call
+}
+
+// Close is a helper method to define mock.On call
+func (_e *EventLog_Expecter) Close() *EventLog_Close_Call {
+	return &EventLog_Close_Call{Call: _e.mock.On("Close")}
+}
+
+func (_c *EventLog_Close_Call) Run(run func()) *EventLog_Close_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *EventLog_Close_Call) Return(_a0 error) *EventLog_Close_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// LastSeq provides a mock function with given fields:
+func (_m *EventLog) LastSeq() uint64 {
+	ret := _m.Called()
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func() uint64); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	return r0
+}
+
+// EventLog_LastSeq_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LastSeq'
+type EventLog_LastSeq_Call struct {
+	*mock.Call
+}
+
+// LastSeq is a helper method to define mock.On call
+func (_e *EventLog_Expecter) LastSeq() *EventLog_LastSeq_Call {
+	return &EventLog_LastSeq_Call{Call: _e.mock.On("LastSeq")}
+}
+
+func (_c *EventLog_LastSeq_Call) Run(run func()) *EventLog_LastSeq_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *EventLog_LastSeq_Call) Return(_a0 uint64) *EventLog_LastSeq_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// Prune provides a mock function with given fields: before
+func (_m *EventLog) Prune(before uint64) error {
+	ret := _m.Called(before)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint64) error); ok {
+		r0 = rf(before)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EventLog_Prune_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Prune'
+type EventLog_Prune_Call struct {
+	*mock.Call
+}
+
+// Prune is a helper method to define mock.On call
+//   - before uint64
+func (_e *EventLog_Expecter) Prune(before interface{}) *EventLog_Prune_Call {
+	return &EventLog_Prune_Call{Call: _e.mock.On("Prune", before)}
+}
+
+func (_c *EventLog_Prune_Call) Run(run func(before uint64)) *EventLog_Prune_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uint64))
+	})
+	return _c
+}
+
+func (_c *EventLog_Prune_Call) Return(_a0 error) *EventLog_Prune_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// Replay provides a mock function with given fields: from, fn
+func (_m *EventLog) Replay(from uint64, fn func(types.Event) error) error {
+	ret := _m.Called(from, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint64, func(types.Event) error) error); ok {
+		r0 = rf(from, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EventLog_Replay_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Replay'
+type EventLog_Replay_Call struct {
+	*mock.Call
+}
+
+// Replay is a helper method to define mock.On call
+//   - from uint64
+//   - fn func(types.Event) error
+func (_e *EventLog_Expecter) Replay(from interface{}, fn interface{}) *EventLog_Replay_Call {
+	return &EventLog_Replay_Call{Call: _e.mock.On("Replay", from, fn)}
+}
+
+func (_c *EventLog_Replay_Call) Run(run func(from uint64, fn func(types.Event) error)) *EventLog_Replay_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uint64), args[1].(func(types.Event) error))
+	})
+	return _c
+}
+
+func (_c *EventLog_Replay_Call) Return(_a0 error) *EventLog_Replay_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+type mockConstructorTestingTNewEventLog interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewEventLog creates a new instance of EventLog. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewEventLog(t mockConstructorTestingTNewEventLog) *EventLog {
+	mock := &EventLog{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,77 @@
+// Code generated by mockery v2.15.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	types "github.com/complynx/rpssl4bu/backend/pkg/types"
+)
+
+// EventRecorder is an autogenerated mock type for the EventRecorder type
+type EventRecorder struct {
+	mock.Mock
+}
+
+type EventRecorder_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *EventRecorder) EXPECT() *EventRecorder_Expecter {
+	return &EventRecorder_Expecter{mock: &_m.Mock}
+}
+
+// RecordEvent provides a mock function with given fields: ctx, event
+func (_m *EventRecorder) RecordEvent(ctx context.Context, event types.Event) error {
+	ret := _m.Called(ctx, event)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, types.Event) error); ok {
+		r0 = rf(ctx, event)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EventRecorder_RecordEvent_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RecordEvent'
+type EventRecorder_RecordEvent_Call struct {
+	*mock.Call
+}
+
+// RecordEvent is a helper method to define mock.On call
+//   - ctx context.Context
+//   - event types.Event
+func (_e *EventRecorder_Expecter) RecordEvent(ctx interface{}, event interface{}) *EventRecorder_RecordEvent_Call {
+	return &EventRecorder_RecordEvent_Call{Call: _e.mock.On("RecordEvent", ctx, event)}
+}
+
+func (_c *EventRecorder_RecordEvent_Call) Run(run func(ctx context.Context, event types.Event)) *EventRecorder_RecordEvent_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(types.Event))
+	})
+	return _c
+}
+
+func (_c *EventRecorder_RecordEvent_Call) Return(_a0 error) *EventRecorder_RecordEvent_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+type mockConstructorTestingTNewEventRecorder interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewEventRecorder creates a new instance of EventRecorder. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewEventRecorder(t mockConstructorTestingTNewEventRecorder) *EventRecorder {
+	mock := &EventRecorder{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -165,6 +165,78 @@ func (_c *Game_Play_Call) Return(_a0 types.Result, _a1 types.Choice, _a2 error)
 	return _c
 }
 
+// RNGSource provides a mock function with given fields:
+func (_m *Game) RNGSource() string {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// Game_RNGSource_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RNGSource'
+type Game_RNGSource_Call struct {
+	*mock.Call
+}
+
+// RNGSource is a helper method to define mock.On call
+func (_e *Game_Expecter) RNGSource() *Game_RNGSource_Call {
+	return &Game_RNGSource_Call{Call: _e.mock.On("RNGSource")}
+}
+
+func (_c *Game_RNGSource_Call) Run(run func()) *Game_RNGSource_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Game_RNGSource_Call) Return(_a0 string) *Game_RNGSource_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// Strategy provides a mock function with given fields:
+func (_m *Game) Strategy() string {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// Game_Strategy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Strategy'
+type Game_Strategy_Call struct {
+	*mock.Call
+}
+
+// Strategy is a helper method to define mock.On call
+func (_e *Game_Expecter) Strategy() *Game_Strategy_Call {
+	return &Game_Strategy_Call{Call: _e.mock.On("Strategy")}
+}
+
+func (_c *Game_Strategy_Call) Run(run func()) *Game_Strategy_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Game_Strategy_Call) Return(_a0 string) *Game_Strategy_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
 type mockConstructorTestingTNewGame interface {
 	mock.TestingT
 	Cleanup(func())
@@ -3,6 +3,7 @@
 package mocks
 
 import (
+	context "context"
 	http "net/http"
 
 	mock "github.com/stretchr/testify/mock"
@@ -21,6 +22,209 @@ func (_m *GameAPI) EXPECT() *GameAPI_Expecter {
 	return &GameAPI_Expecter{mock: &_m.Mock}
 }
 
+// AdminEndGame provides a mock function with given fields: w, r
+func (_m *GameAPI) AdminEndGame(w http.ResponseWriter, r *http.Request) {
+	_m.Called(w, r)
+}
+
+// GameAPI_AdminEndGame_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AdminEndGame'
+type GameAPI_AdminEndGame_Call struct {
+	*mock.Call
+}
+
+// AdminEndGame is a helper method to define mock.On call
+//   - w http.ResponseWriter
+//   - r *http.Request
+func (_e *GameAPI_Expecter) AdminEndGame(w interface{}, r interface{}) *GameAPI_AdminEndGame_Call {
+	return &GameAPI_AdminEndGame_Call{Call: _e.mock.On("AdminEndGame", w, r)}
+}
+
+func (_c *GameAPI_AdminEndGame_Call) Run(run func(w http.ResponseWriter, r *http.Request)) *GameAPI_AdminEndGame_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(http.ResponseWriter), args[1].(*http.Request))
+	})
+	return _c
+}
+
+func (_c *GameAPI_AdminEndGame_Call) Return() *GameAPI_AdminEndGame_Call {
+	_c.Call.Return()
+	return _c
+}
+
+// AdminGame provides a mock function with given fields: w, r
+func (_m *GameAPI) AdminGame(w http.ResponseWriter, r *http.Request) {
+	_m.Called(w, r)
+}
+
+// GameAPI_AdminGame_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AdminGame'
+type GameAPI_AdminGame_Call struct {
+	*mock.Call
+}
+
+// AdminGame is a helper method to define mock.On call
+//   - w http.ResponseWriter
+//   - r *http.Request
+func (_e *GameAPI_Expecter) AdminGame(w interface{}, r interface{}) *GameAPI_AdminGame_Call {
+	return &GameAPI_AdminGame_Call{Call: _e.mock.On("AdminGame", w, r)}
+}
+
+func (_c *GameAPI_AdminGame_Call) Run(run func(w http.ResponseWriter, r *http.Request)) *GameAPI_AdminGame_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(http.ResponseWriter), args[1].(*http.Request))
+	})
+	return _c
+}
+
+func (_c *GameAPI_AdminGame_Call) Return() *GameAPI_AdminGame_Call {
+	_c.Call.Return()
+	return _c
+}
+
+// AdminGames provides a mock function with given fields: w, r
+func (_m *GameAPI) AdminGames(w http.ResponseWriter, r *http.Request) {
+	_m.Called(w, r)
+}
+
+// GameAPI_AdminGames_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AdminGames'
+type GameAPI_AdminGames_Call struct {
+	*mock.Call
+}
+
+// AdminGames is a helper method to define mock.On call
+//   - w http.ResponseWriter
+//   - r *http.Request
+func (_e *GameAPI_Expecter) AdminGames(w interface{}, r interface{}) *GameAPI_AdminGames_Call {
+	return &GameAPI_AdminGames_Call{Call: _e.mock.On("AdminGames", w, r)}
+}
+
+func (_c *GameAPI_AdminGames_Call) Run(run func(w http.ResponseWriter, r *http.Request)) *GameAPI_AdminGames_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(http.ResponseWriter), args[1].(*http.Request))
+	})
+	return _c
+}
+
+func (_c *GameAPI_AdminGames_Call) Return() *GameAPI_AdminGames_Call {
+	_c.Call.Return()
+	return _c
+}
+
+// AdminKick provides a mock function with given fields: w, r
+func (_m *GameAPI) AdminKick(w http.ResponseWriter, r *http.Request) {
+	_m.Called(w, r)
+}
+
+// GameAPI_AdminKick_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AdminKick'
+type GameAPI_AdminKick_Call struct {
+	*mock.Call
+}
+
+// AdminKick is a helper method to define mock.On call
+//   - w http.ResponseWriter
+//   - r *http.Request
+func (_e *GameAPI_Expecter) AdminKick(w interface{}, r interface{}) *GameAPI_AdminKick_Call {
+	return &GameAPI_AdminKick_Call{Call: _e.mock.On("AdminKick", w, r)}
+}
+
+func (_c *GameAPI_AdminKick_Call) Run(run func(w http.ResponseWriter, r *http.Request)) *GameAPI_AdminKick_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(http.ResponseWriter), args[1].(*http.Request))
+	})
+	return _c
+}
+
+func (_c *GameAPI_AdminKick_Call) Return() *GameAPI_AdminKick_Call {
+	_c.Call.Return()
+	return _c
+}
+
+// AdminNotice provides a mock function with given fields: w, r
+func (_m *GameAPI) AdminNotice(w http.ResponseWriter, r *http.Request) {
+	_m.Called(w, r)
+}
+
+// GameAPI_AdminNotice_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AdminNotice'
+type GameAPI_AdminNotice_Call struct {
+	*mock.Call
+}
+
+// AdminNotice is a helper method to define mock.On call
+//   - w http.ResponseWriter
+//   - r *http.Request
+func (_e *GameAPI_Expecter) AdminNotice(w interface{}, r interface{}) *GameAPI_AdminNotice_Call {
+	return &GameAPI_AdminNotice_Call{Call: _e.mock.On("AdminNotice", w, r)}
+}
+
+func (_c *GameAPI_AdminNotice_Call) Run(run func(w http.ResponseWriter, r *http.Request)) *GameAPI_AdminNotice_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(http.ResponseWriter), args[1].(*http.Request))
+	})
+	return _c
+}
+
+func (_c *GameAPI_AdminNotice_Call) Return() *GameAPI_AdminNotice_Call {
+	_c.Call.Return()
+	return _c
+}
+
+// AdminTap provides a mock function with given fields: w, r
+func (_m *GameAPI) AdminTap(w http.ResponseWriter, r *http.Request) {
+	_m.Called(w, r)
+}
+
+// GameAPI_AdminTap_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AdminTap'
+type GameAPI_AdminTap_Call struct {
+	*mock.Call
+}
+
+// AdminTap is a helper method to define mock.On call
+//   - w http.ResponseWriter
+//   - r *http.Request
+func (_e *GameAPI_Expecter) AdminTap(w interface{}, r interface{}) *GameAPI_AdminTap_Call {
+	return &GameAPI_AdminTap_Call{Call: _e.mock.On("AdminTap", w, r)}
+}
+
+func (_c *GameAPI_AdminTap_Call) Run(run func(w http.ResponseWriter, r *http.Request)) *GameAPI_AdminTap_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(http.ResponseWriter), args[1].(*http.Request))
+	})
+	return _c
+}
+
+func (_c *GameAPI_AdminTap_Call) Return() *GameAPI_AdminTap_Call {
+	_c.Call.Return()
+	return _c
+}
+
+// Audit provides a mock function with given fields: w, r
+func (_m *GameAPI) Audit(w http.ResponseWriter, r *http.Request) {
+	_m.Called(w, r)
+}
+
+// GameAPI_Audit_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Audit'
+type GameAPI_Audit_Call struct {
+	*mock.Call
+}
+
+// Audit is a helper method to define mock.On call
+//   - w http.ResponseWriter
+//   - r *http.Request
+func (_e *GameAPI_Expecter) Audit(w interface{}, r interface{}) *GameAPI_Audit_Call {
+	return &GameAPI_Audit_Call{Call: _e.mock.On("Audit", w, r)}
+}
+
+func (_c *GameAPI_Audit_Call) Run(run func(w http.ResponseWriter, r *http.Request)) *GameAPI_Audit_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(http.ResponseWriter), args[1].(*http.Request))
+	})
+	return _c
+}
+
+func (_c *GameAPI_Audit_Call) Return() *GameAPI_Audit_Call {
+	_c.Call.Return()
+	return _c
+}
+
 // Choice provides a mock function with given fields: _a0, _a1
 func (_m *GameAPI) Choice(_a0 http.ResponseWriter, _a1 *http.Request) {
 	_m.Called(_a0, _a1)
@@ -166,6 +370,35 @@ func (_c *GameAPI_CreateP2P_Call) Return() *GameAPI_CreateP2P_Call {
 	return _c
 }
 
+// Export provides a mock function with given fields: w, r
+func (_m *GameAPI) Export(w http.ResponseWriter, r *http.Request) {
+	_m.Called(w, r)
+}
+
+// GameAPI_Export_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Export'
+type GameAPI_Export_Call struct {
+	*mock.Call
+}
+
+// Export is a helper method to define mock.On call
+//   - w http.ResponseWriter
+//   - r *http.Request
+func (_e *GameAPI_Expecter) Export(w interface{}, r interface{}) *GameAPI_Export_Call {
+	return &GameAPI_Export_Call{Call: _e.mock.On("Export", w, r)}
+}
+
+func (_c *GameAPI_Export_Call) Run(run func(w http.ResponseWriter, r *http.Request)) *GameAPI_Export_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(http.ResponseWriter), args[1].(*http.Request))
+	})
+	return _c
+}
+
+func (_c *GameAPI_Export_Call) Return() *GameAPI_Export_Call {
+	_c.Call.Return()
+	return _c
+}
+
 // FindP2PGame provides a mock function with given fields: w, r
 func (_m *GameAPI) FindP2PGame(w http.ResponseWriter, r *http.Request) {
 	_m.Called(w, r)
@@ -224,6 +457,267 @@ func (_c *GameAPI_GetScores_Call) Return() *GameAPI_GetScores_Call {
 	return _c
 }
 
+// History provides a mock function with given fields: w, r
+func (_m *GameAPI) History(w http.ResponseWriter, r *http.Request) {
+	_m.Called(w, r)
+}
+
+// GameAPI_History_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'History'
+type GameAPI_History_Call struct {
+	*mock.Call
+}
+
+// History is a helper method to define mock.On call
+//   - w http.ResponseWriter
+//   - r *http.Request
+func (_e *GameAPI_Expecter) History(w interface{}, r interface{}) *GameAPI_History_Call {
+	return &GameAPI_History_Call{Call: _e.mock.On("History", w, r)}
+}
+
+func (_c *GameAPI_History_Call) Run(run func(w http.ResponseWriter, r *http.Request)) *GameAPI_History_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(http.ResponseWriter), args[1].(*http.Request))
+	})
+	return _c
+}
+
+func (_c *GameAPI_History_Call) Return() *GameAPI_History_Call {
+	_c.Call.Return()
+	return _c
+}
+
+// Import provides a mock function with given fields: w, r
+func (_m *GameAPI) Import(w http.ResponseWriter, r *http.Request) {
+	_m.Called(w, r)
+}
+
+// GameAPI_Import_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Import'
+type GameAPI_Import_Call struct {
+	*mock.Call
+}
+
+// Import is a helper method to define mock.On call
+//   - w http.ResponseWriter
+//   - r *http.Request
+func (_e *GameAPI_Expecter) Import(w interface{}, r interface{}) *GameAPI_Import_Call {
+	return &GameAPI_Import_Call{Call: _e.mock.On("Import", w, r)}
+}
+
+func (_c *GameAPI_Import_Call) Run(run func(w http.ResponseWriter, r *http.Request)) *GameAPI_Import_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(http.ResponseWriter), args[1].(*http.Request))
+	})
+	return _c
+}
+
+func (_c *GameAPI_Import_Call) Return() *GameAPI_Import_Call {
+	_c.Call.Return()
+	return _c
+}
+
+// Invite provides a mock function with given fields: w, r
+func (_m *GameAPI) Invite(w http.ResponseWriter, r *http.Request) {
+	_m.Called(w, r)
+}
+
+// GameAPI_Invite_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Invite'
+type GameAPI_Invite_Call struct {
+	*mock.Call
+}
+
+// Invite is a helper method to define mock.On call
+//   - w http.ResponseWriter
+//   - r *http.Request
+func (_e *GameAPI_Expecter) Invite(w interface{}, r interface{}) *GameAPI_Invite_Call {
+	return &GameAPI_Invite_Call{Call: _e.mock.On("Invite", w, r)}
+}
+
+func (_c *GameAPI_Invite_Call) Run(run func(w http.ResponseWriter, r *http.Request)) *GameAPI_Invite_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(http.ResponseWriter), args[1].(*http.Request))
+	})
+	return _c
+}
+
+func (_c *GameAPI_Invite_Call) Return() *GameAPI_Invite_Call {
+	_c.Call.Return()
+	return _c
+}
+
+// Kick provides a mock function with given fields: w, r
+func (_m *GameAPI) Kick(w http.ResponseWriter, r *http.Request) {
+	_m.Called(w, r)
+}
+
+// GameAPI_Kick_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Kick'
+type GameAPI_Kick_Call struct {
+	*mock.Call
+}
+
+// Kick is a helper method to define mock.On call
+//   - w http.ResponseWriter
+//   - r *http.Request
+func (_e *GameAPI_Expecter) Kick(w interface{}, r interface{}) *GameAPI_Kick_Call {
+	return &GameAPI_Kick_Call{Call: _e.mock.On("Kick", w, r)}
+}
+
+func (_c *GameAPI_Kick_Call) Run(run func(w http.ResponseWriter, r *http.Request)) *GameAPI_Kick_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(http.ResponseWriter), args[1].(*http.Request))
+	})
+	return _c
+}
+
+func (_c *GameAPI_Kick_Call) Return() *GameAPI_Kick_Call {
+	_c.Call.Return()
+	return _c
+}
+
+// Leaderboard provides a mock function with given fields: w, r
+func (_m *GameAPI) Leaderboard(w http.ResponseWriter, r *http.Request) {
+	_m.Called(w, r)
+}
+
+// GameAPI_Leaderboard_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Leaderboard'
+type GameAPI_Leaderboard_Call struct {
+	*mock.Call
+}
+
+// Leaderboard is a helper method to define mock.On call
+//   - w http.ResponseWriter
+//   - r *http.Request
+func (_e *GameAPI_Expecter) Leaderboard(w interface{}, r interface{}) *GameAPI_Leaderboard_Call {
+	return &GameAPI_Leaderboard_Call{Call: _e.mock.On("Leaderboard", w, r)}
+}
+
+func (_c *GameAPI_Leaderboard_Call) Run(run func(w http.ResponseWriter, r *http.Request)) *GameAPI_Leaderboard_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(http.ResponseWriter), args[1].(*http.Request))
+	})
+	return _c
+}
+
+func (_c *GameAPI_Leaderboard_Call) Return() *GameAPI_Leaderboard_Call {
+	_c.Call.Return()
+	return _c
+}
+
+// Lobby provides a mock function with given fields: w, r
+func (_m *GameAPI) Lobby(w http.ResponseWriter, r *http.Request) {
+	_m.Called(w, r)
+}
+
+// GameAPI_Lobby_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Lobby'
+type GameAPI_Lobby_Call struct {
+	*mock.Call
+}
+
+// Lobby is a helper method to define mock.On call
+//   - w http.ResponseWriter
+//   - r *http.Request
+func (_e *GameAPI_Expecter) Lobby(w interface{}, r interface{}) *GameAPI_Lobby_Call {
+	return &GameAPI_Lobby_Call{Call: _e.mock.On("Lobby", w, r)}
+}
+
+func (_c *GameAPI_Lobby_Call) Run(run func(w http.ResponseWriter, r *http.Request)) *GameAPI_Lobby_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(http.ResponseWriter), args[1].(*http.Request))
+	})
+	return _c
+}
+
+func (_c *GameAPI_Lobby_Call) Return() *GameAPI_Lobby_Call {
+	_c.Call.Return()
+	return _c
+}
+
+// Matchmake provides a mock function with given fields: w, r
+func (_m *GameAPI) Matchmake(w http.ResponseWriter, r *http.Request) {
+	_m.Called(w, r)
+}
+
+// GameAPI_Matchmake_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Matchmake'
+type GameAPI_Matchmake_Call struct {
+	*mock.Call
+}
+
+// Matchmake is a helper method to define mock.On call
+//   - w http.ResponseWriter
+//   - r *http.Request
+func (_e *GameAPI_Expecter) Matchmake(w interface{}, r interface{}) *GameAPI_Matchmake_Call {
+	return &GameAPI_Matchmake_Call{Call: _e.mock.On("Matchmake", w, r)}
+}
+
+func (_c *GameAPI_Matchmake_Call) Run(run func(w http.ResponseWriter, r *http.Request)) *GameAPI_Matchmake_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(http.ResponseWriter), args[1].(*http.Request))
+	})
+	return _c
+}
+
+func (_c *GameAPI_Matchmake_Call) Return() *GameAPI_Matchmake_Call {
+	_c.Call.Return()
+	return _c
+}
+
+// P2PGames provides a mock function with given fields: w, r
+func (_m *GameAPI) P2PGames(w http.ResponseWriter, r *http.Request) {
+	_m.Called(w, r)
+}
+
+// GameAPI_P2PGames_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'P2PGames'
+type GameAPI_P2PGames_Call struct {
+	*mock.Call
+}
+
+// P2PGames is a helper method to define mock.On call
+//   - w http.ResponseWriter
+//   - r *http.Request
+func (_e *GameAPI_Expecter) P2PGames(w interface{}, r interface{}) *GameAPI_P2PGames_Call {
+	return &GameAPI_P2PGames_Call{Call: _e.mock.On("P2PGames", w, r)}
+}
+
+func (_c *GameAPI_P2PGames_Call) Run(run func(w http.ResponseWriter, r *http.Request)) *GameAPI_P2PGames_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(http.ResponseWriter), args[1].(*http.Request))
+	})
+	return _c
+}
+
+func (_c *GameAPI_P2PGames_Call) Return() *GameAPI_P2PGames_Call {
+	_c.Call.Return()
+	return _c
+}
+
+// P2PScore provides a mock function with given fields: w, r
+func (_m *GameAPI) P2PScore(w http.ResponseWriter, r *http.Request) {
+	_m.Called(w, r)
+}
+
+// GameAPI_P2PScore_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'P2PScore'
+type GameAPI_P2PScore_Call struct {
+	*mock.Call
+}
+
+// P2PScore is a helper method to define mock.On call
+//   - w http.ResponseWriter
+//   - r *http.Request
+func (_e *GameAPI_Expecter) P2PScore(w interface{}, r interface{}) *GameAPI_P2PScore_Call {
+	return &GameAPI_P2PScore_Call{Call: _e.mock.On("P2PScore", w, r)}
+}
+
+func (_c *GameAPI_P2PScore_Call) Run(run func(w http.ResponseWriter, r *http.Request)) *GameAPI_P2PScore_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(http.ResponseWriter), args[1].(*http.Request))
+	})
+	return _c
+}
+
+func (_c *GameAPI_P2PScore_Call) Return() *GameAPI_P2PScore_Call {
+	_c.Call.Return()
+	return _c
+}
+
 // Play provides a mock function with given fields: _a0, _a1
 func (_m *GameAPI) Play(_a0 http.ResponseWriter, _a1 *http.Request) {
 	_m.Called(_a0, _a1)
@@ -253,6 +747,150 @@ func (_c *GameAPI_Play_Call) Return() *GameAPI_Play_Call {
 	return _c
 }
 
+// RestoreScores provides a mock function with given fields: w, r
+func (_m *GameAPI) RestoreScores(w http.ResponseWriter, r *http.Request) {
+	_m.Called(w, r)
+}
+
+// GameAPI_RestoreScores_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RestoreScores'
+type GameAPI_RestoreScores_Call struct {
+	*mock.Call
+}
+
+// RestoreScores is a helper method to define mock.On call
+//   - w http.ResponseWriter
+//   - r *http.Request
+func (_e *GameAPI_Expecter) RestoreScores(w interface{}, r interface{}) *GameAPI_RestoreScores_Call {
+	return &GameAPI_RestoreScores_Call{Call: _e.mock.On("RestoreScores", w, r)}
+}
+
+func (_c *GameAPI_RestoreScores_Call) Run(run func(w http.ResponseWriter, r *http.Request)) *GameAPI_RestoreScores_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(http.ResponseWriter), args[1].(*http.Request))
+	})
+	return _c
+}
+
+func (_c *GameAPI_RestoreScores_Call) Return() *GameAPI_RestoreScores_Call {
+	_c.Call.Return()
+	return _c
+}
+
+// Shutdown provides a mock function with given fields: ctx
+func (_m *GameAPI) Shutdown(ctx context.Context) {
+	_m.Called(ctx)
+}
+
+// GameAPI_Shutdown_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Shutdown'
+type GameAPI_Shutdown_Call struct {
+	*mock.Call
+}
+
+// Shutdown is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *GameAPI_Expecter) Shutdown(ctx interface{}) *GameAPI_Shutdown_Call {
+	return &GameAPI_Shutdown_Call{Call: _e.mock.On("Shutdown", ctx)}
+}
+
+func (_c *GameAPI_Shutdown_Call) Run(run func(ctx context.Context)) *GameAPI_Shutdown_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *GameAPI_Shutdown_Call) Return() *GameAPI_Shutdown_Call {
+	_c.Call.Return()
+	return _c
+}
+
+// Stats provides a mock function with given fields: w, r
+func (_m *GameAPI) Stats(w http.ResponseWriter, r *http.Request) {
+	_m.Called(w, r)
+}
+
+// GameAPI_Stats_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Stats'
+type GameAPI_Stats_Call struct {
+	*mock.Call
+}
+
+// Stats is a helper method to define mock.On call
+//   - w http.ResponseWriter
+//   - r *http.Request
+func (_e *GameAPI_Expecter) Stats(w interface{}, r interface{}) *GameAPI_Stats_Call {
+	return &GameAPI_Stats_Call{Call: _e.mock.On("Stats", w, r)}
+}
+
+func (_c *GameAPI_Stats_Call) Run(run func(w http.ResponseWriter, r *http.Request)) *GameAPI_Stats_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(http.ResponseWriter), args[1].(*http.Request))
+	})
+	return _c
+}
+
+func (_c *GameAPI_Stats_Call) Return() *GameAPI_Stats_Call {
+	_c.Call.Return()
+	return _c
+}
+
+// StreamScores provides a mock function with given fields: w, r
+func (_m *GameAPI) StreamScores(w http.ResponseWriter, r *http.Request) {
+	_m.Called(w, r)
+}
+
+// GameAPI_StreamScores_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StreamScores'
+type GameAPI_StreamScores_Call struct {
+	*mock.Call
+}
+
+// StreamScores is a helper method to define mock.On call
+//   - w http.ResponseWriter
+//   - r *http.Request
+func (_e *GameAPI_Expecter) StreamScores(w interface{}, r interface{}) *GameAPI_StreamScores_Call {
+	return &GameAPI_StreamScores_Call{Call: _e.mock.On("StreamScores", w, r)}
+}
+
+func (_c *GameAPI_StreamScores_Call) Run(run func(w http.ResponseWriter, r *http.Request)) *GameAPI_StreamScores_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(http.ResponseWriter), args[1].(*http.Request))
+	})
+	return _c
+}
+
+func (_c *GameAPI_StreamScores_Call) Return() *GameAPI_StreamScores_Call {
+	_c.Call.Return()
+	return _c
+}
+
+// WatchP2P provides a mock function with given fields: w, r
+func (_m *GameAPI) WatchP2P(w http.ResponseWriter, r *http.Request) {
+	_m.Called(w, r)
+}
+
+// GameAPI_WatchP2P_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WatchP2P'
+type GameAPI_WatchP2P_Call struct {
+	*mock.Call
+}
+
+// WatchP2P is a helper method to define mock.On call
+//   - w http.ResponseWriter
+//   - r *http.Request
+func (_e *GameAPI_Expecter) WatchP2P(w interface{}, r interface{}) *GameAPI_WatchP2P_Call {
+	return &GameAPI_WatchP2P_Call{Call: _e.mock.On("WatchP2P", w, r)}
+}
+
+func (_c *GameAPI_WatchP2P_Call) Run(run func(w http.ResponseWriter, r *http.Request)) *GameAPI_WatchP2P_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(http.ResponseWriter), args[1].(*http.Request))
+	})
+	return _c
+}
+
+func (_c *GameAPI_WatchP2P_Call) Return() *GameAPI_WatchP2P_Call {
+	_c.Call.Return()
+	return _c
+}
+
 type mockConstructorTestingTNewGameAPI interface {
 	mock.TestingT
 	Cleanup(func())
@@ -0,0 +1,161 @@
+// Code generated by mockery v2.15.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	types "github.com/complynx/rpssl4bu/backend/pkg/types"
+)
+
+// GameStore is an autogenerated mock type for the GameStore type
+type GameStore struct {
+	mock.Mock
+}
+
+type GameStore_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *GameStore) EXPECT() *GameStore_Expecter {
+	return &GameStore_Expecter{mock: &_m.Mock}
+}
+
+// DeleteGame provides a mock function with given fields: ctx, id
+func (_m *GameStore) DeleteGame(ctx context.Context, id types.GameID) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, types.GameID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GameStore_DeleteGame_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteGame'
+type GameStore_DeleteGame_Call struct {
+	*mock.Call
+}
+
+// DeleteGame is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id types.GameID
+func (_e *GameStore_Expecter) DeleteGame(ctx interface{}, id interface{}) *GameStore_DeleteGame_Call {
+	return &GameStore_DeleteGame_Call{Call: _e.mock.On("DeleteGame", ctx, id)}
+}
+
+func (_c *GameStore_DeleteGame_Call) Run(run func(ctx context.Context, id types.GameID)) *GameStore_DeleteGame_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(types.GameID))
+	})
+	return _c
+}
+
+func (_c *GameStore_DeleteGame_Call) Return(_a0 error) *GameStore_DeleteGame_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// LoadGames provides a mock function with given fields: ctx
+func (_m *GameStore) LoadGames(ctx context.Context) ([]types.GameSnapshot, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []types.GameSnapshot
+	if rf, ok := ret.Get(0).(func(context.Context) []types.GameSnapshot); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.GameSnapshot)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GameStore_LoadGames_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LoadGames'
+type GameStore_LoadGames_Call struct {
+	*mock.Call
+}
+
+// LoadGames is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *GameStore_Expecter) LoadGames(ctx interface{}) *GameStore_LoadGames_Call {
+	return &GameStore_LoadGames_Call{Call: _e.mock.On("LoadGames", ctx)}
+}
+
+func (_c *GameStore_LoadGames_Call) Run(run func(ctx context.Context)) *GameStore_LoadGames_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *GameStore_LoadGames_Call) Return(_a0 []types.GameSnapshot, _a1 error) *GameStore_LoadGames_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+// SaveGame provides a mock function with given fields: ctx, game
+func (_m *GameStore) SaveGame(ctx context.Context, game types.GameSnapshot) error {
+	ret := _m.Called(ctx, game)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, types.GameSnapshot) error); ok {
+		r0 = rf(ctx, game)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GameStore_SaveGame_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveGame'
+type GameStore_SaveGame_Call struct {
+	*mock.Call
+}
+
+// SaveGame is a helper method to define mock.On call
+//   - ctx context.Context
+//   - game types.GameSnapshot
+func (_e *GameStore_Expecter) SaveGame(ctx interface{}, game interface{}) *GameStore_SaveGame_Call {
+	return &GameStore_SaveGame_Call{Call: _e.mock.On("SaveGame", ctx, game)}
+}
+
+func (_c *GameStore_SaveGame_Call) Run(run func(ctx context.Context, game types.GameSnapshot)) *GameStore_SaveGame_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(types.GameSnapshot))
+	})
+	return _c
+}
+
+func (_c *GameStore_SaveGame_Call) Return(_a0 error) *GameStore_SaveGame_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+type mockConstructorTestingTNewGameStore interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewGameStore creates a new instance of GameStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewGameStore(t mockConstructorTestingTNewGameStore) *GameStore {
+	mock := &GameStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,84 @@
+// Code generated by mockery v2.15.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	types "github.com/complynx/rpssl4bu/backend/pkg/types"
+)
+
+// Matchmaker is an autogenerated mock type for the Matchmaker type
+type Matchmaker struct {
+	mock.Mock
+}
+
+type Matchmaker_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Matchmaker) EXPECT() *Matchmaker_Expecter {
+	return &Matchmaker_Expecter{mock: &_m.Mock}
+}
+
+// Match provides a mock function with given fields: ctx, request
+func (_m *Matchmaker) Match(ctx context.Context, request types.MatchRequest) (types.Match, error) {
+	ret := _m.Called(ctx, request)
+
+	var r0 types.Match
+	if rf, ok := ret.Get(0).(func(context.Context, types.MatchRequest) types.Match); ok {
+		r0 = rf(ctx, request)
+	} else {
+		r0 = ret.Get(0).(types.Match)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, types.MatchRequest) error); ok {
+		r1 = rf(ctx, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Matchmaker_Match_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Match'
+type Matchmaker_Match_Call struct {
+	*mock.Call
+}
+
+// Match is a helper method to define mock.On call
+//   - ctx context.Context
+//   - request types.MatchRequest
+func (_e *Matchmaker_Expecter) Match(ctx interface{}, request interface{}) *Matchmaker_Match_Call {
+	return &Matchmaker_Match_Call{Call: _e.mock.On("Match", ctx, request)}
+}
+
+func (_c *Matchmaker_Match_Call) Run(run func(ctx context.Context, request types.MatchRequest)) *Matchmaker_Match_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(types.MatchRequest))
+	})
+	return _c
+}
+
+func (_c *Matchmaker_Match_Call) Return(_a0 types.Match, _a1 error) *Matchmaker_Match_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+type mockConstructorTestingTNewMatchmaker interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMatchmaker creates a new instance of Matchmaker. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMatchmaker(t mockConstructorTestingTNewMatchmaker) *Matchmaker {
+	mock := &Matchmaker{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -23,34 +23,41 @@ func (_m *P2PGame) EXPECT() *P2PGame_Expecter {
 	return &P2PGame_Expecter{mock: &_m.Mock}
 }
 
-// AddPlayer provides a mock function with given fields: name
-func (_m *P2PGame) AddPlayer(name string) (bool, chan types.Message, error) {
-	ret := _m.Called(name)
+// AddPlayer provides a mock function with given fields: name, owner
+func (_m *P2PGame) AddPlayer(name string, owner types.Owner) (bool, chan types.Update, string, error) {
+	ret := _m.Called(name, owner)
 
 	var r0 bool
-	if rf, ok := ret.Get(0).(func(string) bool); ok {
-		r0 = rf(name)
+	if rf, ok := ret.Get(0).(func(string, types.Owner) bool); ok {
+		r0 = rf(name, owner)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
 
-	var r1 chan types.Message
-	if rf, ok := ret.Get(1).(func(string) chan types.Message); ok {
-		r1 = rf(name)
+	var r1 chan types.Update
+	if rf, ok := ret.Get(1).(func(string, types.Owner) chan types.Update); ok {
+		r1 = rf(name, owner)
 	} else {
 		if ret.Get(1) != nil {
-			r1 = ret.Get(1).(chan types.Message)
+			r1 = ret.Get(1).(chan types.Update)
 		}
 	}
 
-	var r2 error
-	if rf, ok := ret.Get(2).(func(string) error); ok {
-		r2 = rf(name)
+	var r2 string
+	if rf, ok := ret.Get(2).(func(string, types.Owner) string); ok {
+		r2 = rf(name, owner)
 	} else {
-		r2 = ret.Error(2)
+		r2 = ret.Get(2).(string)
 	}
 
-	return r0, r1, r2
+	var r3 error
+	if rf, ok := ret.Get(3).(func(string, types.Owner) error); ok {
+		r3 = rf(name, owner)
+	} else {
+		r3 = ret.Error(3)
+	}
+
+	return r0, r1, r2, r3
 }
 
 // P2PGame_AddPlayer_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddPlayer'
@@ -60,25 +67,210 @@ type P2PGame_AddPlayer_Call struct {
 
 // AddPlayer is a helper method to define mock.On call
 //   - name string
-func (_e *P2PGame_Expecter) AddPlayer(name interface{}) *P2PGame_AddPlayer_Call {
-	return &P2PGame_AddPlayer_Call{Call: _e.mock.On("AddPlayer", name)}
+//   - owner types.Owner
+func (_e *P2PGame_Expecter) AddPlayer(name interface{}, owner interface{}) *P2PGame_AddPlayer_Call {
+	return &P2PGame_AddPlayer_Call{Call: _e.mock.On("AddPlayer", name, owner)}
 }
 
-func (_c *P2PGame_AddPlayer_Call) Run(run func(name string)) *P2PGame_AddPlayer_Call {
+func (_c *P2PGame_AddPlayer_Call) Run(run func(name string, owner types.Owner)) *P2PGame_AddPlayer_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(string), args[1].(types.Owner))
 	})
 	return _c
 }
 
-func (_c *P2PGame_AddPlayer_Call) Return(_a0 bool, _a1 chan types.Message, _a2 error) *P2PGame_AddPlayer_Call {
+func (_c *P2PGame_AddPlayer_Call) Return(_a0 bool, _a1 chan types.Update, _a2 string, _a3 error) *P2PGame_AddPlayer_Call {
+	_c.Call.Return(_a0, _a1, _a2, _a3)
+	return _c
+}
+
+// AddPlayerOnSide provides a mock function with given fields: name, owner, rightSide
+func (_m *P2PGame) AddPlayerOnSide(name string, owner types.Owner, rightSide bool) (chan types.Update, string, error) {
+	ret := _m.Called(name, owner, rightSide)
+
+	var r0 chan types.Update
+	if rf, ok := ret.Get(0).(func(string, types.Owner, bool) chan types.Update); ok {
+		r0 = rf(name, owner, rightSide)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(chan types.Update)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(string, types.Owner, bool) string); ok {
+		r1 = rf(name, owner, rightSide)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, types.Owner, bool) error); ok {
+		r2 = rf(name, owner, rightSide)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// P2PGame_AddPlayerOnSide_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddPlayerOnSide'
+type P2PGame_AddPlayerOnSide_Call struct {
+	*mock.Call
+}
+
+// AddPlayerOnSide is a helper method to define mock.On call
+//   - name string
+//   - owner types.Owner
+//   - rightSide bool
+func (_e *P2PGame_Expecter) AddPlayerOnSide(name interface{}, owner interface{}, rightSide interface{}) *P2PGame_AddPlayerOnSide_Call {
+	return &P2PGame_AddPlayerOnSide_Call{Call: _e.mock.On("AddPlayerOnSide", name, owner, rightSide)}
+}
+
+func (_c *P2PGame_AddPlayerOnSide_Call) Run(run func(name string, owner types.Owner, rightSide bool)) *P2PGame_AddPlayerOnSide_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(types.Owner), args[2].(bool))
+	})
+	return _c
+}
+
+func (_c *P2PGame_AddPlayerOnSide_Call) Return(_a0 chan types.Update, _a1 string, _a2 error) *P2PGame_AddPlayerOnSide_Call {
 	_c.Call.Return(_a0, _a1, _a2)
 	return _c
 }
 
+// AddSpectator provides a mock function with given fields:
+func (_m *P2PGame) AddSpectator() (chan types.Update, error) {
+	ret := _m.Called()
+
+	var r0 chan types.Update
+	if rf, ok := ret.Get(0).(func() chan types.Update); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(chan types.Update)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// P2PGame_AddSpectator_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddSpectator'
+type P2PGame_AddSpectator_Call struct {
+	*mock.Call
+}
+
+// AddSpectator is a helper method to define mock.On call
+func (_e *P2PGame_Expecter) AddSpectator() *P2PGame_AddSpectator_Call {
+	return &P2PGame_AddSpectator_Call{Call: _e.mock.On("AddSpectator")}
+}
+
+func (_c *P2PGame_AddSpectator_Call) Run(run func()) *P2PGame_AddSpectator_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *P2PGame_AddSpectator_Call) Return(_a0 chan types.Update, _a1 error) *P2PGame_AddSpectator_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+// Chat provides a mock function with given fields: rightSide, text
+func (_m *P2PGame) Chat(rightSide bool, text string) error {
+	ret := _m.Called(rightSide, text)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(bool, string) error); ok {
+		r0 = rf(rightSide, text)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// P2PGame_Chat_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Chat'
+type P2PGame_Chat_Call struct {
+	*mock.Call
+}
+
+// Chat is a helper method to define mock.On call
+//   - rightSide bool
+//   - text string
+func (_e *P2PGame_Expecter) Chat(rightSide interface{}, text interface{}) *P2PGame_Chat_Call {
+	return &P2PGame_Chat_Call{Call: _e.mock.On("Chat", rightSide, text)}
+}
+
+func (_c *P2PGame_Chat_Call) Run(run func(rightSide bool, text string)) *P2PGame_Chat_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(bool), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *P2PGame_Chat_Call) Return(_a0 error) *P2PGame_Chat_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// CheckPassword provides a mock function with given fields: password
+func (_m *P2PGame) CheckPassword(password string) bool {
+	ret := _m.Called(password)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(password)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// P2PGame_CheckPassword_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CheckPassword'
+type P2PGame_CheckPassword_Call struct {
+	*mock.Call
+}
+
+// CheckPassword is a helper method to define mock.On call
+//   - password string
+func (_e *P2PGame_Expecter) CheckPassword(password interface{}) *P2PGame_CheckPassword_Call {
+	return &P2PGame_CheckPassword_Call{Call: _e.mock.On("CheckPassword", password)}
+}
+
+func (_c *P2PGame_CheckPassword_Call) Run(run func(password string)) *P2PGame_CheckPassword_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *P2PGame_CheckPassword_Call) Return(_a0 bool) *P2PGame_CheckPassword_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
 // Choice provides a mock function with given fields: choice, rightSide
-func (_m *P2PGame) Choice(choice types.Choice, rightSide bool) {
-	_m.Called(choice, rightSide)
+func (_m *P2PGame) Choice(choice types.Choice, rightSide bool) error {
+	ret := _m.Called(choice, rightSide)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(types.Choice, bool) error); ok {
+		r0 = rf(choice, rightSide)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
 
 // P2PGame_Choice_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Choice'
@@ -100,7 +292,100 @@ func (_c *P2PGame_Choice_Call) Run(run func(choice types.Choice, rightSide bool)
 	return _c
 }
 
-func (_c *P2PGame_Choice_Call) Return() *P2PGame_Choice_Call {
+func (_c *P2PGame_Choice_Call) Return(_a0 error) *P2PGame_Choice_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// DetachPlayer provides a mock function with given fields: ch
+func (_m *P2PGame) DetachPlayer(ch chan types.Update) {
+	_m.Called(ch)
+}
+
+// P2PGame_DetachPlayer_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DetachPlayer'
+type P2PGame_DetachPlayer_Call struct {
+	*mock.Call
+}
+
+// DetachPlayer is a helper method to define mock.On call
+//   - ch chan types.Update
+func (_e *P2PGame_Expecter) DetachPlayer(ch interface{}) *P2PGame_DetachPlayer_Call {
+	return &P2PGame_DetachPlayer_Call{Call: _e.mock.On("DetachPlayer", ch)}
+}
+
+func (_c *P2PGame_DetachPlayer_Call) Run(run func(ch chan types.Update)) *P2PGame_DetachPlayer_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(chan types.Update))
+	})
+	return _c
+}
+
+func (_c *P2PGame_DetachPlayer_Call) Return() *P2PGame_DetachPlayer_Call {
+	_c.Call.Return()
+	return _c
+}
+
+// Emote provides a mock function with given fields: rightSide, emote
+func (_m *P2PGame) Emote(rightSide bool, emote types.Emote) error {
+	ret := _m.Called(rightSide, emote)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(bool, types.Emote) error); ok {
+		r0 = rf(rightSide, emote)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// P2PGame_Emote_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Emote'
+type P2PGame_Emote_Call struct {
+	*mock.Call
+}
+
+// Emote is a helper method to define mock.On call
+//   - rightSide bool
+//   - emote types.Emote
+func (_e *P2PGame_Expecter) Emote(rightSide interface{}, emote interface{}) *P2PGame_Emote_Call {
+	return &P2PGame_Emote_Call{Call: _e.mock.On("Emote", rightSide, emote)}
+}
+
+func (_c *P2PGame_Emote_Call) Run(run func(rightSide bool, emote types.Emote)) *P2PGame_Emote_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(bool), args[1].(types.Emote))
+	})
+	return _c
+}
+
+func (_c *P2PGame_Emote_Call) Return(_a0 error) *P2PGame_Emote_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// End provides a mock function with given fields:
+func (_m *P2PGame) End() {
+	_m.Called()
+}
+
+// P2PGame_End_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'End'
+type P2PGame_End_Call struct {
+	*mock.Call
+}
+
+// End is a helper method to define mock.On call
+func (_e *P2PGame_Expecter) End() *P2PGame_End_Call {
+	return &P2PGame_End_Call{Call: _e.mock.On("End")}
+}
+
+func (_c *P2PGame_End_Call) Run(run func()) *P2PGame_End_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *P2PGame_End_Call) Return() *P2PGame_End_Call {
 	_c.Call.Return()
 	return _c
 }
@@ -141,6 +426,70 @@ func (_c *P2PGame_GetID_Call) Return(_a0 types.GameID) *P2PGame_GetID_Call {
 	return _c
 }
 
+// Heartbeat provides a mock function with given fields: rightSide
+func (_m *P2PGame) Heartbeat(rightSide bool) {
+	_m.Called(rightSide)
+}
+
+// P2PGame_Heartbeat_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Heartbeat'
+type P2PGame_Heartbeat_Call struct {
+	*mock.Call
+}
+
+// Heartbeat is a helper method to define mock.On call
+//   - rightSide bool
+func (_e *P2PGame_Expecter) Heartbeat(rightSide interface{}) *P2PGame_Heartbeat_Call {
+	return &P2PGame_Heartbeat_Call{Call: _e.mock.On("Heartbeat", rightSide)}
+}
+
+func (_c *P2PGame_Heartbeat_Call) Run(run func(rightSide bool)) *P2PGame_Heartbeat_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(bool))
+	})
+	return _c
+}
+
+func (_c *P2PGame_Heartbeat_Call) Return() *P2PGame_Heartbeat_Call {
+	_c.Call.Return()
+	return _c
+}
+
+// Host provides a mock function with given fields:
+func (_m *P2PGame) Host() types.Owner {
+	ret := _m.Called()
+
+	var r0 types.Owner
+	if rf, ok := ret.Get(0).(func() types.Owner); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(types.Owner)
+	}
+
+	return r0
+}
+
+// P2PGame_Host_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Host'
+type P2PGame_Host_Call struct {
+	*mock.Call
+}
+
+// Host is a helper method to define mock.On call
+func (_e *P2PGame_Expecter) Host() *P2PGame_Host_Call {
+	return &P2PGame_Host_Call{Call: _e.mock.On("Host")}
+}
+
+func (_c *P2PGame_Host_Call) Run(run func()) *P2PGame_Host_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *P2PGame_Host_Call) Return(_a0 types.Owner) *P2PGame_Host_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
 // IsFull provides a mock function with given fields: ctx
 func (_m *P2PGame) IsFull(ctx context.Context) bool {
 	ret := _m.Called(ctx)
@@ -178,6 +527,81 @@ func (_c *P2PGame_IsFull_Call) Return(_a0 bool) *P2PGame_IsFull_Call {
 	return _c
 }
 
+// Kick provides a mock function with given fields: rightSide, ban
+func (_m *P2PGame) Kick(rightSide bool, ban bool) error {
+	ret := _m.Called(rightSide, ban)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(bool, bool) error); ok {
+		r0 = rf(rightSide, ban)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// P2PGame_Kick_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Kick'
+type P2PGame_Kick_Call struct {
+	*mock.Call
+}
+
+// Kick is a helper method to define mock.On call
+//   - rightSide bool
+//   - ban bool
+func (_e *P2PGame_Expecter) Kick(rightSide interface{}, ban interface{}) *P2PGame_Kick_Call {
+	return &P2PGame_Kick_Call{Call: _e.mock.On("Kick", rightSide, ban)}
+}
+
+func (_c *P2PGame_Kick_Call) Run(run func(rightSide bool, ban bool)) *P2PGame_Kick_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(bool), args[1].(bool))
+	})
+	return _c
+}
+
+func (_c *P2PGame_Kick_Call) Return(_a0 error) *P2PGame_Kick_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// Notice provides a mock function with given fields: text
+func (_m *P2PGame) Notice(text string) error {
+	ret := _m.Called(text)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(text)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// P2PGame_Notice_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Notice'
+type P2PGame_Notice_Call struct {
+	*mock.Call
+}
+
+// Notice is a helper method to define mock.On call
+//   - text string
+func (_e *P2PGame_Expecter) Notice(text interface{}) *P2PGame_Notice_Call {
+	return &P2PGame_Notice_Call{Call: _e.mock.On("Notice", text)}
+}
+
+func (_c *P2PGame_Notice_Call) Run(run func(text string)) *P2PGame_Notice_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *P2PGame_Notice_Call) Return(_a0 error) *P2PGame_Notice_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
 // RemovePlayer provides a mock function with given fields: rightSide
 func (_m *P2PGame) RemovePlayer(rightSide bool) {
 	_m.Called(rightSide)
@@ -206,6 +630,225 @@ func (_c *P2PGame_RemovePlayer_Call) Return() *P2PGame_RemovePlayer_Call {
 	return _c
 }
 
+// RemoveSpectator provides a mock function with given fields: ch
+func (_m *P2PGame) RemoveSpectator(ch chan types.Update) {
+	_m.Called(ch)
+}
+
+// P2PGame_RemoveSpectator_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveSpectator'
+type P2PGame_RemoveSpectator_Call struct {
+	*mock.Call
+}
+
+// RemoveSpectator is a helper method to define mock.On call
+//   - ch chan types.Update
+func (_e *P2PGame_Expecter) RemoveSpectator(ch interface{}) *P2PGame_RemoveSpectator_Call {
+	return &P2PGame_RemoveSpectator_Call{Call: _e.mock.On("RemoveSpectator", ch)}
+}
+
+func (_c *P2PGame_RemoveSpectator_Call) Run(run func(ch chan types.Update)) *P2PGame_RemoveSpectator_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(chan types.Update))
+	})
+	return _c
+}
+
+func (_c *P2PGame_RemoveSpectator_Call) Return() *P2PGame_RemoveSpectator_Call {
+	_c.Call.Return()
+	return _c
+}
+
+// ResumePlayer provides a mock function with given fields: token
+func (_m *P2PGame) ResumePlayer(token string) (bool, chan types.Update, error) {
+	ret := _m.Called(token)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(token)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 chan types.Update
+	if rf, ok := ret.Get(1).(func(string) chan types.Update); ok {
+		r1 = rf(token)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(chan types.Update)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string) error); ok {
+		r2 = rf(token)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// P2PGame_ResumePlayer_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResumePlayer'
+type P2PGame_ResumePlayer_Call struct {
+	*mock.Call
+}
+
+// ResumePlayer is a helper method to define mock.On call
+//   - token string
+func (_e *P2PGame_Expecter) ResumePlayer(token interface{}) *P2PGame_ResumePlayer_Call {
+	return &P2PGame_ResumePlayer_Call{Call: _e.mock.On("ResumePlayer", token)}
+}
+
+func (_c *P2PGame_ResumePlayer_Call) Run(run func(token string)) *P2PGame_ResumePlayer_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *P2PGame_ResumePlayer_Call) Return(_a0 bool, _a1 chan types.Update, _a2 error) *P2PGame_ResumePlayer_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+// Score provides a mock function with given fields:
+func (_m *P2PGame) Score() types.MatchScore {
+	ret := _m.Called()
+
+	var r0 types.MatchScore
+	if rf, ok := ret.Get(0).(func() types.MatchScore); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(types.MatchScore)
+	}
+
+	return r0
+}
+
+// P2PGame_Score_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Score'
+type P2PGame_Score_Call struct {
+	*mock.Call
+}
+
+// Score is a helper method to define mock.On call
+func (_e *P2PGame_Expecter) Score() *P2PGame_Score_Call {
+	return &P2PGame_Score_Call{Call: _e.mock.On("Score")}
+}
+
+func (_c *P2PGame_Score_Call) Run(run func()) *P2PGame_Score_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *P2PGame_Score_Call) Return(_a0 types.MatchScore) *P2PGame_Score_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// Status provides a mock function with given fields:
+func (_m *P2PGame) Status() types.GameStatus {
+	ret := _m.Called()
+
+	var r0 types.GameStatus
+	if rf, ok := ret.Get(0).(func() types.GameStatus); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(types.GameStatus)
+	}
+
+	return r0
+}
+
+// P2PGame_Status_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Status'
+type P2PGame_Status_Call struct {
+	*mock.Call
+}
+
+// Status is a helper method to define mock.On call
+func (_e *P2PGame_Expecter) Status() *P2PGame_Status_Call {
+	return &P2PGame_Status_Call{Call: _e.mock.On("Status")}
+}
+
+func (_c *P2PGame_Status_Call) Run(run func()) *P2PGame_Status_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *P2PGame_Status_Call) Return(_a0 types.GameStatus) *P2PGame_Status_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// Tap provides a mock function with given fields:
+func (_m *P2PGame) Tap() chan types.Update {
+	ret := _m.Called()
+
+	var r0 chan types.Update
+	if rf, ok := ret.Get(0).(func() chan types.Update); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(chan types.Update)
+		}
+	}
+
+	return r0
+}
+
+// P2PGame_Tap_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Tap'
+type P2PGame_Tap_Call struct {
+	*mock.Call
+}
+
+// Tap is a helper method to define mock.On call
+func (_e *P2PGame_Expecter) Tap() *P2PGame_Tap_Call {
+	return &P2PGame_Tap_Call{Call: _e.mock.On("Tap")}
+}
+
+func (_c *P2PGame_Tap_Call) Run(run func()) *P2PGame_Tap_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *P2PGame_Tap_Call) Return(_a0 chan types.Update) *P2PGame_Tap_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// Untap provides a mock function with given fields: ch
+func (_m *P2PGame) Untap(ch chan types.Update) {
+	_m.Called(ch)
+}
+
+// P2PGame_Untap_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Untap'
+type P2PGame_Untap_Call struct {
+	*mock.Call
+}
+
+// Untap is a helper method to define mock.On call
+//   - ch chan types.Update
+func (_e *P2PGame_Expecter) Untap(ch interface{}) *P2PGame_Untap_Call {
+	return &P2PGame_Untap_Call{Call: _e.mock.On("Untap", ch)}
+}
+
+func (_c *P2PGame_Untap_Call) Run(run func(ch chan types.Update)) *P2PGame_Untap_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(chan types.Update))
+	})
+	return _c
+}
+
+func (_c *P2PGame_Untap_Call) Return() *P2PGame_Untap_Call {
+	_c.Call.Return()
+	return _c
+}
+
 type mockConstructorTestingTNewP2PGame interface {
 	mock.TestingT
 	Cleanup(func())
@@ -24,13 +24,13 @@ func (_m *P2PGameFactory) EXPECT() *P2PGameFactory_Expecter {
 	return &P2PGameFactory_Expecter{mock: &_m.Mock}
 }
 
-// CreateGame provides a mock function with given fields: ctx
-func (_m *P2PGameFactory) CreateGame(ctx context.Context) (pkg.P2PGame, error) {
-	ret := _m.Called(ctx)
+// CreateGame provides a mock function with given fields: ctx, settings, access
+func (_m *P2PGameFactory) CreateGame(ctx context.Context, settings types.GameSettings, access types.GameAccess) (pkg.P2PGame, error) {
+	ret := _m.Called(ctx, settings, access)
 
 	var r0 pkg.P2PGame
-	if rf, ok := ret.Get(0).(func(context.Context) pkg.P2PGame); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, types.GameSettings, types.GameAccess) pkg.P2PGame); ok {
+		r0 = rf(ctx, settings, access)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(pkg.P2PGame)
@@ -38,8 +38,8 @@ func (_m *P2PGameFactory) CreateGame(ctx context.Context) (pkg.P2PGame, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	if rf, ok := ret.Get(1).(func(context.Context, types.GameSettings, types.GameAccess) error); ok {
+		r1 = rf(ctx, settings, access)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -54,13 +54,15 @@ type P2PGameFactory_CreateGame_Call struct {
 
 // CreateGame is a helper method to define mock.On call
 //   - ctx context.Context
-func (_e *P2PGameFactory_Expecter) CreateGame(ctx interface{}) *P2PGameFactory_CreateGame_Call {
-	return &P2PGameFactory_CreateGame_Call{Call: _e.mock.On("CreateGame", ctx)}
+//   - settings types.GameSettings
+//   - access types.GameAccess
+func (_e *P2PGameFactory_Expecter) CreateGame(ctx interface{}, settings interface{}, access interface{}) *P2PGameFactory_CreateGame_Call {
+	return &P2PGameFactory_CreateGame_Call{Call: _e.mock.On("CreateGame", ctx, settings, access)}
 }
 
-func (_c *P2PGameFactory_CreateGame_Call) Run(run func(ctx context.Context)) *P2PGameFactory_CreateGame_Call {
+func (_c *P2PGameFactory_CreateGame_Call) Run(run func(ctx context.Context, settings types.GameSettings, access types.GameAccess)) *P2PGameFactory_CreateGame_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context))
+		run(args[0].(context.Context), args[1].(types.GameSettings), args[2].(types.GameAccess))
 	})
 	return _c
 }
@@ -70,6 +72,72 @@ func (_c *P2PGameFactory_CreateGame_Call) Return(_a0 pkg.P2PGame, _a1 error) *P2
 	return _c
 }
 
+// Drain provides a mock function with given fields: ctx
+func (_m *P2PGameFactory) Drain(ctx context.Context) {
+	_m.Called(ctx)
+}
+
+// P2PGameFactory_Drain_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Drain'
+type P2PGameFactory_Drain_Call struct {
+	*mock.Call
+}
+
+// Drain is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *P2PGameFactory_Expecter) Drain(ctx interface{}) *P2PGameFactory_Drain_Call {
+	return &P2PGameFactory_Drain_Call{Call: _e.mock.On("Drain", ctx)}
+}
+
+func (_c *P2PGameFactory_Drain_Call) Run(run func(ctx context.Context)) *P2PGameFactory_Drain_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *P2PGameFactory_Drain_Call) Return() *P2PGameFactory_Drain_Call {
+	_c.Call.Return()
+	return _c
+}
+
+// Games provides a mock function with given fields:
+func (_m *P2PGameFactory) Games() []types.GameStatus {
+	ret := _m.Called()
+
+	var r0 []types.GameStatus
+	if rf, ok := ret.Get(0).(func() []types.GameStatus); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.GameStatus)
+		}
+	}
+
+	return r0
+}
+
+// P2PGameFactory_Games_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Games'
+type P2PGameFactory_Games_Call struct {
+	*mock.Call
+}
+
+// Games is a helper method to define mock.On call
+func (_e *P2PGameFactory_Expecter) Games() *P2PGameFactory_Games_Call {
+	return &P2PGameFactory_Games_Call{Call: _e.mock.On("Games")}
+}
+
+func (_c *P2PGameFactory_Games_Call) Run(run func()) *P2PGameFactory_Games_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *P2PGameFactory_Games_Call) Return(_a0 []types.GameStatus) *P2PGameFactory_Games_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
 // GetGame provides a mock function with given fields: id
 func (_m *P2PGameFactory) GetGame(id types.GameID) (pkg.P2PGame, bool) {
 	ret := _m.Called(id)
@@ -116,6 +184,132 @@ func (_c *P2PGameFactory_GetGame_Call) Return(_a0 pkg.P2PGame, _a1 bool) *P2PGam
 	return _c
 }
 
+// Lobby provides a mock function with given fields:
+func (_m *P2PGameFactory) Lobby() []types.GameInfo {
+	ret := _m.Called()
+
+	var r0 []types.GameInfo
+	if rf, ok := ret.Get(0).(func() []types.GameInfo); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.GameInfo)
+		}
+	}
+
+	return r0
+}
+
+// P2PGameFactory_Lobby_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Lobby'
+type P2PGameFactory_Lobby_Call struct {
+	*mock.Call
+}
+
+// Lobby is a helper method to define mock.On call
+func (_e *P2PGameFactory_Expecter) Lobby() *P2PGameFactory_Lobby_Call {
+	return &P2PGameFactory_Lobby_Call{Call: _e.mock.On("Lobby")}
+}
+
+func (_c *P2PGameFactory_Lobby_Call) Run(run func()) *P2PGameFactory_Lobby_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *P2PGameFactory_Lobby_Call) Return(_a0 []types.GameInfo) *P2PGameFactory_Lobby_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// Notice provides a mock function with given fields: text
+func (_m *P2PGameFactory) Notice(text string) (int, error) {
+	ret := _m.Called(text)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(string) int); ok {
+		r0 = rf(text)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(text)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// P2PGameFactory_Notice_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Notice'
+type P2PGameFactory_Notice_Call struct {
+	*mock.Call
+}
+
+// Notice is a helper method to define mock.On call
+//   - text string
+func (_e *P2PGameFactory_Expecter) Notice(text interface{}) *P2PGameFactory_Notice_Call {
+	return &P2PGameFactory_Notice_Call{Call: _e.mock.On("Notice", text)}
+}
+
+func (_c *P2PGameFactory_Notice_Call) Run(run func(text string)) *P2PGameFactory_Notice_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *P2PGameFactory_Notice_Call) Return(_a0 int, _a1 error) *P2PGameFactory_Notice_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+// Restore provides a mock function with given fields: ctx
+func (_m *P2PGameFactory) Restore(ctx context.Context) (int, error) {
+	ret := _m.Called(ctx)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// P2PGameFactory_Restore_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Restore'
+type P2PGameFactory_Restore_Call struct {
+	*mock.Call
+}
+
+// Restore is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *P2PGameFactory_Expecter) Restore(ctx interface{}) *P2PGameFactory_Restore_Call {
+	return &P2PGameFactory_Restore_Call{Call: _e.mock.On("Restore", ctx)}
+}
+
+func (_c *P2PGameFactory_Restore_Call) Run(run func(ctx context.Context)) *P2PGameFactory_Restore_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *P2PGameFactory_Restore_Call) Return(_a0 int, _a1 error) *P2PGameFactory_Restore_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
 // StopGames provides a mock function with given fields: ctx
 func (_m *P2PGameFactory) StopGames(ctx context.Context) {
 	_m.Called(ctx)
@@ -144,6 +338,45 @@ func (_c *P2PGameFactory_StopGames_Call) Return() *P2PGameFactory_StopGames_Call
 	return _c
 }
 
+// WatchLobby provides a mock function with given fields: ctx
+func (_m *P2PGameFactory) WatchLobby(ctx context.Context) <-chan struct{} {
+	ret := _m.Called(ctx)
+
+	var r0 <-chan struct{}
+	if rf, ok := ret.Get(0).(func(context.Context) <-chan struct{}); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan struct{})
+		}
+	}
+
+	return r0
+}
+
+// P2PGameFactory_WatchLobby_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WatchLobby'
+type P2PGameFactory_WatchLobby_Call struct {
+	*mock.Call
+}
+
+// WatchLobby is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *P2PGameFactory_Expecter) WatchLobby(ctx interface{}) *P2PGameFactory_WatchLobby_Call {
+	return &P2PGameFactory_WatchLobby_Call{Call: _e.mock.On("WatchLobby", ctx)}
+}
+
+func (_c *P2PGameFactory_WatchLobby_Call) Run(run func(ctx context.Context)) *P2PGameFactory_WatchLobby_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *P2PGameFactory_WatchLobby_Call) Return(_a0 <-chan struct{}) *P2PGameFactory_WatchLobby_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
 type mockConstructorTestingTNewP2PGameFactory interface {
 	mock.TestingT
 	Cleanup(func())
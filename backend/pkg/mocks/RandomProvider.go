@@ -65,6 +65,42 @@ func (_c *RandomProvider_Rand_Call) Return(_a0 int, _a1 error) *RandomProvider_R
 	return _c
 }
 
+// Source provides a mock function with given fields:
+func (_m *RandomProvider) Source() string {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// RandomProvider_Source_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Source'
+type RandomProvider_Source_Call struct {
+	*mock.Call
+}
+
+// Source is a helper method to define mock.On call
+func (_e *RandomProvider_Expecter) Source() *RandomProvider_Source_Call {
+	return &RandomProvider_Source_Call{Call: _e.mock.On("Source")}
+}
+
+func (_c *RandomProvider_Source_Call) Run(run func()) *RandomProvider_Source_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *RandomProvider_Source_Call) Return(_a0 string) *RandomProvider_Source_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
 type mockConstructorTestingTNewRandomProvider interface {
 	mock.TestingT
 	Cleanup(func())
@@ -0,0 +1,169 @@
+// Code generated by mockery v2.15.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	types "github.com/complynx/rpssl4bu/backend/pkg/types"
+)
+
+// RatingStorage is an autogenerated mock type for the RatingStorage type
+type RatingStorage struct {
+	mock.Mock
+}
+
+type RatingStorage_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *RatingStorage) EXPECT() *RatingStorage_Expecter {
+	return &RatingStorage_Expecter{mock: &_m.Mock}
+}
+
+// GetRating provides a mock function with given fields: ctx, owner
+func (_m *RatingStorage) GetRating(ctx context.Context, owner types.Owner) (types.Rating, error) {
+	ret := _m.Called(ctx, owner)
+
+	var r0 types.Rating
+	if rf, ok := ret.Get(0).(func(context.Context, types.Owner) types.Rating); ok {
+		r0 = rf(ctx, owner)
+	} else {
+		r0 = ret.Get(0).(types.Rating)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, types.Owner) error); ok {
+		r1 = rf(ctx, owner)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RatingStorage_GetRating_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetRating'
+type RatingStorage_GetRating_Call struct {
+	*mock.Call
+}
+
+// GetRating is a helper method to define mock.On call
+//   - ctx context.Context
+//   - owner types.Owner
+func (_e *RatingStorage_Expecter) GetRating(ctx interface{}, owner interface{}) *RatingStorage_GetRating_Call {
+	return &RatingStorage_GetRating_Call{Call: _e.mock.On("GetRating", ctx, owner)}
+}
+
+func (_c *RatingStorage_GetRating_Call) Run(run func(ctx context.Context, owner types.Owner)) *RatingStorage_GetRating_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(types.Owner))
+	})
+	return _c
+}
+
+func (_c *RatingStorage_GetRating_Call) Return(_a0 types.Rating, _a1 error) *RatingStorage_GetRating_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+// Leaderboard provides a mock function with given fields: ctx, limit
+func (_m *RatingStorage) Leaderboard(ctx context.Context, limit int) ([]types.LeaderboardEntry, error) {
+	ret := _m.Called(ctx, limit)
+
+	var r0 []types.LeaderboardEntry
+	if rf, ok := ret.Get(0).(func(context.Context, int) []types.LeaderboardEntry); ok {
+		r0 = rf(ctx, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.LeaderboardEntry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RatingStorage_Leaderboard_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Leaderboard'
+type RatingStorage_Leaderboard_Call struct {
+	*mock.Call
+}
+
+// Leaderboard is a helper method to define mock.On call
+//   - ctx context.Context
+//   - limit int
+func (_e *RatingStorage_Expecter) Leaderboard(ctx interface{}, limit interface{}) *RatingStorage_Leaderboard_Call {
+	return &RatingStorage_Leaderboard_Call{Call: _e.mock.On("Leaderboard", ctx, limit)}
+}
+
+func (_c *RatingStorage_Leaderboard_Call) Run(run func(ctx context.Context, limit int)) *RatingStorage_Leaderboard_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *RatingStorage_Leaderboard_Call) Return(_a0 []types.LeaderboardEntry, _a1 error) *RatingStorage_Leaderboard_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+// RateMatch provides a mock function with given fields: ctx, match
+func (_m *RatingStorage) RateMatch(ctx context.Context, match types.MatchResult) error {
+	ret := _m.Called(ctx, match)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, types.MatchResult) error); ok {
+		r0 = rf(ctx, match)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RatingStorage_RateMatch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RateMatch'
+type RatingStorage_RateMatch_Call struct {
+	*mock.Call
+}
+
+// RateMatch is a helper method to define mock.On call
+//   - ctx context.Context
+//   - match types.MatchResult
+func (_e *RatingStorage_Expecter) RateMatch(ctx interface{}, match interface{}) *RatingStorage_RateMatch_Call {
+	return &RatingStorage_RateMatch_Call{Call: _e.mock.On("RateMatch", ctx, match)}
+}
+
+func (_c *RatingStorage_RateMatch_Call) Run(run func(ctx context.Context, match types.MatchResult)) *RatingStorage_RateMatch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(types.MatchResult))
+	})
+	return _c
+}
+
+func (_c *RatingStorage_RateMatch_Call) Return(_a0 error) *RatingStorage_RateMatch_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+type mockConstructorTestingTNewRatingStorage interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewRatingStorage creates a new instance of RatingStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewRatingStorage(t mockConstructorTestingTNewRatingStorage) *RatingStorage {
+	mock := &RatingStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
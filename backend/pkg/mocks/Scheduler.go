@@ -0,0 +1,65 @@
+// Code generated by mockery v2.15.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Scheduler is an autogenerated mock type for the Scheduler type
+type Scheduler struct {
+	mock.Mock
+}
+
+type Scheduler_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Scheduler) EXPECT() *Scheduler_Expecter {
+	return &Scheduler_Expecter{mock: &_m.Mock}
+}
+
+// Stop provides a mock function with given fields: ctx
+func (_m *Scheduler) Stop(ctx context.Context) {
+	_m.Called(ctx)
+}
+
+// Scheduler_Stop_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Stop'
+type Scheduler_Stop_Call struct {
+	*mock.Call
+}
+
+// Stop is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Scheduler_Expecter) Stop(ctx interface{}) *Scheduler_Stop_Call {
+	return &Scheduler_Stop_Call{Call: _e.mock.On("Stop", ctx)}
+}
+
+func (_c *Scheduler_Stop_Call) Run(run func(ctx context.Context)) *Scheduler_Stop_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Scheduler_Stop_Call) Return() *Scheduler_Stop_Call {
+	_c.Call.Return()
+	return _c
+}
+
+type mockConstructorTestingTNewScheduler interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewScheduler creates a new instance of Scheduler. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewScheduler(t mockConstructorTestingTNewScheduler) *Scheduler {
+	mock := &Scheduler{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
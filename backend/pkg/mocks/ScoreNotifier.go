@@ -0,0 +1,87 @@
+// Code generated by mockery v2.15.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	types "github.com/complynx/rpssl4bu/backend/pkg/types"
+)
+
+// ScoreNotifier is an autogenerated mock type for the ScoreNotifier type
+type ScoreNotifier struct {
+	mock.Mock
+}
+
+type ScoreNotifier_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *ScoreNotifier) EXPECT() *ScoreNotifier_Expecter {
+	return &ScoreNotifier_Expecter{mock: &_m.Mock}
+}
+
+// Subscribe provides a mock function with given fields: ctx, owner, lastID
+func (_m *ScoreNotifier) Subscribe(ctx context.Context, owner types.Owner, lastID uint64) (<-chan types.ScoreEvent, bool) {
+	ret := _m.Called(ctx, owner, lastID)
+
+	var r0 <-chan types.ScoreEvent
+	if rf, ok := ret.Get(0).(func(context.Context, types.Owner, uint64) <-chan types.ScoreEvent); ok {
+		r0 = rf(ctx, owner, lastID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan types.ScoreEvent)
+		}
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func(context.Context, types.Owner, uint64) bool); ok {
+		r1 = rf(ctx, owner, lastID)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// ScoreNotifier_Subscribe_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Subscribe'
+type ScoreNotifier_Subscribe_Call struct {
+	*mock.Call
+}
+
+// Subscribe is a helper method to define mock.On call
+//   - ctx context.Context
+//   - owner types.Owner
+//   - lastID uint64
+func (_e *ScoreNotifier_Expecter) Subscribe(ctx interface{}, owner interface{}, lastID interface{}) *ScoreNotifier_Subscribe_Call {
+	return &ScoreNotifier_Subscribe_Call{Call: _e.mock.On("Subscribe", ctx, owner, lastID)}
+}
+
+func (_c *ScoreNotifier_Subscribe_Call) Run(run func(ctx context.Context, owner types.Owner, lastID uint64)) *ScoreNotifier_Subscribe_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(types.Owner), args[2].(uint64))
+	})
+	return _c
+}
+
+func (_c *ScoreNotifier_Subscribe_Call) Return(events <-chan types.ScoreEvent, missed bool) *ScoreNotifier_Subscribe_Call {
+	_c.Call.Return(events, missed)
+	return _c
+}
+
+type mockConstructorTestingTNewScoreNotifier interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewScoreNotifier creates a new instance of ScoreNotifier. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewScoreNotifier(t mockConstructorTestingTNewScoreNotifier) *ScoreNotifier {
+	mock := &ScoreNotifier{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
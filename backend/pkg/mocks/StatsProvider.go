@@ -0,0 +1,129 @@
+// Code generated by mockery v2.15.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	types "github.com/complynx/rpssl4bu/backend/pkg/types"
+)
+
+// StatsProvider is an autogenerated mock type for the StatsProvider type
+type StatsProvider struct {
+	mock.Mock
+}
+
+type StatsProvider_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *StatsProvider) EXPECT() *StatsProvider_Expecter {
+	return &StatsProvider_Expecter{mock: &_m.Mock}
+}
+
+// GetPlayerStats provides a mock function with given fields: ctx, name
+func (_m *StatsProvider) GetPlayerStats(ctx context.Context, name string) (types.Stats, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 types.Stats
+	if rf, ok := ret.Get(0).(func(context.Context, string) types.Stats); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Get(0).(types.Stats)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StatsProvider_GetPlayerStats_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPlayerStats'
+type StatsProvider_GetPlayerStats_Call struct {
+	*mock.Call
+}
+
+// GetPlayerStats is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+func (_e *StatsProvider_Expecter) GetPlayerStats(ctx interface{}, name interface{}) *StatsProvider_GetPlayerStats_Call {
+	return &StatsProvider_GetPlayerStats_Call{Call: _e.mock.On("GetPlayerStats", ctx, name)}
+}
+
+func (_c *StatsProvider_GetPlayerStats_Call) Run(run func(ctx context.Context, name string)) *StatsProvider_GetPlayerStats_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *StatsProvider_GetPlayerStats_Call) Return(_a0 types.Stats, _a1 error) *StatsProvider_GetPlayerStats_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+// GetStats provides a mock function with given fields: ctx, owner
+func (_m *StatsProvider) GetStats(ctx context.Context, owner types.Owner) (types.Stats, error) {
+	ret := _m.Called(ctx, owner)
+
+	var r0 types.Stats
+	if rf, ok := ret.Get(0).(func(context.Context, types.Owner) types.Stats); ok {
+		r0 = rf(ctx, owner)
+	} else {
+		r0 = ret.Get(0).(types.Stats)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, types.Owner) error); ok {
+		r1 = rf(ctx, owner)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StatsProvider_GetStats_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetStats'
+type StatsProvider_GetStats_Call struct {
+	*mock.Call
+}
+
+// GetStats is a helper method to define mock.On call
+//   - ctx context.Context
+//   - owner types.Owner
+func (_e *StatsProvider_Expecter) GetStats(ctx interface{}, owner interface{}) *StatsProvider_GetStats_Call {
+	return &StatsProvider_GetStats_Call{Call: _e.mock.On("GetStats", ctx, owner)}
+}
+
+func (_c *StatsProvider_GetStats_Call) Run(run func(ctx context.Context, owner types.Owner)) *StatsProvider_GetStats_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(types.Owner))
+	})
+	return _c
+}
+
+func (_c *StatsProvider_GetStats_Call) Return(_a0 types.Stats, _a1 error) *StatsProvider_GetStats_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+type mockConstructorTestingTNewStatsProvider interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewStatsProvider creates a new instance of StatsProvider. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewStatsProvider(t mockConstructorTestingTNewStatsProvider) *StatsProvider {
+	mock := &StatsProvider{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
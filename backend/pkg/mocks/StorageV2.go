@@ -0,0 +1,419 @@
+// Code generated by mockery v2.15.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	types "github.com/complynx/rpssl4bu/backend/pkg/types"
+)
+
+// StorageV2 is an autogenerated mock type for the StorageV2 type
+type StorageV2 struct {
+	mock.Mock
+}
+
+type StorageV2_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *StorageV2) EXPECT() *StorageV2_Expecter {
+	return &StorageV2_Expecter{mock: &_m.Mock}
+}
+
+// AddRecord provides a mock function with given fields: ctx, record
+func (_m *StorageV2) AddRecord(ctx context.Context, record types.GameRecord) error {
+	ret := _m.Called(ctx, record)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, types.GameRecord) error); ok {
+		r0 = rf(ctx, record)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StorageV2_AddRecord_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddRecord'
+type StorageV2_AddRecord_Call struct {
+	*mock.Call
+}
+
+// AddRecord is a helper method to define mock.On call
+//   - ctx context.Context
+//   - record types.GameRecord
+func (_e *StorageV2_Expecter) AddRecord(ctx interface{}, record interface{}) *StorageV2_AddRecord_Call {
+	return &StorageV2_AddRecord_Call{Call: _e.mock.On("AddRecord", ctx, record)}
+}
+
+func (_c *StorageV2_AddRecord_Call) Run(run func(ctx context.Context, record types.GameRecord)) *StorageV2_AddRecord_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(types.GameRecord))
+	})
+	return _c
+}
+
+func (_c *StorageV2_AddRecord_Call) Return(_a0 error) *StorageV2_AddRecord_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// AuditLog provides a mock function with given fields: ctx, owner, limit
+func (_m *StorageV2) AuditLog(ctx context.Context, owner types.Owner, limit int) ([]types.AuditEntry, error) {
+	ret := _m.Called(ctx, owner, limit)
+
+	var r0 []types.AuditEntry
+	if rf, ok := ret.Get(0).(func(context.Context, types.Owner, int) []types.AuditEntry); ok {
+		r0 = rf(ctx, owner, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.AuditEntry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, types.Owner, int) error); ok {
+		r1 = rf(ctx, owner, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StorageV2_AuditLog_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AuditLog'
+type StorageV2_AuditLog_Call struct {
+	*mock.Call
+}
+
+// AuditLog is a helper method to define mock.On call
+//   - ctx context.Context
+//   - owner types.Owner
+//   - limit int
+func (_e *StorageV2_Expecter) AuditLog(ctx interface{}, owner interface{}, limit interface{}) *StorageV2_AuditLog_Call {
+	return &StorageV2_AuditLog_Call{Call: _e.mock.On("AuditLog", ctx, owner, limit)}
+}
+
+func (_c *StorageV2_AuditLog_Call) Run(run func(ctx context.Context, owner types.Owner, limit int)) *StorageV2_AuditLog_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(types.Owner), args[2].(int))
+	})
+	return _c
+}
+
+func (_c *StorageV2_AuditLog_Call) Return(_a0 []types.AuditEntry, _a1 error) *StorageV2_AuditLog_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+// ClearRecords provides a mock function with given fields: ctx, owner
+func (_m *StorageV2) ClearRecords(ctx context.Context, owner types.Owner) error {
+	ret := _m.Called(ctx, owner)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, types.Owner) error); ok {
+		r0 = rf(ctx, owner)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StorageV2_ClearRecords_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ClearRecords'
+type StorageV2_ClearRecords_Call struct {
+	*mock.Call
+}
+
+// ClearRecords is a helper method to define mock.On call
+//   - ctx context.Context
+//   - owner types.Owner
+func (_e *StorageV2_Expecter) ClearRecords(ctx interface{}, owner interface{}) *StorageV2_ClearRecords_Call {
+	return &StorageV2_ClearRecords_Call{Call: _e.mock.On("ClearRecords", ctx, owner)}
+}
+
+func (_c *StorageV2_ClearRecords_Call) Run(run func(ctx context.Context, owner types.Owner)) *StorageV2_ClearRecords_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(types.Owner))
+	})
+	return _c
+}
+
+func (_c *StorageV2_ClearRecords_Call) Return(_a0 error) *StorageV2_ClearRecords_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// ClearScores provides a mock function with given fields:
+func (_m *StorageV2) ClearScores() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StorageV2_ClearScores_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ClearScores'
+type StorageV2_ClearScores_Call struct {
+	*mock.Call
+}
+
+// ClearScores is a helper method to define mock.On call
+func (_e *StorageV2_Expecter) ClearScores() *StorageV2_ClearScores_Call {
+	return &StorageV2_ClearScores_Call{Call: _e.mock.On("ClearScores")}
+}
+
+func (_c *StorageV2_ClearScores_Call) Run(run func()) *StorageV2_ClearScores_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *StorageV2_ClearScores_Call) Return(_a0 error) *StorageV2_ClearScores_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// GetLastRecords provides a mock function with given fields: ctx, owner, limit
+func (_m *StorageV2) GetLastRecords(ctx context.Context, owner types.Owner, limit int) ([]types.GameRecord, error) {
+	ret := _m.Called(ctx, owner, limit)
+
+	var r0 []types.GameRecord
+	if rf, ok := ret.Get(0).(func(context.Context, types.Owner, int) []types.GameRecord); ok {
+		r0 = rf(ctx, owner, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.GameRecord)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, types.Owner, int) error); ok {
+		r1 = rf(ctx, owner, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StorageV2_GetLastRecords_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLastRecords'
+type StorageV2_GetLastRecords_Call struct {
+	*mock.Call
+}
+
+// GetLastRecords is a helper method to define mock.On call
+//   - ctx context.Context
+//   - owner types.Owner
+//   - limit int
+func (_e *StorageV2_Expecter) GetLastRecords(ctx interface{}, owner interface{}, limit interface{}) *StorageV2_GetLastRecords_Call {
+	return &StorageV2_GetLastRecords_Call{Call: _e.mock.On("GetLastRecords", ctx, owner, limit)}
+}
+
+func (_c *StorageV2_GetLastRecords_Call) Run(run func(ctx context.Context, owner types.Owner, limit int)) *StorageV2_GetLastRecords_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(types.Owner), args[2].(int))
+	})
+	return _c
+}
+
+func (_c *StorageV2_GetLastRecords_Call) Return(_a0 []types.GameRecord, _a1 error) *StorageV2_GetLastRecords_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+// GetLastScores provides a mock function with given fields:
+func (_m *StorageV2) GetLastScores() ([]types.Result, error) {
+	ret := _m.Called()
+
+	var r0 []types.Result
+	if rf, ok := ret.Get(0).(func() []types.Result); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.Result)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StorageV2_GetLastScores_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLastScores'
+type StorageV2_GetLastScores_Call struct {
+	*mock.Call
+}
+
+// GetLastScores is a helper method to define mock.On call
+func (_e *StorageV2_Expecter) GetLastScores() *StorageV2_GetLastScores_Call {
+	return &StorageV2_GetLastScores_Call{Call: _e.mock.On("GetLastScores")}
+}
+
+func (_c *StorageV2_GetLastScores_Call) Run(run func()) *StorageV2_GetLastScores_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *StorageV2_GetLastScores_Call) Return(_a0 []types.Result, _a1 error) *StorageV2_GetLastScores_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+// History provides a mock function with given fields: ctx, query
+func (_m *StorageV2) History(ctx context.Context, query types.HistoryQuery) (types.HistoryPage, error) {
+	ret := _m.Called(ctx, query)
+
+	var r0 types.HistoryPage
+	if rf, ok := ret.Get(0).(func(context.Context, types.HistoryQuery) types.HistoryPage); ok {
+		r0 = rf(ctx, query)
+	} else {
+		r0 = ret.Get(0).(types.HistoryPage)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, types.HistoryQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StorageV2_History_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'History'
+type StorageV2_History_Call struct {
+	*mock.Call
+}
+
+// History is a helper method to define mock.On call
+//   - ctx context.Context
+//   - query types.HistoryQuery
+func (_e *StorageV2_Expecter) History(ctx interface{}, query interface{}) *StorageV2_History_Call {
+	return &StorageV2_History_Call{Call: _e.mock.On("History", ctx, query)}
+}
+
+func (_c *StorageV2_History_Call) Run(run func(ctx context.Context, query types.HistoryQuery)) *StorageV2_History_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(types.HistoryQuery))
+	})
+	return _c
+}
+
+func (_c *StorageV2_History_Call) Return(_a0 types.HistoryPage, _a1 error) *StorageV2_History_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+// RestoreRecords provides a mock function with given fields: ctx, owner
+func (_m *StorageV2) RestoreRecords(ctx context.Context, owner types.Owner) (types.Tombstone, error) {
+	ret := _m.Called(ctx, owner)
+
+	var r0 types.Tombstone
+	if rf, ok := ret.Get(0).(func(context.Context, types.Owner) types.Tombstone); ok {
+		r0 = rf(ctx, owner)
+	} else {
+		r0 = ret.Get(0).(types.Tombstone)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, types.Owner) error); ok {
+		r1 = rf(ctx, owner)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StorageV2_RestoreRecords_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RestoreRecords'
+type StorageV2_RestoreRecords_Call struct {
+	*mock.Call
+}
+
+// RestoreRecords is a helper method to define mock.On call
+//   - ctx context.Context
+//   - owner types.Owner
+func (_e *StorageV2_Expecter) RestoreRecords(ctx interface{}, owner interface{}) *StorageV2_RestoreRecords_Call {
+	return &StorageV2_RestoreRecords_Call{Call: _e.mock.On("RestoreRecords", ctx, owner)}
+}
+
+func (_c *StorageV2_RestoreRecords_Call) Run(run func(ctx context.Context, owner types.Owner)) *StorageV2_RestoreRecords_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(types.Owner))
+	})
+	return _c
+}
+
+func (_c *StorageV2_RestoreRecords_Call) Return(_a0 types.Tombstone, _a1 error) *StorageV2_RestoreRecords_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+// SetLastScore provides a mock function with given fields: _a0
+func (_m *StorageV2) SetLastScore(_a0 types.Result) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(types.Result) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StorageV2_SetLastScore_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetLastScore'
+type StorageV2_SetLastScore_Call struct {
+	*mock.Call
+}
+
+// SetLastScore is a helper method to define mock.On call
+//   - _a0 types.Result
+func (_e *StorageV2_Expecter) SetLastScore(_a0 interface{}) *StorageV2_SetLastScore_Call {
+	return &StorageV2_SetLastScore_Call{Call: _e.mock.On("SetLastScore", _a0)}
+}
+
+func (_c *StorageV2_SetLastScore_Call) Run(run func(_a0 types.Result)) *StorageV2_SetLastScore_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(types.Result))
+	})
+	return _c
+}
+
+func (_c *StorageV2_SetLastScore_Call) Return(_a0 error) *StorageV2_SetLastScore_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+type mockConstructorTestingTNewStorageV2 interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewStorageV2 creates a new instance of StorageV2. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewStorageV2(t mockConstructorTestingTNewStorageV2) *StorageV2 {
+	mock := &StorageV2{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
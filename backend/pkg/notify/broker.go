@@ -0,0 +1,221 @@
+package notify
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+)
+
+// Broker is a storage which also streams the changes of the scoreboards.
+type Broker interface {
+	pkg.StorageV2
+	pkg.ScoreNotifier
+}
+
+// subscriberBuffer is the number of events a subscriber may lag behind before it's dropped
+const subscriberBuffer = 64
+
+// published event with the statistics right after it
+type published struct {
+	event       types.ScoreEvent
+	ownerStats  *types.Stats
+	globalStats *types.Stats
+}
+
+// forOwner returns the event as seen by the subscriber of the owner's scoreboard
+func (p published) forOwner(owner types.Owner) types.ScoreEvent {
+	ev := p.event
+	switch {
+	case owner == types.Global:
+		ev.Stats = p.globalStats
+	case ev.Owner == owner:
+		ev.Stats = p.ownerStats
+	case ev.Type == types.ScoresCleared && p.globalStats != nil:
+		// everything was cleared
+		ev.Stats = &types.Stats{}
+	}
+	return ev
+}
+
+type subscriber struct {
+	owner types.Owner
+	ch    chan types.ScoreEvent
+}
+
+// broker wraps the storage and publishes every change after it's stored.
+// The last changes are kept, so that subscribers can resume after reconnecting.
+type broker struct {
+	pkg.StorageV2
+	stats pkg.StatsProvider
+
+	mu          sync.Mutex
+	lastID      uint64
+	history     []published
+	historySize int
+	subscribers map[*subscriber]struct{}
+}
+
+// NewBroker wraps the storage, keeping up to historySize of the last changes for resuming subscribers.
+// Changes carry statistics from the provider, if it's not nil.
+func NewBroker(storage pkg.StorageV2, stats pkg.StatsProvider, historySize int) Broker {
+	return &broker{
+		StorageV2:   storage,
+		stats:       stats,
+		historySize: historySize,
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+// writes hold the lock so that changes are published in the storage order
+func (b *broker) AddRecord(ctx context.Context, record types.GameRecord) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.StorageV2.AddRecord(ctx, record); err != nil {
+		return err
+	}
+	// the stored record has its ID
+	if records, err := b.StorageV2.GetLastRecords(ctx, record.Owner, 1); err == nil && len(records) == 1 {
+		record = records[0]
+	}
+	b.publish(ctx, types.ScoreEvent{
+		Type:   types.ScorePlayed,
+		Owner:  record.Owner,
+		Record: &record,
+	})
+	return nil
+}
+
+func (b *broker) SetLastScore(r types.Result) error {
+	return b.AddRecord(context.Background(), types.GameRecord{
+		Time:   time.Now(),
+		Mode:   types.ModeComputer,
+		Result: r,
+	})
+}
+
+func (b *broker) ClearRecords(ctx context.Context, owner types.Owner) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.StorageV2.ClearRecords(ctx, owner); err != nil {
+		return err
+	}
+	b.publish(ctx, types.ScoreEvent{
+		Type:  types.ScoresCleared,
+		Owner: owner,
+	})
+	return nil
+}
+
+func (b *broker) ClearScores() error {
+	return b.ClearRecords(context.Background(), types.Global)
+}
+
+func (b *broker) RestoreRecords(ctx context.Context, owner types.Owner) (types.Tombstone, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	tombstone, err := b.StorageV2.RestoreRecords(ctx, owner)
+	if err != nil {
+		return tombstone, err
+	}
+	b.publish(ctx, types.ScoreEvent{
+		Type:  types.ScoresRestored,
+		Owner: owner,
+	})
+	return tombstone, nil
+}
+
+// other events don't change the scoreboards, they are dropped if the storage doesn't keep them
+func (b *broker) RecordEvent(ctx context.Context, event types.Event) error {
+	if recorder, ok := b.StorageV2.(pkg.EventRecorder); ok {
+		return recorder.RecordEvent(ctx, event)
+	}
+	return nil
+}
+
+// publish must be called with the lock held
+func (b *broker) publish(ctx context.Context, ev types.ScoreEvent) {
+	b.lastID++
+	ev.ID = b.lastID
+	p := published{event: ev}
+	if b.stats != nil {
+		if ev.Owner != types.Global {
+			if stats, err := b.stats.GetStats(ctx, ev.Owner); err == nil {
+				p.ownerStats = &stats
+			}
+		}
+		if stats, err := b.stats.GetStats(ctx, types.Global); err == nil {
+			p.globalStats = &stats
+		}
+	}
+
+	if len(b.history) == b.historySize && b.historySize > 0 {
+		b.history = append(b.history[:0], b.history[1:]...)
+	}
+	if b.historySize > 0 {
+		b.history = append(b.history, p)
+	}
+
+	for sub := range b.subscribers {
+		if !ev.Concerns(sub.owner) {
+			continue
+		}
+		select {
+		case sub.ch <- p.forOwner(sub.owner):
+		default:
+			// too slow, it will resume from its last event
+			b.drop(sub)
+		}
+	}
+}
+
+// drop must be called with the lock held
+func (b *broker) drop(sub *subscriber) {
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+func (b *broker) Subscribe(ctx context.Context, owner types.Owner, lastID uint64) (<-chan types.ScoreEvent, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	missed := false
+	var backlog []published
+	if lastID != 0 {
+		oldest := b.lastID + 1
+		if len(b.history) > 0 {
+			oldest = b.history[0].event.ID
+		}
+		// IDs from before a restart are unknown as well
+		missed = lastID > b.lastID || lastID+1 < oldest
+		for _, p := range b.history {
+			if p.event.ID > lastID && p.event.Concerns(owner) {
+				backlog = append(backlog, p)
+			}
+		}
+	}
+
+	sub := &subscriber{
+		owner: owner,
+		ch:    make(chan types.ScoreEvent, len(backlog)+subscriberBuffer),
+	}
+	for _, p := range backlog {
+		sub.ch <- p.forOwner(owner)
+	}
+	b.subscribers[sub] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.drop(sub)
+	}()
+	return sub.ch, missed
+}
@@ -0,0 +1,170 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/mocks"
+	"github.com/complynx/rpssl4bu/backend/pkg/stats"
+	"github.com/complynx/rpssl4bu/backend/pkg/storage"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	sheldon = types.SessionOwner("00000000000000000000000000000001")
+	penny   = types.UserOwner("penny")
+)
+
+func receive(t *testing.T, ch <-chan types.ScoreEvent) types.ScoreEvent {
+	select {
+	case ev, ok := <-ch:
+		require.True(t, ok, "channel is open")
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+	return types.ScoreEvent{}
+}
+
+func assertNoEvent(t *testing.T, ch <-chan types.ScoreEvent) {
+	select {
+	case ev := <-ch:
+		t.Fatalf("unexpected event %+v", ev)
+	default:
+	}
+}
+
+func TestBroker(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tracker, err := stats.NewTracker(ctx, storage.NewSimple(10))
+	require.NoError(t, err)
+	b := NewBroker(tracker, tracker, 10)
+
+	own, missed := b.Subscribe(ctx, sheldon, 0)
+	assert.False(t, missed)
+	all, _ := b.Subscribe(ctx, types.Global, 0)
+
+	require.NoError(t, b.AddRecord(ctx, types.GameRecord{Owner: sheldon, Result: types.Win}))
+	ev := receive(t, own)
+	assert.Equal(t, uint64(1), ev.ID)
+	assert.Equal(t, types.ScorePlayed, ev.Type)
+	assert.Equal(t, sheldon, ev.Owner)
+	assert.Equal(t, uint64(1), ev.Record.ID, "record is sent as stored")
+	assert.Equal(t, 1, ev.Stats.Wins)
+	ev = receive(t, all)
+	assert.Equal(t, uint64(1), ev.ID)
+
+	// others' games are only seen globally, with global statistics
+	require.NoError(t, b.AddRecord(ctx, types.GameRecord{Owner: penny, Result: types.Lose}))
+	ev = receive(t, all)
+	assert.Equal(t, penny, ev.Owner)
+	assert.Equal(t, 2, ev.Stats.Games)
+	assertNoEvent(t, own)
+
+	// clearing everything concerns everyone
+	require.NoError(t, b.ClearScores())
+	ev = receive(t, own)
+	assert.Equal(t, types.ScoresCleared, ev.Type)
+	assert.Equal(t, types.Global, ev.Owner)
+	assert.Equal(t, 0, ev.Stats.Games)
+	assert.Equal(t, types.ScoresCleared, receive(t, all).Type)
+
+	// restoring is published with the statistics
+	_, err = b.RestoreRecords(ctx, types.Global)
+	require.NoError(t, err)
+	ev = receive(t, own)
+	assert.Equal(t, types.ScoresRestored, ev.Type)
+	assert.Nil(t, ev.Stats)
+	ev = receive(t, all)
+	assert.Equal(t, types.ScoresRestored, ev.Type)
+	assert.Equal(t, 2, ev.Stats.Games)
+
+	// channel is closed with the context
+	subCtx, subCancel := context.WithCancel(ctx)
+	ch, _ := b.Subscribe(subCtx, penny, 0)
+	subCancel()
+	assert.Eventually(t, func() bool {
+		_, ok := <-ch
+		return !ok
+	}, time.Second, time.Millisecond)
+}
+
+func TestBrokerResume(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := NewBroker(storage.NewSimple(10), nil, 3)
+
+	for i := 0; i < 5; i++ {
+		owner := sheldon
+		if i%2 == 1 {
+			owner = penny
+		}
+		require.NoError(t, b.AddRecord(ctx, types.GameRecord{Owner: owner, Result: types.Win}))
+	}
+
+	// events 3, 4 and 5 are kept, the owner's ones after the last seen are resent
+	ch, missed := b.Subscribe(ctx, sheldon, 2)
+	assert.False(t, missed)
+	ev := receive(t, ch)
+	assert.Equal(t, uint64(3), ev.ID)
+	assert.Nil(t, ev.Stats)
+	assert.Equal(t, uint64(5), receive(t, ch).ID)
+	assertNoEvent(t, ch)
+
+	ch, missed = b.Subscribe(ctx, types.Global, 5)
+	assert.False(t, missed)
+	assertNoEvent(t, ch)
+
+	// event 2 is gone
+	ch, missed = b.Subscribe(ctx, types.Global, 1)
+	assert.True(t, missed)
+	assert.Equal(t, uint64(3), receive(t, ch).ID)
+
+	// events from before a restart
+	_, missed = b.Subscribe(ctx, types.Global, 42)
+	assert.True(t, missed)
+}
+
+func TestBrokerSlowSubscriber(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := NewBroker(storage.NewSimple(10), nil, subscriberBuffer+10)
+
+	ch, _ := b.Subscribe(ctx, types.Global, 0)
+	for i := 0; i < subscriberBuffer+1; i++ {
+		require.NoError(t, b.SetLastScore(types.Tie))
+	}
+
+	// it's dropped, the buffered events are still delivered
+	var last uint64
+	for ev := range ch {
+		last = ev.ID
+	}
+	assert.Equal(t, uint64(subscriberBuffer), last)
+
+	// and it resumes from there
+	ch, missed := b.Subscribe(ctx, types.Global, last)
+	assert.False(t, missed)
+	assert.Equal(t, uint64(subscriberBuffer+1), receive(t, ch).ID)
+}
+
+func TestBrokerErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := mocks.NewStorageV2(t)
+	b := NewBroker(s, nil, 10)
+	ch, _ := b.Subscribe(ctx, types.Global, 0)
+
+	// failed writes are not published
+	s.EXPECT().AddRecord(mock.Anything, mock.Anything).Return(errors.New("test")).Once()
+	s.EXPECT().ClearRecords(mock.Anything, sheldon).Return(errors.New("test")).Once()
+	assert.Error(t, b.AddRecord(ctx, types.GameRecord{Owner: sheldon}))
+	assert.Error(t, b.ClearRecords(ctx, sheldon))
+	assertNoEvent(t, ch)
+}
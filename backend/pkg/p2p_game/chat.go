@@ -0,0 +1,128 @@
+package p2pgame
+
+import (
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxChatLength is the number of characters a chat text may have
+	maxChatLength = 200
+	// chatHistory is the number of the newest chat messages kept in a game and sent to the newcomers
+	chatHistory = 50
+)
+
+// profanity is the words hidden from the chat, with their common forms
+var profanity = regexp.MustCompile(`(?i)\b(` + strings.Join([]string{
+	`fuck\w*`, `motherfuck\w*`, `shit\w*`, `bullshit`, `bitch\w*`, `bastards?`,
+	`assholes?`, `dickheads?`, `cunts?`, `crap`, `damn\w*`, `piss\w*`, `wank\w*`,
+}, "|") + `)\b`)
+
+// censor replaces the profanity in the text with asterisks
+func censor(text string) string {
+	return profanity.ReplaceAllStringFunc(text, func(word string) string {
+		return strings.Repeat("*", utf8.RuneCountInString(word))
+	})
+}
+
+func (g *p2pgame) Chat(rightSide bool, text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" || !utf8.ValidString(text) || utf8.RuneCountInString(text) > maxChatLength {
+		return types.ErrBadChat
+	}
+	return g.say(rightSide, types.ChatMessage{Text: censor(text)})
+}
+
+func (g *p2pgame) Emote(rightSide bool, emote types.Emote) error {
+	if !emote.Valid() {
+		return types.ErrBadEmote
+	}
+	return g.say(rightSide, types.ChatMessage{Emote: emote})
+}
+
+// noticeText checks the text of an operators' notice
+func noticeText(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || !utf8.ValidString(text) || utf8.RuneCountInString(text) > maxChatLength {
+		return "", types.ErrBadChat
+	}
+	return text, nil
+}
+
+func (g *p2pgame) Notice(text string) error {
+	text, err := noticeText(text)
+	if err != nil {
+		return err
+	}
+	g.notice(text)
+	return nil
+}
+
+// notice relays the checked text of the operators to everyone in the game
+func (g *p2pgame) notice(text string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.broadcast(types.ChatMessage{Side: types.SideServer, Text: text})
+}
+
+// say adds the message of the player to the history and relays it to everyone in the game
+func (g *p2pgame) say(rightSide bool, msg types.ChatMessage) error {
+	go g.ping()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	seat := g.seat(rightSide)
+	if seat.Name == "" {
+		return types.ErrNoPlayer
+	}
+	msg.Side = types.SideLeft
+	if rightSide {
+		msg.Side = types.SideRight
+	}
+	msg.Name = seat.Name
+	g.active = time.Now()
+	g.broadcast(msg)
+	return nil
+}
+
+// broadcast adds the message to the history and relays it to everyone in the game, must be called with the lock held
+func (g *p2pgame) broadcast(msg types.ChatMessage) {
+	g.lastChatID++
+	msg.ID = g.lastChatID
+	msg.Time = time.Now()
+
+	g.chat = append(g.chat, msg)
+	if len(g.chat) > chatHistory {
+		g.chat = g.chat[len(g.chat)-chatHistory:]
+	}
+	g.changed()
+
+	update := types.Update{Chat: &msg}
+	for _, p := range []*player{&g.left, &g.right} {
+		if p.out != nil {
+			p.out.send(update)
+		}
+	}
+	for _, out := range g.spectators {
+		out.send(update)
+	}
+	for _, out := range g.taps {
+		out.send(update)
+	}
+	g.log.Info("chat message", zap.String("side", msg.Side), zap.Uint64("id", msg.ID))
+}
+
+// sendChatHistory sends the kept chat messages to a new connection, must be called with the lock held
+func (g *p2pgame) sendChatHistory(out *outbox) {
+	for _, msg := range g.chat {
+		msg := msg
+		out.send(types.Update{Chat: &msg})
+	}
+}
@@ -2,9 +2,14 @@ package p2pgame
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"fmt"
-	"regexp"
 	"runtime"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 
@@ -13,14 +18,34 @@ import (
 	"github.com/complynx/rpssl4bu/backend/pkg/random"
 	"github.com/complynx/rpssl4bu/backend/pkg/types"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 )
 
 const gameExistence = 2 * time.Hour
 
+// maxSpectators is the number of spectators a game can have at once
+const maxSpectators = 100
+
+// restartFlush is how long the updates are delivered to the connections of a game stopped by the restart
+const restartFlush = time.Second
+
+// reconnectGrace is how long the seat of a disconnected player is held for them to resume
+const reconnectGrace = 30 * time.Second
+
 type player struct {
 	Name   string
+	Owner  types.Owner
 	Choice types.Choice
-	Chan   chan types.Message
+	// TokenHash is the hash of the token letting the player resume after losing the connection,
+	// the token itself isn't kept
+	TokenHash string
+
+	// out delivers the states to the player, nil while they are disconnected
+	out *outbox
+	// last is the latest state of the player, replayed when they resume
+	last types.Message
+	// detached removes the disconnected player after the grace period
+	detached *time.Timer
 }
 
 type p2pgame struct {
@@ -31,84 +56,342 @@ type p2pgame struct {
 	ctx         context.Context
 	mu          sync.RWMutex
 	pingChannel chan struct{}
+	// changes signals the runner to save the snapshot of the game
+	changes chan struct{}
+	// done is closed when the runner has finished
+	done chan struct{}
+	// keep is set when the game is stopped by the shutdown, its snapshot is kept to restore it then
+	keep bool
+	// expires is when the game ends unless somebody plays it
+	expires time.Time
+
+	created time.Time
+	// active is when a player last joined, chose or chatted
+	active time.Time
+	// creator is the name of the first player who took a seat
+	creator string
+	// host may kick and ban the players, types.Global for nobody
+	host types.Owner
+	// bcrypt hash of the password of the game, nil if there's none
+	password []byte
+	// banned owners can't take a seat anymore
+	banned map[types.Owner]struct{}
 
 	left  player
 	right player
+	// spectators watching the game, by their channels
+	spectators map[chan types.Update]*outbox
+	// taps of the operators, seeing the choices as soon as they are made
+	taps map[chan types.Update]*outbox
+	// restart is set when the server is shutting down, no new rounds are started then
+	restart *types.Restart
+	// saving counts the finished rounds and matches still being stored, the drain waits for them
+	saving   int
+	settings types.GameSettings
+	// score of the match between the current players
+	score types.MatchScore
+	// match is the number of the current match in the game, starting from 1
+	match int
+	// newest chat messages, oldest first
+	chat       []types.ChatMessage
+	lastChatID uint64
 }
 
 type gameFactory struct {
-	rng   pkg.RandomProvider
-	games map[types.GameID]*p2pgame
-	mu    sync.RWMutex
-	log   *zap.Logger
+	rng     pkg.RandomProvider
+	storage pkg.StorageV2
+	games   map[types.GameID]*p2pgame
+	mu      sync.RWMutex
+	log     *zap.Logger
+	grace   time.Duration
+	// maxSpectators is the number of spectators allowed in a game
+	maxSpectators int
+
+	// events is the storage, if it keeps events
+	events pkg.EventRecorder
+	// ratings of the players, the matches aren't rated without them
+	ratings pkg.RatingStorage
+	// store keeps the snapshots of the games, they aren't restored after a restart without it
+	store pkg.GameStore
+	// saveDelay is how long the changes of a game are gathered before its snapshot is saved
+	saveDelay time.Duration
+
+	// watchers of the lobby
+	lobbyMu  sync.Mutex
+	watchers map[chan struct{}]struct{}
+
+	// draining is set when the server is shutting down, no new games are created then
+	draining bool
+}
+
+// Option configures the game factory.
+type Option func(*gameFactory)
+
+// WithRatings rates the finished matches between registered players.
+func WithRatings(ratings pkg.RatingStorage) Option {
+	return func(gf *gameFactory) {
+		gf.ratings = ratings
+	}
+}
+
+// WithGameStore saves the snapshots of the games to restore them after a restart.
+func WithGameStore(store pkg.GameStore) Option {
+	return func(gf *gameFactory) {
+		gf.store = store
+	}
 }
 
-func NewGameFactory(rng pkg.RandomProvider, log *zap.Logger) pkg.P2PGameFactory {
-	return &gameFactory{
-		rng:   rng,
-		games: make(map[types.GameID]*p2pgame),
-		log:   log,
+func NewGameFactory(rng pkg.RandomProvider, storage pkg.StorageV2, log *zap.Logger, opts ...Option) pkg.P2PGameFactory {
+	gf := &gameFactory{
+		rng:     rng,
+		storage: storage,
+		games:   make(map[types.GameID]*p2pgame),
+		log:     log,
+		grace:   reconnectGrace,
+
+		saveDelay:     saveDelay,
+		maxSpectators: maxSpectators,
+		watchers:      make(map[chan struct{}]struct{}),
+	}
+	gf.events, _ = storage.(pkg.EventRecorder)
+	for _, opt := range opts {
+		opt(gf)
 	}
+	return gf
 }
 
-func (gf *gameFactory) setGameIfNotExist(g *p2pgame) bool {
+func (gf *gameFactory) setGameIfNotExist(g *p2pgame) (bool, error) {
 	gf.mu.Lock()
 	defer gf.mu.Unlock()
 
+	if gf.draining {
+		return false, types.ErrShuttingDown
+	}
 	_, exists := gf.games[g.ID]
 	if exists {
-		return false
+		return false, nil
 	}
 
 	gf.games[g.ID] = g
-	return true
+	return true, nil
 }
 
+// StopGames stops the games keeping their snapshots, and waits for them until the context is done
 func (gf *gameFactory) StopGames(ctx context.Context) {
 	gf.mu.RLock()
-	defer gf.mu.RUnlock()
-
+	games := make([]*p2pgame, 0, len(gf.games))
 	for _, g := range gf.games {
+		games = append(games, g)
+	}
+	gf.mu.RUnlock()
+
+	for _, g := range games {
+		g.mu.Lock()
+		g.keep = true
+		g.mu.Unlock()
 		g.cancel()
 	}
+	for _, g := range games {
+		select {
+		case <-g.done:
+		case <-ctx.Done():
+			gf.log.Warn("games not stopped in time")
+			return
+		}
+	}
 }
 
-func (gf *gameFactory) removeGame(id types.GameID) {
+// drainPoll is how often the draining checks if the rounds are over
+const drainPoll = 50 * time.Millisecond
+
+// stopTimeout is how long the drained games have to save their snapshots
+const stopTimeout = 5 * time.Second
+
+func (gf *gameFactory) Drain(ctx context.Context) {
+	deadline, _ := ctx.Deadline()
 	gf.mu.Lock()
-	defer gf.mu.Unlock()
+	gf.draining = true
+	games := make([]*p2pgame, 0, len(gf.games))
+	for _, g := range gf.games {
+		games = append(games, g)
+	}
+	gf.mu.Unlock()
+
+	gf.log.Info("draining games", zap.Int("games", len(games)), zap.Time("deadline", deadline))
+	for _, g := range games {
+		g.restarting(deadline)
+	}
+
+	// the games are stopped after the deadline too
+	stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), stopTimeout)
+	defer cancel()
+	defer gf.StopGames(stopCtx)
 
-	delete(gf.games, id)
+	ticker := time.NewTicker(drainPoll)
+	defer ticker.Stop()
+	for slices.ContainsFunc(games, (*p2pgame).playing) {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			gf.log.Warn("rounds not over before the deadline")
+			return
+		}
+	}
 }
 
-func (gf *gameFactory) CreateGame(ctx context.Context) (pkg.P2PGame, error) {
-	var id types.GameID
-	var err error
-	game := &p2pgame{
-		factory:     gf,
-		log:         gf.log,
-		pingChannel: make(chan struct{}),
+func (gf *gameFactory) removeGame(g *p2pgame) {
+	gf.mu.Lock()
+	delete(gf.games, g.ID)
+	gf.mu.Unlock()
+
+	g.lobbyChanged()
+}
+
+func (gf *gameFactory) Lobby() []types.GameInfo {
+	gf.mu.RLock()
+	games := make([]*p2pgame, 0, len(gf.games))
+	for _, g := range gf.games {
+		if g.settings.Public {
+			games = append(games, g)
+		}
+	}
+	gf.mu.RUnlock()
 
-		left:  player{},
-		right: player{},
+	now := time.Now()
+	ret := []types.GameInfo{}
+	for _, g := range games {
+		if info, open := g.info(now); open {
+			ret = append(ret, info)
+		}
 	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Created.Before(ret[j].Created) })
+	return ret
+}
+
+func (gf *gameFactory) WatchLobby(ctx context.Context) <-chan struct{} {
+	ch := make(chan struct{}, 1)
+	gf.lobbyMu.Lock()
+	gf.watchers[ch] = struct{}{}
+	gf.lobbyMu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		gf.lobbyMu.Lock()
+		delete(gf.watchers, ch)
+		gf.lobbyMu.Unlock()
+		close(ch)
+	}()
+	return ch
+}
+
+// lobbyChanged signals the watchers of the lobby, the ones already signalled are skipped
+func (gf *gameFactory) lobbyChanged() {
+	gf.lobbyMu.Lock()
+	defer gf.lobbyMu.Unlock()
+
+	for ch := range gf.watchers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (gf *gameFactory) CreateGame(ctx context.Context, settings types.GameSettings, access types.GameAccess) (pkg.P2PGame, error) {
+	settings, err := settings.Normalize()
+	if err != nil {
+		return nil, fmt.Errorf("settings: %w", err)
+	}
+	var password []byte
+	if access.Password != "" {
+		if password, err = bcrypt.GenerateFromPassword([]byte(access.Password), bcrypt.DefaultCost); err != nil {
+			return nil, fmt.Errorf("hash password: %w", err)
+		}
+	}
+	var id types.GameID
+	game := gf.newGame(settings, time.Now())
+	game.host = access.Host
+	game.password = password
 	for {
 		id, err = random.RandomID(ctx, gf.rng)
 		if err != nil {
 			return nil, fmt.Errorf("generating ID: %w", err)
 		}
 		game.ID = id
-		if gf.setGameIfNotExist(game) {
+		added, err := gf.setGameIfNotExist(game)
+		if err != nil {
+			return nil, err
+		}
+		if added {
 			break
 		}
 	}
 	err = game.Start(ctx)
 	if err != nil {
-		gf.removeGame(game.ID)
+		gf.removeGame(game)
 		return nil, fmt.Errorf("starting game: %w", err)
 	}
+	game.lobbyChanged()
 	return game, nil
 }
 
+// newGame returns a game without players created at the time
+func (gf *gameFactory) newGame(settings types.GameSettings, now time.Time) *p2pgame {
+	return &p2pgame{
+		settings:    settings,
+		factory:     gf,
+		log:         gf.log,
+		pingChannel: make(chan struct{}),
+		changes:     make(chan struct{}, 1),
+		done:        make(chan struct{}),
+
+		left:       player{},
+		right:      player{},
+		spectators: make(map[chan types.Update]*outbox),
+		taps:       make(map[chan types.Update]*outbox),
+		score:      types.NewMatchScore(settings.BestOf),
+		match:      1,
+		created:    now,
+		active:     now,
+		expires:    now.Add(gameExistence),
+		banned:     make(map[types.Owner]struct{}),
+	}
+}
+
+func (gf *gameFactory) Games() []types.GameStatus {
+	gf.mu.RLock()
+	games := make([]*p2pgame, 0, len(gf.games))
+	for _, g := range gf.games {
+		games = append(games, g)
+	}
+	gf.mu.RUnlock()
+
+	ret := make([]types.GameStatus, 0, len(games))
+	for _, g := range games {
+		ret = append(ret, g.Status())
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Created.Before(ret[j].Created) })
+	return ret
+}
+
+func (gf *gameFactory) Notice(text string) (int, error) {
+	text, err := noticeText(text)
+	if err != nil {
+		return 0, err
+	}
+	gf.mu.RLock()
+	games := make([]*p2pgame, 0, len(gf.games))
+	for _, g := range gf.games {
+		games = append(games, g)
+	}
+	gf.mu.RUnlock()
+
+	for _, g := range games {
+		g.notice(text)
+	}
+	return len(games), nil
+}
+
 func (gf *gameFactory) GetGame(id types.GameID) (pkg.P2PGame, bool) {
 	gf.mu.RLock()
 	defer gf.mu.RUnlock()
@@ -124,9 +407,81 @@ func (g *p2pgame) IsFull(ctx context.Context) bool {
 	return g.left.Name != "" && g.right.Name != ""
 }
 
+// info describes the game for the lobby, open is false if it's full
+func (g *p2pgame) info(now time.Time) (info types.GameInfo, open bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.gameInfo(now)
+}
+
+// gameInfo describes the game for the lobby, must be called with the lock held
+func (g *p2pgame) gameInfo(now time.Time) (info types.GameInfo, open bool) {
+	players := 0
+	for _, p := range []*player{&g.left, &g.right} {
+		if p.Name != "" {
+			players++
+		}
+	}
+	return types.GameInfo{
+		ID:           g.ID,
+		Creator:      g.creator,
+		GameSettings: g.settings,
+		Created:      g.created,
+		Age:          int(now.Sub(g.created).Seconds()),
+		Players:      players,
+		Spectators:   len(g.spectators),
+		Protected:    g.password != nil,
+	}, players < 2
+}
+
+func (g *p2pgame) Status() types.GameStatus {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	info, _ := g.gameInfo(time.Now())
+	return types.GameStatus{
+		GameInfo:     info,
+		Host:         g.host,
+		Left:         g.left.status(),
+		Right:        g.right.status(),
+		LastActivity: g.active,
+		Score:        g.score,
+	}
+}
+
+// status describes the player, nil for a free seat
+func (p *player) status() *types.PlayerStatus {
+	if p.Name == "" {
+		return nil
+	}
+	return &types.PlayerStatus{
+		Name:         p.Name,
+		Owner:        p.Owner,
+		Disconnected: p.disconnected(),
+	}
+}
+
+// lobbyChanged tells the lobby watchers about the change of the public game
+func (g *p2pgame) lobbyChanged() {
+	if g.settings.Public {
+		g.factory.lobbyChanged()
+	}
+}
+
 func (g *p2pgame) Start(ctx context.Context) error {
 	g.log = g.log.With(zap.String("game_id", g.ID.String()))
 	g.ctx, g.cancel = context.WithCancel(context.Background())
+
+	// the players of the restored game have the grace period to resume from now on
+	g.mu.Lock()
+	for _, rightSide := range []bool{false, true} {
+		if seat := g.seat(rightSide); seat.Name != "" && seat.out == nil && seat.detached == nil {
+			g.holdSeat(rightSide)
+		}
+	}
+	g.mu.Unlock()
+
 	go g.run()
 	return nil
 }
@@ -135,26 +490,64 @@ func (g *p2pgame) GetID() types.GameID {
 	return g.ID
 }
 
-var ErrBadName = fmt.Errorf("bad name")
+func (g *p2pgame) Host() types.Owner {
+	return g.host
+}
+
+// the password is hashed with bcrypt, which is slow to brute-force if the snapshots leak
+func (g *p2pgame) CheckPassword(password string) bool {
+	if g.password == nil {
+		return true
+	}
+	// no game has an empty password, the lobby checks it for every protected game
+	if password == "" {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword(g.password, []byte(password)) == nil
+}
+
 var ErrGameIsFull = fmt.Errorf("game is full")
-var nameRe = regexp.MustCompile(`^[a-zA-Z ]{0,20}$`)
+var ErrBadToken = fmt.Errorf("bad resume token")
+var ErrTooManySpectators = fmt.Errorf("too many spectators")
 
 const unnamed = "Anonymous"
 
-func (g *p2pgame) AddPlayer(name string) (rightSide bool, ch chan types.Message, err error) {
-	if !nameRe.MatchString(name) {
-		return false, nil, ErrBadName
+func (g *p2pgame) seat(rightSide bool) *player {
+	if rightSide {
+		return &g.right
+	}
+	return &g.left
+}
+
+func (g *p2pgame) AddPlayer(name string, owner types.Owner) (bool, chan types.Update, string, error) {
+	return g.addPlayer(name, owner, nil)
+}
+
+func (g *p2pgame) AddPlayerOnSide(name string, owner types.Owner, rightSide bool) (chan types.Update, string, error) {
+	_, ch, token, err := g.addPlayer(name, owner, &rightSide)
+	return ch, token, err
+}
+
+// addPlayer seats the player on the given side, or on the free one if it's nil
+func (g *p2pgame) addPlayer(name string, owner types.Owner, side *bool) (rightSide bool, ch chan types.Update, token string, err error) {
+	if !types.ValidPlayerName(name) {
+		return false, nil, "", types.ErrBadName
 	}
 
 	if name == "" {
 		name = unnamed
 	}
 
-	defer g.sendState(types.Unknown)
+	token, err = newToken()
+	if err != nil {
+		return false, nil, "", fmt.Errorf("resume token: %w", err)
+	}
+
 	go g.ping()
 
 	defer func() {
 		if err == nil {
+			g.recordEvent(types.EventPlayerJoined, name, owner)
 			g.log.Info("player added",
 				zap.Bool("side", rightSide),
 				zap.Any("player1", g.left.Name),
@@ -166,20 +559,56 @@ func (g *p2pgame) AddPlayer(name string) (rightSide bool, ch chan types.Message,
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	if g.left.Name != "" {
-		if g.right.Name != "" {
-			return false, nil, ErrGameIsFull
+	if g.restart != nil {
+		return false, nil, "", types.ErrShuttingDown
+	}
+	if _, banned := g.banned[owner]; banned && owner != types.Global {
+		return false, nil, "", types.ErrBanned
+	}
+	switch {
+	case side != nil:
+		rightSide = *side
+		if g.seat(rightSide).Name != "" {
+			return false, nil, "", types.ErrSeatTaken
 		}
+	case g.left.Name == "":
+	case g.right.Name == "":
+		rightSide = true
+	default:
+		return false, nil, "", ErrGameIsFull
+	}
+	seat := g.seat(rightSide)
+	*seat = player{
+		Name:      name,
+		Owner:     owner,
+		TokenHash: hashToken(token),
+		out:       newOutbox(),
+	}
+	if g.creator == "" {
+		g.creator = name
+	}
+	g.active = time.Now()
+	g.lobbyChanged()
+	g.sendChatHistory(seat.out)
+	g.sendState(types.Unknown)
+	g.changed()
+	return rightSide, seat.out.ch, token, nil
+}
 
-		g.right.Name = name
-		g.right.Chan = make(chan types.Message)
-		return true, g.right.Chan, nil
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
-	g.left.Name = name
-	g.left.Chan = make(chan types.Message)
-	return false, g.left.Chan, nil
+	return hex.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
+// sendState sends the current state to the players and spectators, must be called with the lock held
 func (g *p2pgame) sendState(result types.Result) {
 	g.log.Info("sending state",
 		zap.Any("result", result),
@@ -187,49 +616,96 @@ func (g *p2pgame) sendState(result types.Result) {
 		zap.Any("player2", g.right.Name),
 		zap.Any("player1_choice", g.left.Choice),
 		zap.Any("player2_choice", g.right.Choice),
+		zap.Int("spectators", len(g.spectators)),
+		zap.Int("round", g.score.Round),
 	)
 
-	if g.left.Name != "" {
-		go func(n1, n2 string, c1, c2 types.Choice, result types.Result) {
-			if c1 == types.Undefined {
-				c2 = types.Undefined
-			}
-			g.left.Chan <- types.Message{
-				LeftPlayerName:    n1,
-				RightPlayerName:   n2,
-				Result:            result,
-				LeftPlayerChoice:  c1,
-				RightPlayerChoice: c2,
-			}
-			g.log.Info("sent state to p1",
-				zap.Any("result", result),
-				zap.Any("player1", n1),
-				zap.Any("player2", n2),
-				zap.Any("player1_choice", c1),
-				zap.Any("player2_choice", c2),
-			)
-		}(g.left.Name, g.right.Name, g.left.Choice, g.right.Choice, result)
+	n1, n2, c1, c2 := g.left.Name, g.right.Name, g.left.Choice, g.right.Choice
+	d1, d2 := g.left.disconnected(), g.right.disconnected()
+	spectators := len(g.spectators)
+	if c1 == types.Undefined {
+		c2 = types.Undefined
 	}
-	if g.right.Name != "" {
-		go func(n1, n2 string, c1, c2 types.Choice, result types.Result) {
-			if c2 == types.Undefined {
-				c1 = types.Undefined
-			}
-			g.right.Chan <- types.Message{
-				LeftPlayerName:    n1,
-				RightPlayerName:   n2,
-				Result:            result.Swap(),
-				LeftPlayerChoice:  c1,
-				RightPlayerChoice: c2,
-			}
-			g.log.Info("sent state to p2",
-				zap.Any("result", result.Swap()),
-				zap.Any("player1", n1),
-				zap.Any("player2", n2),
-				zap.Any("player1_choice", c1),
-				zap.Any("player2_choice", c2),
-			)
-		}(g.left.Name, g.right.Name, g.left.Choice, g.right.Choice, result)
+	g.left.send(types.Message{
+		LeftPlayerName:          n1,
+		RightPlayerName:         n2,
+		Result:                  result,
+		LeftPlayerChoice:        c1,
+		RightPlayerChoice:       c2,
+		LeftPlayerDisconnected:  d1,
+		RightPlayerDisconnected: d2,
+		Spectators:              spectators,
+		Score:                   g.score,
+		Settings:                g.settings,
+	})
+
+	c1, c2 = g.left.Choice, g.right.Choice
+	if c2 == types.Undefined {
+		c1 = types.Undefined
+	}
+	g.right.send(types.Message{
+		LeftPlayerName:          n1,
+		RightPlayerName:         n2,
+		Result:                  result.Swap(),
+		LeftPlayerChoice:        c1,
+		RightPlayerChoice:       c2,
+		LeftPlayerDisconnected:  d1,
+		RightPlayerDisconnected: d2,
+		Spectators:              spectators,
+		Score:                   g.score,
+		Settings:                g.settings,
+	})
+
+	// the choices are revealed to spectators only with the result of the round
+	c1, c2 = types.Undefined, types.Undefined
+	if result != types.Unknown {
+		c1, c2 = g.left.Choice, g.right.Choice
+	}
+	for _, out := range g.spectators {
+		out.send(types.Update{State: &types.Message{
+			LeftPlayerName:          n1,
+			RightPlayerName:         n2,
+			Result:                  result,
+			LeftPlayerChoice:        c1,
+			RightPlayerChoice:       c2,
+			LeftPlayerDisconnected:  d1,
+			RightPlayerDisconnected: d2,
+			Spectators:              spectators,
+			Score:                   g.score,
+			Settings:                g.settings,
+		}})
+	}
+
+	// while the taps see everything
+	for _, out := range g.taps {
+		out.send(types.Update{State: &types.Message{
+			LeftPlayerName:          n1,
+			RightPlayerName:         n2,
+			Result:                  result,
+			LeftPlayerChoice:        g.left.Choice,
+			RightPlayerChoice:       g.right.Choice,
+			LeftPlayerDisconnected:  d1,
+			RightPlayerDisconnected: d2,
+			Spectators:              spectators,
+			Score:                   g.score,
+			Settings:                g.settings,
+		}})
+	}
+}
+
+// disconnected tells if the player has lost the connection and their seat is held
+func (p *player) disconnected() bool {
+	return p.Name != "" && p.out == nil
+}
+
+// send keeps the state as the latest and sends it if the player is connected
+func (p *player) send(msg types.Message) {
+	if p.Name == "" {
+		return
+	}
+	p.last = msg
+	if p.out != nil {
+		p.out.send(types.Update{State: &msg})
 	}
 }
 
@@ -241,57 +717,484 @@ func (g *p2pgame) ping() {
 }
 
 func (g *p2pgame) RemovePlayer(rightSide bool) {
-	defer g.sendState(types.Unknown)
+	g.mu.Lock()
+	removed, match, ok := g.removePlayer(rightSide, true)
+	g.mu.Unlock()
 
-	defer func() {
-		g.log.Info("player removed",
-			zap.Bool("side", rightSide),
-			zap.Any("player1", g.left.Name),
-			zap.Any("player2", g.right.Name),
-		)
-	}()
+	if ok {
+		g.playerLeft(rightSide, removed, match)
+	}
+}
+
+// removePlayer frees the seat and returns the match it has ended if it's to be rated,
+// the match is lost by the player if forfeit, it's void if the host has kicked them.
+// Must be called with the lock held.
+func (g *p2pgame) removePlayer(rightSide bool, forfeit bool) (player, *types.MatchResult, bool) {
+	seat := g.seat(rightSide)
+	if seat.Name == "" {
+		return player{}, nil, false
+	}
+	var match *types.MatchResult
+	if forfeit && !g.score.Over {
+		// a finished match has been rated already
+		match = g.matchResult(sideOf(rightSide))
+	}
+	removed := *seat
+	if seat.detached != nil {
+		seat.detached.Stop()
+	}
+	if seat.out != nil {
+		seat.out.close()
+	}
+	*seat = player{}
+	g.saving++
+	// the next player starts a new match
+	g.score = types.NewMatchScore(g.settings.BestOf)
+	g.match++
+	g.sendState(types.Unknown)
+	g.changed()
+	g.lobbyChanged()
+	return removed, match, true
+}
+
+func (g *p2pgame) Kick(rightSide bool, ban bool) error {
+	g.mu.Lock()
+	removed, match, ok := g.removePlayer(rightSide, false)
+	if ok && ban && removed.Owner != types.Global {
+		g.banned[removed.Owner] = struct{}{}
+	}
+	g.mu.Unlock()
+
+	if !ok {
+		return types.ErrNoPlayer
+	}
+	g.playerLeft(rightSide, removed, match)
+	g.log.Info("player kicked", zap.Bool("side", rightSide), zap.Bool("ban", ban))
+	return nil
+}
+
+// playerLeft stores the leaving of the player removed by removePlayer, must be called without the lock
+func (g *p2pgame) playerLeft(rightSide bool, removed player, match *types.MatchResult) {
+	defer g.saved()
+
+	g.recordEvent(types.EventPlayerLeft, removed.Name, removed.Owner)
+	g.rateMatch(match)
+	g.log.Info("player removed",
+		zap.Bool("side", rightSide),
+		zap.String("name", removed.Name),
+	)
+}
 
+func (g *p2pgame) DetachPlayer(ch chan types.Update) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
+	for _, rightSide := range []bool{false, true} {
+		seat := g.seat(rightSide)
+		if seat.out == nil || seat.out.ch != ch {
+			continue
+		}
+		seat.out.close()
+		seat.out = nil
+		g.holdSeat(rightSide)
+		// the opponent is told the connection is lost
+		g.sendState(types.Unknown)
+		g.log.Info("player detached", zap.Bool("side", rightSide), zap.String("name", seat.Name))
+		return
+	}
+}
+
+// holdSeat keeps the seat of the disconnected player for the grace period, must be called with the lock held
+func (g *p2pgame) holdSeat(rightSide bool) {
+	tokenHash := g.seat(rightSide).TokenHash
+	g.seat(rightSide).detached = time.AfterFunc(g.factory.grace, func() {
+		g.expire(rightSide, tokenHash)
+	})
+}
+
+// expire removes the player who hasn't resumed in time
+func (g *p2pgame) expire(rightSide bool, tokenHash string) {
+	g.mu.Lock()
+	var removed player
+	var match *types.MatchResult
+	ok := false
+	if seat := g.seat(rightSide); seat.TokenHash == tokenHash && seat.out == nil {
+		removed, match, ok = g.removePlayer(rightSide, true)
+	}
+	g.mu.Unlock()
+
+	if ok {
+		g.playerLeft(rightSide, removed, match)
+	}
+}
+
+func (g *p2pgame) ResumePlayer(token string) (rightSide bool, ch chan types.Update, err error) {
+	go g.ping()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	tokenHash := hashToken(token)
+	switch {
+	case g.left.Name != "" && tokensEqual(g.left.TokenHash, tokenHash):
+	case g.right.Name != "" && tokensEqual(g.right.TokenHash, tokenHash):
+		rightSide = true
+	default:
+		return false, nil, ErrBadToken
+	}
+
+	seat := g.seat(rightSide)
+	if seat.detached != nil {
+		seat.detached.Stop()
+		seat.detached = nil
+	}
+	if seat.out != nil {
+		// the previous connection is replaced
+		seat.out.close()
+	}
+	seat.out = newOutbox()
+	g.active = time.Now()
+	g.sendChatHistory(seat.out)
+	g.sendRestart(seat.out)
+	last := seat.last
 	if rightSide {
-		g.right.Name = ""
-		close(g.right.Chan)
+		last.RightPlayerDisconnected = false
 	} else {
-		g.left.Name = ""
-		close(g.left.Chan)
+		last.LeftPlayerDisconnected = false
+	}
+	seat.out.send(types.Update{State: &last})
+	// and the opponent that it's back
+	g.sendState(types.Unknown)
+	g.changed()
+
+	g.log.Info("player resumed", zap.Bool("side", rightSide), zap.String("name", seat.Name))
+	return rightSide, seat.out.ch, nil
+}
+
+func (g *p2pgame) AddSpectator() (chan types.Update, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.spectators) >= g.factory.maxSpectators {
+		return nil, ErrTooManySpectators
+	}
+	out := newOutbox()
+	g.spectators[out.ch] = out
+	g.sendChatHistory(out)
+	g.sendRestart(out)
+	g.sendState(types.Unknown)
+	g.lobbyChanged()
+
+	g.log.Info("spectator added", zap.Int("spectators", len(g.spectators)))
+	return out.ch, nil
+}
+
+func (g *p2pgame) RemoveSpectator(ch chan types.Update) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	out, ok := g.spectators[ch]
+	if !ok {
+		return
+	}
+	out.close()
+	delete(g.spectators, ch)
+	g.sendState(types.Unknown)
+	g.lobbyChanged()
+
+	g.log.Info("spectator removed", zap.Int("spectators", len(g.spectators)))
+}
+
+func (g *p2pgame) Tap() chan types.Update {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	out := newOutbox()
+	g.taps[out.ch] = out
+	g.sendChatHistory(out)
+	g.sendRestart(out)
+	g.sendState(types.Unknown)
+
+	g.log.Info("tap added", zap.Int("taps", len(g.taps)))
+	return out.ch
+}
+
+func (g *p2pgame) Untap(ch chan types.Update) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	out, ok := g.taps[ch]
+	if !ok {
+		return
+	}
+	out.close()
+	delete(g.taps, ch)
+
+	g.log.Info("tap removed", zap.Int("taps", len(g.taps)))
+}
+
+// restarting tells everyone in the game that the server is restarting by the deadline
+func (g *p2pgame) restarting(deadline time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.restart = &types.Restart{Deadline: deadline}
+	for _, p := range []*player{&g.left, &g.right} {
+		if p.out != nil {
+			g.sendRestart(p.out)
+		}
+	}
+	for _, out := range g.spectators {
+		g.sendRestart(out)
 	}
+	for _, out := range g.taps {
+		g.sendRestart(out)
+	}
+}
+
+// sendRestart sends the restart notice if the server is restarting, must be called with the lock held
+func (g *p2pgame) sendRestart(out *outbox) {
+	if g.restart != nil {
+		restart := *g.restart
+		out.send(types.Update{Restart: &restart})
+	}
+}
+
+// playing tells if a round has been started and isn't over yet, or isn't stored yet
+func (g *p2pgame) playing() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.left.Choice != types.Undefined || g.right.Choice != types.Undefined || g.saving > 0
+}
+
+// saved tells that the round or the match counted in saving is stored
+func (g *p2pgame) saved() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.saving--
+}
+
+func (g *p2pgame) End() {
+	g.log.Info("ending game")
+	g.cancel()
+}
+
+func tokensEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
 }
 
-func (g *p2pgame) Choice(choice types.Choice, rightSide bool) {
+func (g *p2pgame) Choice(choice types.Choice, rightSide bool) error {
+	if !g.settings.Ruleset.Allows(choice) {
+		return types.ErrBadChoice
+	}
 	go g.ping()
 
 	g.log.Info("User choice", zap.Bool("side", rightSide), zap.Any("choice", choice))
 	res := types.Unknown
+	var records []types.GameRecord
+	var match *types.MatchResult
+
+	defer func() {
+		if records == nil {
+			return
+		}
+		defer g.saved()
+		for _, record := range records {
+			g.saveRecord(record)
+		}
+		g.rateMatch(match)
+	}()
 
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
+	// only the round being played may be finished while restarting
+	if g.restart != nil && g.left.Choice == types.Undefined && g.right.Choice == types.Undefined {
+		return types.ErrShuttingDown
+	}
+	if g.score.Over {
+		// a rematch
+		g.score = types.NewMatchScore(g.settings.BestOf)
+		g.match++
+	}
 	if rightSide {
 		g.right.Choice = choice
 	} else {
 		g.left.Choice = choice
 	}
+	g.active = time.Now()
 	if g.left.Choice != types.Undefined && g.right.Choice != types.Undefined {
 		res = game.GameResult(g.left.Choice, g.right.Choice)
+		g.score.Count(res)
+		if g.score.Over {
+			match = g.matchResult("")
+		}
 	}
 	g.sendState(res)
+	g.changed()
 	if res != types.Unknown {
+		records = g.roundRecords(res)
+		g.saving++
 		g.left.Choice = types.Undefined
 		g.right.Choice = types.Undefined
+		if !g.score.Over {
+			g.score.Round++
+		}
+	}
+	return nil
+}
+
+func (g *p2pgame) Heartbeat(rightSide bool) {
+	go g.ping()
+}
+
+func (g *p2pgame) Score() types.MatchScore {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.score
+}
+
+// records of the finished round, one for each side, the right one is the mirror
+func (g *p2pgame) roundRecords(res types.Result) []types.GameRecord {
+	now := time.Now()
+	return []types.GameRecord{
+		{
+			Owner:          g.left.Owner,
+			Time:           now,
+			Mode:           types.ModeP2P,
+			GameID:         g.ID,
+			PlayerName:     g.left.Name,
+			OpponentName:   g.right.Name,
+			PlayerChoice:   g.left.Choice,
+			OpponentChoice: g.right.Choice,
+			Result:         res,
+		},
+		{
+			Owner:          g.right.Owner,
+			Time:           now,
+			Mode:           types.ModeP2P,
+			GameID:         g.ID,
+			PlayerName:     g.right.Name,
+			OpponentName:   g.left.Name,
+			PlayerChoice:   g.right.Choice,
+			OpponentChoice: g.left.Choice,
+			Result:         res.Swap(),
+			Mirror:         true,
+		},
+	}
+}
+
+func sideOf(rightSide bool) string {
+	if rightSide {
+		return types.SideRight
+	}
+	return types.SideLeft
+}
+
+// matchResult returns the result of the current match if it's to be rated: the players are different
+// registered users and they have played a round. An unfinished match is lost by the side which has left it.
+// Must be called with the lock held.
+func (g *p2pgame) matchResult(leaver string) *types.MatchResult {
+	left, right := g.left.Owner, g.right.Owner
+	played := g.score.Left != types.Tally{}
+	if g.factory.ratings == nil || !played || !left.IsUser() || !right.IsUser() || left == right {
+		return nil
+	}
+	match := &types.MatchResult{
+		ID:     fmt.Sprintf("%s-%d", g.ID, g.match),
+		Time:   time.Now(),
+		Left:   left,
+		Right:  right,
+		Winner: g.score.Winner,
+	}
+	if leaver != "" && g.score.BestOf > 0 {
+		match.Winner = types.SideLeft
+		if leaver == types.SideLeft {
+			match.Winner = types.SideRight
+		}
+	}
+	return match
+}
+
+// storeCtx is the context of storing the game's records, which are kept when the game is stopped after the round
+func (g *p2pgame) storeCtx() context.Context {
+	return context.WithoutCancel(g.ctx)
+}
+
+func (g *p2pgame) rateMatch(match *types.MatchResult) {
+	if match == nil {
+		return
+	}
+	if err := g.factory.ratings.RateMatch(g.storeCtx(), *match); err != nil {
+		g.log.Error("Failed to rate match", zap.String("match", match.ID), zap.Error(err))
+	}
+}
+
+func (g *p2pgame) saveRecord(record types.GameRecord) {
+	if g.factory.storage == nil {
+		return
+	}
+	if err := g.factory.storage.AddRecord(g.storeCtx(), record); err != nil {
+		g.log.Error("Failed to save game record", zap.Error(err))
+	}
+}
+
+func (g *p2pgame) recordEvent(eventType types.EventType, name string, owner types.Owner) {
+	if g.factory.events == nil {
+		return
+	}
+	err := g.factory.events.RecordEvent(g.storeCtx(), types.Event{
+		Type:       eventType,
+		Time:       time.Now(),
+		GameID:     g.ID,
+		PlayerName: name,
+		Owner:      owner,
+	})
+	if err != nil {
+		g.log.Error("Failed to record event", zap.Stringer("event", eventType), zap.Error(err))
+	}
+}
+
+// disconnect closes the connections of everyone in the finished game
+func (g *p2pgame) disconnect() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// the restart notice is delivered first
+	closeOut := (*outbox).close
+	if g.restart != nil {
+		closeOut = func(out *outbox) { out.finish(restartFlush) }
+	}
+	for _, p := range []*player{&g.left, &g.right} {
+		if p.detached != nil {
+			p.detached.Stop()
+			p.detached = nil
+		}
+		if p.out != nil {
+			closeOut(p.out)
+			p.out = nil
+		}
+	}
+	for ch, out := range g.spectators {
+		closeOut(out)
+		delete(g.spectators, ch)
+	}
+	for ch, out := range g.taps {
+		closeOut(out)
+		delete(g.taps, ch)
 	}
 }
 
 func (g *p2pgame) run() {
+	defer close(g.done)
 	defer g.log.Info("p2p game finished")
-	defer g.factory.removeGame(g.ID)
+	defer g.factory.removeGame(g)
 	defer g.cancel()
-	defer close(g.pingChannel)
+	defer g.disconnect()
+	defer g.persist()
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -303,12 +1206,26 @@ func (g *p2pgame) run() {
 
 	g.log.Info("p2p game started")
 
-	timer := time.NewTimer(gameExistence)
+	g.mu.RLock()
+	timer := time.NewTimer(time.Until(g.expires))
+	g.mu.RUnlock()
+	// the pending save, nil if nothing has changed since the last one
+	var save <-chan time.Time
 
 	for {
 		select {
 		case <-g.pingChannel:
+			g.mu.Lock()
+			g.expires = time.Now().Add(gameExistence)
+			g.mu.Unlock()
 			timer.Reset(gameExistence)
+		case <-g.changes:
+			if save == nil {
+				save = time.After(g.factory.saveDelay)
+			}
+		case <-save:
+			save = nil
+			g.save()
 		case <-timer.C:
 			return
 		case <-g.ctx.Done():
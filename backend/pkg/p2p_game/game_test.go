@@ -0,0 +1,912 @@
+package p2pgame
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg"
+	"github.com/complynx/rpssl4bu/backend/pkg/random"
+	"github.com/complynx/rpssl4bu/backend/pkg/storage"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// waitFor reads updates from the channel until a state matches
+func waitFor(t *testing.T, ch <-chan types.Update, match func(types.Message) bool) types.Message {
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case update, ok := <-ch:
+			require.True(t, ok, "channel is open")
+			if update.State != nil && match(*update.State) {
+				return *update.State
+			}
+		case <-timeout:
+			t.Fatal("no matching state")
+		}
+	}
+}
+
+// waitChat reads updates from the channel until a chat message with the ID
+func waitChat(t *testing.T, ch <-chan types.Update, id uint64) types.ChatMessage {
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case update, ok := <-ch:
+			require.True(t, ok, "channel is open")
+			if update.Chat != nil && update.Chat.ID == id {
+				return *update.Chat
+			}
+		case <-timeout:
+			t.Fatal("no chat message")
+		}
+	}
+}
+
+// waitClosed drains the channel until it's closed
+func waitClosed(t *testing.T, ch <-chan types.Update) {
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("channel is not closed")
+		}
+	}
+}
+
+func TestResume(t *testing.T) {
+	gf := NewGameFactory(random.NewSimpleRandom(""), storage.NewSimple(10), zap.NewNop())
+	gf.(*gameFactory).grace = 50 * time.Millisecond
+	defer gf.StopGames(context.Background())
+	game, err := gf.CreateGame(context.Background(), types.GameSettings{}, types.GameAccess{})
+	require.NoError(t, err)
+
+	left, leftCh, leftToken, err := game.AddPlayer("Sheldon", types.Global)
+	require.NoError(t, err)
+	assert.False(t, left)
+	right, rightCh, rightToken, err := game.AddPlayer("Penny", types.Global)
+	require.NoError(t, err)
+	assert.True(t, right)
+	assert.NotEqual(t, leftToken, rightToken)
+	assert.Len(t, leftToken, 32)
+
+	// the seat is held after the connection is lost
+	game.DetachPlayer(leftCh)
+	waitClosed(t, leftCh)
+	assert.True(t, game.IsFull(context.Background()))
+	waitFor(t, rightCh, func(m types.Message) bool { return m.LeftPlayerDisconnected })
+	game.Choice(types.Rock, true)
+	waitFor(t, rightCh, func(m types.Message) bool { return m.RightPlayerChoice == types.Rock })
+
+	_, _, err = game.ResumePlayer("nope")
+	assert.ErrorIs(t, err, ErrBadToken)
+
+	// and the latest state is replayed on resume
+	side, leftCh, err := game.ResumePlayer(leftToken)
+	require.NoError(t, err)
+	assert.False(t, side)
+	msg := waitFor(t, leftCh, func(types.Message) bool { return true })
+	assert.Equal(t, types.Message{
+		LeftPlayerName:  "Sheldon",
+		RightPlayerName: "Penny",
+		Result:          types.Unknown,
+		Score:           types.NewMatchScore(0),
+		Settings:        types.GameSettings{Ruleset: types.RulesetRPSSL},
+	}, msg)
+
+	waitFor(t, rightCh, func(m types.Message) bool { return !m.LeftPlayerDisconnected })
+
+	// the round goes on
+	game.Choice(types.Paper, false)
+	waitFor(t, leftCh, func(m types.Message) bool { return m.Result == types.Win })
+
+	// resuming replaces the connection which is still there
+	_, newCh, err := game.ResumePlayer(leftToken)
+	require.NoError(t, err)
+	waitClosed(t, leftCh)
+	game.DetachPlayer(leftCh)
+	assert.True(t, game.IsFull(context.Background()))
+
+	// the player is removed after the grace period
+	game.DetachPlayer(newCh)
+	waitFor(t, rightCh, func(m types.Message) bool { return m.LeftPlayerName == "" })
+	assert.False(t, game.IsFull(context.Background()))
+	_, _, err = game.ResumePlayer(leftToken)
+	assert.ErrorIs(t, err, ErrBadToken)
+}
+
+func TestSpectators(t *testing.T) {
+	gf := NewGameFactory(random.NewSimpleRandom(""), storage.NewSimple(10), zap.NewNop())
+	gf.(*gameFactory).maxSpectators = 2
+	defer gf.StopGames(context.Background())
+	game, err := gf.CreateGame(context.Background(), types.GameSettings{}, types.GameAccess{})
+	require.NoError(t, err)
+
+	_, leftCh, _, err := game.AddPlayer("Sheldon", types.Global)
+	require.NoError(t, err)
+	watchCh, err := game.AddSpectator()
+	require.NoError(t, err)
+	waitFor(t, watchCh, func(m types.Message) bool { return m.LeftPlayerName == "Sheldon" })
+
+	// players know they are watched
+	otherCh, err := game.AddSpectator()
+	require.NoError(t, err)
+	waitFor(t, leftCh, func(m types.Message) bool { return m.Spectators == 2 })
+	_, err = game.AddSpectator()
+	assert.ErrorIs(t, err, ErrTooManySpectators)
+	game.RemoveSpectator(otherCh)
+	waitClosed(t, otherCh)
+	waitFor(t, leftCh, func(m types.Message) bool { return m.Spectators == 1 })
+
+	_, _, _, err = game.AddPlayer("Penny", types.Global)
+	require.NoError(t, err)
+
+	// the choice is hidden until the round is over
+	game.Choice(types.Rock, false)
+	msg := waitFor(t, watchCh, func(m types.Message) bool { return m.RightPlayerName == "Penny" })
+	assert.Equal(t, types.Undefined, msg.LeftPlayerChoice)
+	msg = waitFor(t, leftCh, func(m types.Message) bool { return m.LeftPlayerChoice == types.Rock })
+	assert.Equal(t, 1, msg.Spectators)
+
+	game.Choice(types.Paper, true)
+	msg = waitFor(t, watchCh, func(m types.Message) bool { return m.Result != types.Unknown })
+	assert.Equal(t, types.Message{
+		LeftPlayerName:    "Sheldon",
+		RightPlayerName:   "Penny",
+		LeftPlayerChoice:  types.Rock,
+		RightPlayerChoice: types.Paper,
+		Result:            types.Lose,
+		Spectators:        1,
+		Score: types.MatchScore{
+			Round:  1,
+			Left:   types.Tally{Lose: 1},
+			Right:  types.Tally{Win: 1},
+			Winner: types.SideRight,
+		},
+		Settings: types.GameSettings{Ruleset: types.RulesetRPSSL},
+	}, msg)
+}
+
+func TestScore(t *testing.T) {
+	gf := NewGameFactory(random.NewSimpleRandom(""), storage.NewSimple(10), zap.NewNop())
+	defer gf.StopGames(context.Background())
+	game, err := gf.CreateGame(context.Background(), types.GameSettings{}, types.GameAccess{})
+	require.NoError(t, err)
+
+	_, leftCh, _, err := game.AddPlayer("Sheldon", types.Global)
+	require.NoError(t, err)
+	_, _, _, err = game.AddPlayer("Penny", types.Global)
+	require.NoError(t, err)
+	assert.Equal(t, types.NewMatchScore(0), game.Score())
+
+	game.Choice(types.Spock, false)
+	game.Choice(types.Scissors, true)
+	msg := waitFor(t, leftCh, func(m types.Message) bool { return m.Result != types.Unknown })
+	assert.Equal(t, types.MatchScore{
+		Round:  1,
+		Left:   types.Tally{Win: 1},
+		Right:  types.Tally{Lose: 1},
+		Winner: types.SideLeft,
+	}, msg.Score)
+
+	game.Choice(types.Rock, true)
+	msg = waitFor(t, leftCh, func(m types.Message) bool { return m.RightPlayerChoice == types.Undefined && m.Score.Round == 2 })
+	assert.Equal(t, types.Tally{Win: 1}, msg.Score.Left)
+	game.Choice(types.Rock, false)
+	msg = waitFor(t, leftCh, func(m types.Message) bool { return m.Result == types.Tie })
+	assert.Equal(t, 2, msg.Score.Round)
+	assert.Equal(t, types.Tally{Win: 1, Tie: 1}, game.Score().Left)
+	assert.Equal(t, 3, game.Score().Round)
+
+	// a new opponent starts a new match
+	game.RemovePlayer(true)
+	assert.Equal(t, types.NewMatchScore(0), game.Score())
+}
+
+func TestChat(t *testing.T) {
+	gf := NewGameFactory(random.NewSimpleRandom(""), storage.NewSimple(10), zap.NewNop())
+	defer gf.StopGames(context.Background())
+	game, err := gf.CreateGame(context.Background(), types.GameSettings{}, types.GameAccess{})
+	require.NoError(t, err)
+
+	_, leftCh, leftToken, err := game.AddPlayer("Sheldon", types.Global)
+	require.NoError(t, err)
+	assert.ErrorIs(t, game.Chat(true, "anyone?"), types.ErrNoPlayer)
+	_, rightCh, _, err := game.AddPlayer("Penny", types.Global)
+	require.NoError(t, err)
+	watchCh, err := game.AddSpectator()
+	require.NoError(t, err)
+
+	require.NoError(t, game.Chat(false, "  Bazinga, you shitheads!  "))
+	for _, ch := range []chan types.Update{leftCh, rightCh, watchCh} {
+		msg := waitChat(t, ch, 1)
+		assert.Equal(t, "Bazinga, you *********!", msg.Text)
+		assert.Equal(t, types.SideLeft, msg.Side)
+		assert.Equal(t, "Sheldon", msg.Name)
+	}
+	require.NoError(t, game.Emote(true, types.EmoteThumbsUp))
+	msg := waitChat(t, leftCh, 2)
+	assert.Equal(t, types.EmoteThumbsUp, msg.Emote)
+	assert.Equal(t, types.SideRight, msg.Side)
+
+	assert.ErrorIs(t, game.Chat(true, "   "), types.ErrBadChat)
+	assert.ErrorIs(t, game.Chat(true, strings.Repeat("a", maxChatLength+1)), types.ErrBadChat)
+	assert.ErrorIs(t, game.Emote(true, "dance"), types.ErrBadEmote)
+
+	// the history is sent on resume, newest messages only
+	for i := 0; i < chatHistory; i++ {
+		require.NoError(t, game.Chat(true, "hello"))
+	}
+	game.DetachPlayer(leftCh)
+	_, leftCh, err = game.ResumePlayer(leftToken)
+	require.NoError(t, err)
+	ids := map[uint64]bool{}
+	for len(ids) < chatHistory {
+		update := <-leftCh
+		if update.Chat != nil {
+			ids[update.Chat.ID] = true
+		}
+	}
+	assert.False(t, ids[2])
+	assert.True(t, ids[3])
+	assert.True(t, ids[chatHistory+2])
+}
+
+func TestCensor(t *testing.T) {
+	assert.Equal(t, "Hello, Shelly", censor("Hello, Shelly"))
+	assert.Equal(t, "**** it, ****", censor("Damn it, CRAP"))
+	assert.Equal(t, "a classic assessment", censor("a classic assessment"))
+}
+
+func TestGameStress(t *testing.T) {
+	gf := NewGameFactory(random.NewSimpleRandom(""), storage.NewSimple(10), zap.NewNop())
+	gf.(*gameFactory).grace = time.Minute
+	game, err := gf.CreateGame(context.Background(), types.GameSettings{}, types.GameAccess{})
+	require.NoError(t, err)
+
+	// read reads the channel until it's closed, the updates come in order
+	var readers sync.WaitGroup
+	read := func(ch chan types.Update) {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			var last uint64
+			for u := range ch {
+				assert.Greater(t, u.Seq, last)
+				last = u.Seq
+			}
+		}()
+	}
+
+	tokens := make([]string, 2)
+	for i, name := range []string{"Sheldon", "Penny"} {
+		_, ch, token, err := game.AddPlayer(name, types.Global)
+		require.NoError(t, err)
+		tokens[i] = token
+		read(ch)
+	}
+
+	var wg sync.WaitGroup
+	for side := range tokens {
+		rightSide := side == 1
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				game.Choice(types.Choice(i%5+1), rightSide)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				game.Chat(rightSide, "hello")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				_, ch, err := game.ResumePlayer(tokens[side])
+				require.NoError(t, err)
+				read(ch)
+				if i%2 == 0 {
+					game.DetachPlayer(ch)
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			ch, err := game.AddSpectator()
+			require.NoError(t, err)
+			read(ch)
+			game.RemoveSpectator(ch)
+		}
+	}()
+	wg.Wait()
+
+	// the connections are closed with the game
+	gf.StopGames(context.Background())
+	done := make(chan struct{})
+	go func() {
+		readers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("connections are left open")
+	}
+}
+
+func TestSettings(t *testing.T) {
+	gf := NewGameFactory(random.NewSimpleRandom(""), storage.NewSimple(10), zap.NewNop())
+	defer gf.StopGames(context.Background())
+	_, err := gf.CreateGame(context.Background(), types.GameSettings{BestOf: 2}, types.GameAccess{})
+	assert.Error(t, err)
+	game, err := gf.CreateGame(context.Background(), types.GameSettings{Ruleset: types.RulesetClassic, BestOf: 1}, types.GameAccess{})
+	require.NoError(t, err)
+
+	_, leftCh, _, err := game.AddPlayer("Sheldon", types.Global)
+	require.NoError(t, err)
+	_, _, _, err = game.AddPlayer("Penny", types.Global)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, game.Choice(types.Spock, false), types.ErrBadChoice)
+	require.NoError(t, game.Choice(types.Rock, false))
+	require.NoError(t, game.Choice(types.Scissors, true))
+	msg := waitFor(t, leftCh, func(m types.Message) bool { return m.Result != types.Unknown })
+	assert.Equal(t, types.GameSettings{Ruleset: types.RulesetClassic, BestOf: 1}, msg.Settings)
+	assert.True(t, msg.Score.Over)
+	assert.Equal(t, types.SideLeft, msg.Score.Winner)
+
+	// the next choice starts a rematch
+	require.NoError(t, game.Choice(types.Rock, false))
+	assert.Equal(t, types.NewMatchScore(1), game.Score())
+	require.NoError(t, game.Choice(types.Paper, true))
+	msg = waitFor(t, leftCh, func(m types.Message) bool { return m.Result != types.Unknown })
+	assert.True(t, msg.Score.Over)
+	assert.Equal(t, types.SideRight, msg.Score.Winner)
+}
+
+func TestRatings(t *testing.T) {
+	store := storage.NewSimple(10)
+	ratings := store.(pkg.RatingStorage)
+	gf := NewGameFactory(random.NewSimpleRandom(""), store, zap.NewNop(), WithRatings(ratings))
+	defer gf.StopGames(context.Background())
+	ctx := context.Background()
+	sheldon, penny, leonard := types.UserOwner("sheldon"), types.UserOwner("penny"), types.UserOwner("leonard")
+	rating := func(owner types.Owner) types.Rating {
+		r, err := ratings.GetRating(ctx, owner)
+		require.NoError(t, err)
+		return r
+	}
+
+	game, err := gf.CreateGame(ctx, types.GameSettings{BestOf: 3}, types.GameAccess{})
+	require.NoError(t, err)
+	_, _, _, err = game.AddPlayer("Sheldon", sheldon)
+	require.NoError(t, err)
+	_, _, _, err = game.AddPlayer("Penny", penny)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, game.Choice(types.Spock, false))
+		assert.Equal(t, 0, rating(sheldon).Games)
+		require.NoError(t, game.Choice(types.Rock, true))
+	}
+	assert.Equal(t, 1, rating(sheldon).Games)
+	assert.Greater(t, rating(sheldon).Rating, types.InitialRating)
+	assert.Less(t, rating(penny).Rating, types.InitialRating)
+
+	// the finished match is rated once
+	game.RemovePlayer(true)
+	assert.Equal(t, 1, rating(penny).Games)
+
+	// the one leaving an unfinished match loses it
+	_, _, _, err = game.AddPlayer("Leonard", leonard)
+	require.NoError(t, err)
+	game.RemovePlayer(true)
+	assert.Equal(t, 0, rating(leonard).Games, "no rounds played")
+	_, _, _, err = game.AddPlayer("Leonard", leonard)
+	require.NoError(t, err)
+	require.NoError(t, game.Choice(types.Spock, false))
+	require.NoError(t, game.Choice(types.Lizard, true))
+	game.RemovePlayer(true)
+	assert.Equal(t, 1, rating(leonard).Games)
+	assert.Less(t, rating(leonard).Rating, types.InitialRating)
+	assert.Equal(t, 2, rating(sheldon).Games)
+
+	// the anonymous players aren't rated
+	_, _, _, err = game.AddPlayer("Raj", types.SessionOwner("00000000000000000000000000000001"))
+	require.NoError(t, err)
+	require.NoError(t, game.Choice(types.Spock, false))
+	require.NoError(t, game.Choice(types.Lizard, true))
+	game.RemovePlayer(true)
+	assert.Equal(t, 2, rating(sheldon).Games)
+
+	// the match ended by the host isn't rated
+	before := rating(sheldon)
+	_, _, _, err = game.AddPlayer("Leonard", leonard)
+	require.NoError(t, err)
+	require.NoError(t, game.Choice(types.Spock, false))
+	require.NoError(t, game.Choice(types.Lizard, true))
+	require.NoError(t, game.Kick(true, false))
+	assert.Equal(t, before, rating(sheldon))
+	assert.Equal(t, 1, rating(leonard).Games)
+	_, _, _, err = game.AddPlayer("Leonard", leonard)
+	require.NoError(t, err)
+	require.NoError(t, game.Choice(types.Lizard, false))
+	require.NoError(t, game.Choice(types.Spock, true))
+	require.NoError(t, game.Kick(true, true))
+	assert.Equal(t, before, rating(sheldon))
+	assert.Equal(t, 1, rating(leonard).Games)
+}
+
+// waitSignal waits for the lobby to change
+func waitSignal(t *testing.T, ch <-chan struct{}) {
+	select {
+	case _, ok := <-ch:
+		require.True(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("no lobby change")
+	}
+}
+
+func TestLobby(t *testing.T) {
+	gf := NewGameFactory(random.NewSimpleRandom(""), storage.NewSimple(10), zap.NewNop())
+	defer gf.StopGames(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	changed := gf.WatchLobby(ctx)
+
+	_, err := gf.CreateGame(ctx, types.GameSettings{}, types.GameAccess{})
+	require.NoError(t, err)
+	assert.Empty(t, gf.Lobby())
+
+	settings := types.GameSettings{Ruleset: types.RulesetClassic, BestOf: 3, Public: true}
+	game, err := gf.CreateGame(ctx, settings, types.GameAccess{})
+	require.NoError(t, err)
+	waitSignal(t, changed)
+	other, err := gf.CreateGame(ctx, types.GameSettings{Public: true}, types.GameAccess{})
+	require.NoError(t, err)
+	waitSignal(t, changed)
+
+	_, _, _, err = game.AddPlayer("Sheldon", types.Global)
+	require.NoError(t, err)
+	waitSignal(t, changed)
+	_, err = game.AddSpectator()
+	require.NoError(t, err)
+	waitSignal(t, changed)
+
+	lobby := gf.Lobby()
+	require.Len(t, lobby, 2)
+	assert.Equal(t, other.GetID(), lobby[1].ID)
+	info := lobby[0]
+	assert.Equal(t, game.GetID(), info.ID)
+	assert.Equal(t, "Sheldon", info.Creator)
+	assert.Equal(t, settings, info.GameSettings)
+	assert.Equal(t, 1, info.Players)
+	assert.Equal(t, 1, info.Spectators)
+	assert.WithinDuration(t, time.Now(), info.Created, time.Second)
+
+	// the full games aren't open
+	_, _, _, err = game.AddPlayer("Penny", types.Global)
+	require.NoError(t, err)
+	waitSignal(t, changed)
+	lobby = gf.Lobby()
+	require.Len(t, lobby, 1)
+	assert.Equal(t, other.GetID(), lobby[0].ID)
+	game.RemovePlayer(false)
+	waitSignal(t, changed)
+	lobby = gf.Lobby()
+	require.Len(t, lobby, 2)
+	assert.Equal(t, "Sheldon", lobby[0].Creator)
+
+	// neither are the finished ones
+	other.(*p2pgame).cancel()
+	waitSignal(t, changed)
+	require.Eventually(t, func() bool { return len(gf.Lobby()) == 1 }, time.Second, time.Millisecond)
+
+	// the watch ends with the context
+	cancel()
+	for range changed {
+	}
+}
+
+func TestAccess(t *testing.T) {
+	gf := NewGameFactory(random.NewSimpleRandom(""), storage.NewSimple(10), zap.NewNop())
+	defer gf.StopGames(context.Background())
+	host := types.SessionOwner("host")
+	game, err := gf.CreateGame(context.Background(), types.GameSettings{Public: true},
+		types.GameAccess{Host: host, Password: "bazinga"})
+	require.NoError(t, err)
+
+	assert.Equal(t, host, game.Host())
+	assert.True(t, game.CheckPassword("bazinga"))
+	assert.False(t, game.CheckPassword(""))
+	assert.False(t, game.CheckPassword("Bazinga"))
+	require.Len(t, gf.Lobby(), 1)
+	assert.True(t, gf.Lobby()[0].Protected)
+
+	open, err := gf.CreateGame(context.Background(), types.GameSettings{}, types.GameAccess{})
+	require.NoError(t, err)
+	assert.Equal(t, types.Global, open.Host())
+	assert.True(t, open.CheckPassword(""))
+
+	// the invited player takes the right side first
+	penny := types.SessionOwner("penny")
+	right, _, err := game.AddPlayerOnSide("Penny", penny, true)
+	require.NoError(t, err)
+	_, _, err = game.AddPlayerOnSide("Howard", types.Global, true)
+	assert.ErrorIs(t, err, types.ErrSeatTaken)
+	side, left, _, err := game.AddPlayer("Sheldon", host)
+	require.NoError(t, err)
+	assert.False(t, side)
+
+	// the kicked player may come back unless banned
+	require.NoError(t, game.Kick(true, false))
+	waitClosed(t, right)
+	waitFor(t, left, func(msg types.Message) bool { return msg.RightPlayerName == "" })
+	side, _, _, err = game.AddPlayer("Penny", penny)
+	require.NoError(t, err)
+	assert.True(t, side)
+	require.NoError(t, game.Kick(true, true))
+	_, _, _, err = game.AddPlayer("Penny", penny)
+	assert.ErrorIs(t, err, types.ErrBanned)
+	assert.ErrorIs(t, game.Kick(true, false), types.ErrNoPlayer)
+
+	// anonymous players can't be banned
+	_, _, _, err = game.AddPlayer("Raj", types.Global)
+	require.NoError(t, err)
+	require.NoError(t, game.Kick(true, true))
+	_, _, _, err = game.AddPlayer("Raj", types.Global)
+	assert.NoError(t, err)
+}
+
+func TestTap(t *testing.T) {
+	gf := NewGameFactory(random.NewSimpleRandom(""), storage.NewSimple(10), zap.NewNop())
+	defer gf.StopGames(context.Background())
+	game, err := gf.CreateGame(context.Background(), types.GameSettings{}, types.GameAccess{})
+	require.NoError(t, err)
+	_, _, _, err = game.AddPlayer("Sheldon", types.Global)
+	require.NoError(t, err)
+	_, _, _, err = game.AddPlayer("Penny", types.Global)
+	require.NoError(t, err)
+
+	spectator, err := game.AddSpectator()
+	require.NoError(t, err)
+	tap := game.Tap()
+	require.NoError(t, game.Choice(types.Spock, true))
+	state := waitFor(t, tap, func(msg types.Message) bool { return msg.RightPlayerChoice == types.Spock })
+	assert.Equal(t, 1, state.Spectators, "the tap isn't a spectator")
+
+	require.NoError(t, game.Notice("Server restarts soon"))
+	assert.Equal(t, types.SideServer, waitChat(t, spectator, 1).Side)
+	assert.Equal(t, "Server restarts soon", waitChat(t, tap, 1).Text)
+	assert.ErrorIs(t, game.Notice(strings.Repeat("a", maxChatLength+1)), types.ErrBadChat)
+	n, err := gf.Notice("Bye")
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	status := game.Status()
+	require.NotNil(t, status.Right)
+	assert.Equal(t, "Penny", status.Right.Name)
+	assert.Equal(t, 1, status.Spectators)
+
+	game.Untap(tap)
+	waitClosed(t, tap)
+	game.End()
+	waitClosed(t, spectator)
+}
+
+func TestDrain(t *testing.T) {
+	gf := NewGameFactory(random.NewSimpleRandom(""), storage.NewSimple(10), zap.NewNop())
+	game, err := gf.CreateGame(context.Background(), types.GameSettings{}, types.GameAccess{})
+	require.NoError(t, err)
+	_, left, _, err := game.AddPlayer("Sheldon", types.Global)
+	require.NoError(t, err)
+	_, right, _, err := game.AddPlayer("Penny", types.Global)
+	require.NoError(t, err)
+	spectator, err := game.AddSpectator()
+	require.NoError(t, err)
+	require.NoError(t, game.Choice(types.Rock, false))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	drained := make(chan struct{})
+	go func() {
+		gf.Drain(ctx)
+		close(drained)
+	}()
+	for _, ch := range []chan types.Update{left, right, spectator} {
+		restart := waitRestart(t, ch)
+		deadline, _ := ctx.Deadline()
+		assert.Equal(t, deadline, restart.Deadline)
+	}
+
+	_, err = gf.CreateGame(context.Background(), types.GameSettings{}, types.GameAccess{})
+	assert.ErrorIs(t, err, types.ErrShuttingDown)
+	game.RemovePlayer(true)
+	_, _, _, err = game.AddPlayer("Howard", types.Global)
+	assert.ErrorIs(t, err, types.ErrShuttingDown)
+	select {
+	case <-drained:
+		t.Fatal("drained during the round")
+	case <-time.After(2 * drainPoll):
+	}
+
+	// the round being played is over once it's left
+	game.RemovePlayer(false)
+	<-drained
+	waitClosed(t, spectator)
+	_, found := gf.GetGame(game.GetID())
+	assert.False(t, found)
+}
+
+// slowStorage stores the records once they are released, failing the ones whose context is done by then
+type slowStorage struct {
+	pkg.StorageV2
+	release chan struct{}
+}
+
+func (s *slowStorage) AddRecord(ctx context.Context, record types.GameRecord) error {
+	<-s.release
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.StorageV2.AddRecord(ctx, record)
+}
+
+func TestDrainSaving(t *testing.T) {
+	s := &slowStorage{StorageV2: storage.NewSimple(10), release: make(chan struct{})}
+	gf := NewGameFactory(random.NewSimpleRandom(""), s, zap.NewNop())
+	game, err := gf.CreateGame(context.Background(), types.GameSettings{}, types.GameAccess{})
+	require.NoError(t, err)
+	_, left, _, err := game.AddPlayer("Sheldon", types.Global)
+	require.NoError(t, err)
+	_, _, _, err = game.AddPlayer("Penny", types.Global)
+	require.NoError(t, err)
+	require.NoError(t, game.Choice(types.Rock, false))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	drained := make(chan struct{})
+	go func() {
+		gf.Drain(ctx)
+		close(drained)
+	}()
+	waitRestart(t, left)
+
+	// the last round is over, but not stored yet
+	go game.Choice(types.Paper, true)
+	waitFor(t, left, func(msg types.Message) bool { return msg.Result != types.Unknown })
+	select {
+	case <-drained:
+		t.Fatal("drained before the round was stored")
+	case <-time.After(2 * drainPoll):
+	}
+
+	// and it's stored even if the game is stopped meanwhile
+	game.End()
+	close(s.release)
+	<-drained
+	page, err := s.History(context.Background(), types.HistoryQuery{Limit: 10, Mirrors: true})
+	require.NoError(t, err)
+	assert.Len(t, page.Records, 2)
+}
+
+func TestDrainDeadline(t *testing.T) {
+	gf := NewGameFactory(random.NewSimpleRandom(""), storage.NewSimple(10), zap.NewNop())
+	games := make([]pkg.P2PGame, 2)
+	for i := range games {
+		game, err := gf.CreateGame(context.Background(), types.GameSettings{}, types.GameAccess{})
+		require.NoError(t, err)
+		_, _, _, err = game.AddPlayer("Sheldon", types.Global)
+		require.NoError(t, err)
+		_, _, _, err = game.AddPlayer("Penny", types.Global)
+		require.NoError(t, err)
+		games[i] = game
+	}
+	// the round of the first game never ends
+	require.NoError(t, games[0].Choice(types.Rock, false))
+	spectator, err := games[0].AddSpectator()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*drainPoll)
+	defer cancel()
+	drained := make(chan struct{})
+	go func() {
+		gf.Drain(ctx)
+		close(drained)
+	}()
+	waitRestart(t, spectator)
+	// no new rounds are started
+	assert.ErrorIs(t, games[1].Choice(types.Rock, false), types.ErrShuttingDown)
+	<-drained
+	assert.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+	waitClosed(t, spectator)
+}
+
+// waitRestart reads updates from the channel until the restart notice
+func waitRestart(t *testing.T, ch <-chan types.Update) types.Restart {
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case update, ok := <-ch:
+			require.True(t, ok, "channel is open")
+			if update.Restart != nil {
+				return *update.Restart
+			}
+		case <-timeout:
+			t.Fatal("no restart notice")
+		}
+	}
+}
+
+func TestRestore(t *testing.T) {
+	store := storage.NewSimple(10)
+	games := store.(pkg.GameStore)
+	ctx := context.Background()
+	sheldon, penny, howard := types.UserOwner("sheldon"), types.UserOwner("penny"), types.UserOwner("howard")
+
+	gf := NewGameFactory(random.NewSimpleRandom(""), store, zap.NewNop(), WithGameStore(games))
+	game, err := gf.CreateGame(ctx, types.GameSettings{BestOf: 3}, types.GameAccess{Host: sheldon, Password: "bazinga"})
+	require.NoError(t, err)
+	_, leftCh, leftToken, err := game.AddPlayer("Sheldon", sheldon)
+	require.NoError(t, err)
+	_, _, _, err = game.AddPlayer("Howard", howard)
+	require.NoError(t, err)
+	require.NoError(t, game.Kick(true, true))
+	_, _, rightToken, err := game.AddPlayer("Penny", penny)
+	require.NoError(t, err)
+	require.NoError(t, game.Chat(false, "Knock knock"))
+	require.NoError(t, game.Choice(types.Spock, false))
+	require.NoError(t, game.Choice(types.Rock, true))
+	// the second round is being played on shutdown
+	require.NoError(t, game.Choice(types.Rock, false))
+	waitFor(t, leftCh, func(m types.Message) bool { return m.LeftPlayerChoice == types.Rock })
+
+	gf.StopGames(ctx)
+	snapshots, err := games.LoadGames(ctx)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	expires := snapshots[0].Expires
+	// and the expired games are dropped on start
+	require.NoError(t, games.SaveGame(ctx, types.GameSnapshot{ID: game.GetID() + 1, Expires: time.Now()}))
+
+	gf = NewGameFactory(random.NewSimpleRandom(""), store, zap.NewNop(), WithGameStore(games))
+	restored, err := gf.Restore(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, restored)
+	_, found := gf.GetGame(game.GetID() + 1)
+	assert.False(t, found)
+	game, found = gf.GetGame(game.GetID())
+	require.True(t, found)
+	assert.True(t, game.(*p2pgame).expires.Equal(expires), "the expiry is kept")
+	assert.Equal(t, sheldon, game.Host())
+	assert.True(t, game.CheckPassword("bazinga"))
+	assert.False(t, game.CheckPassword("bazooka"))
+	_, _, err = game.AddPlayerOnSide("Howard", howard, true)
+	assert.ErrorIs(t, err, types.ErrBanned)
+
+	status := game.Status()
+	assert.Equal(t, "Sheldon", status.Creator)
+	require.NotNil(t, status.Right)
+	assert.True(t, status.Right.Disconnected)
+	assert.Equal(t, penny, status.Right.Owner)
+
+	// the players resume where they left off
+	_, leftCh, err = game.ResumePlayer(leftToken)
+	require.NoError(t, err)
+	assert.Equal(t, "Knock knock", waitChat(t, leftCh, 1).Text)
+	msg := waitFor(t, leftCh, func(types.Message) bool { return true })
+	assert.Equal(t, types.Rock, msg.LeftPlayerChoice)
+	assert.Equal(t, 2, msg.Score.Round)
+	assert.True(t, msg.RightPlayerDisconnected)
+	_, _, err = game.ResumePlayer(rightToken)
+	require.NoError(t, err)
+	require.NoError(t, game.Choice(types.Lizard, true))
+	msg = waitFor(t, leftCh, func(m types.Message) bool { return m.Result != types.Unknown })
+	assert.Equal(t, types.Win, msg.Result)
+	assert.True(t, msg.Score.Over)
+
+	// the ended game isn't restored anymore
+	game.End()
+	<-game.(*p2pgame).done
+	snapshots, err = games.LoadGames(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, snapshots)
+}
+
+func TestRestoreTwice(t *testing.T) {
+	store := storage.NewSimple(10)
+	games := store.(pkg.GameStore)
+	ctx := context.Background()
+
+	gf := NewGameFactory(random.NewSimpleRandom(""), store, zap.NewNop(), WithGameStore(games))
+	game, err := gf.CreateGame(ctx, types.GameSettings{}, types.GameAccess{})
+	require.NoError(t, err)
+	_, _, _, err = game.AddPlayer("Sheldon", types.UserOwner("sheldon"))
+	require.NoError(t, err)
+	gf.StopGames(ctx)
+
+	gf = NewGameFactory(random.NewSimpleRandom(""), store, zap.NewNop(), WithGameStore(games))
+	gf.(*gameFactory).grace = 50 * time.Millisecond
+	restored, err := gf.Restore(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, restored)
+	// the game which is there already is kept as it is
+	restored, err = gf.Restore(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, restored)
+
+	game, found := gf.GetGame(game.GetID())
+	require.True(t, found)
+	require.NotNil(t, game.Status().Left)
+	// the seat is held from the start of the restored game
+	assert.Eventually(t, func() bool { return game.Status().Left == nil }, time.Second, 10*time.Millisecond)
+	game.End()
+}
+
+// countingStore counts the saved snapshots
+type countingStore struct {
+	pkg.GameStore
+	mu    sync.Mutex
+	saves int
+}
+
+func (s *countingStore) SaveGame(ctx context.Context, snapshot types.GameSnapshot) error {
+	s.mu.Lock()
+	s.saves++
+	s.mu.Unlock()
+	return s.GameStore.SaveGame(ctx, snapshot)
+}
+
+func (s *countingStore) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saves
+}
+
+func TestSaveSnapshots(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewSimple(10)
+	games := &countingStore{GameStore: store.(pkg.GameStore)}
+	gf := NewGameFactory(random.NewSimpleRandom(""), store, zap.NewNop(), WithGameStore(games))
+	gf.(*gameFactory).saveDelay = 20 * time.Millisecond
+	game, err := gf.CreateGame(ctx, types.GameSettings{}, types.GameAccess{})
+	require.NoError(t, err)
+	defer game.End()
+
+	// the changes are saved together
+	_, _, token, err := game.AddPlayer("Sheldon", types.UserOwner("sheldon"))
+	require.NoError(t, err)
+	for i := 0; i < savedChat+5; i++ {
+		require.NoError(t, game.Chat(false, "Bazinga"))
+	}
+	assert.Eventually(t, func() bool { return games.count() == 1 }, time.Second, 5*time.Millisecond)
+
+	// the spectators aren't saved
+	_, err = game.AddSpectator()
+	require.NoError(t, err)
+	time.Sleep(3 * gf.(*gameFactory).saveDelay)
+	assert.Equal(t, 1, games.count())
+
+	snapshots, err := games.LoadGames(ctx)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	assert.Len(t, snapshots[0].Chat, savedChat)
+	assert.Equal(t, uint64(savedChat+5), snapshots[0].Chat[savedChat-1].ID)
+	require.NotNil(t, snapshots[0].Left)
+	assert.NotEqual(t, token, snapshots[0].Left.TokenHash, "the token isn't stored")
+	assert.Equal(t, hashToken(token), snapshots[0].Left.TokenHash)
+}
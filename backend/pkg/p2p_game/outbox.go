@@ -0,0 +1,143 @@
+package p2pgame
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+)
+
+// outboxSize is the number of updates waiting for a slow connection before they are coalesced
+const outboxSize = 64
+
+// outbox delivers the updates to a connection in order, from a queue of up to outboxSize.
+// When the queue overflows, the states in it are coalesced to the latest one, as it's
+// all the connection needs, and then the oldest chat messages are dropped.
+// Its channel is closed after the outbox is, the undelivered updates are dropped unless it's finished.
+type outbox struct {
+	ch       chan types.Update
+	wake     chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	mu     sync.Mutex
+	queue  []types.Update
+	seq    uint64
+	closed bool
+}
+
+func newOutbox() *outbox {
+	o := &outbox{
+		ch:   make(chan types.Update),
+		wake: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+	}
+	go o.run()
+	return o
+}
+
+// send queues the update with the next sequence number, it's dropped after close
+func (o *outbox) send(update types.Update) {
+	o.mu.Lock()
+	if o.closed {
+		o.mu.Unlock()
+		return
+	}
+	o.seq++
+	update.Seq = o.seq
+	o.queue = append(o.queue, update)
+	if len(o.queue) > outboxSize {
+		o.queue = coalesce(o.queue)
+	}
+	o.mu.Unlock()
+
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// coalesce keeps only the latest state in the queue and as many of the newest chat messages as fit,
+// the other updates are kept, the slots left behind are cleared
+func coalesce(queue []types.Update) []types.Update {
+	latest := -1
+	for i := range queue {
+		if queue[i].State != nil {
+			latest = i
+		}
+	}
+	kept := queue[:0]
+	for i, u := range queue {
+		if u.State == nil || i == latest {
+			kept = append(kept, u)
+		}
+	}
+	for len(kept) > outboxSize {
+		drop := slices.IndexFunc(kept, func(u types.Update) bool { return u.Chat != nil })
+		if drop < 0 {
+			break
+		}
+		kept = append(kept[:drop], kept[drop+1:]...)
+	}
+	clear(queue[len(kept):])
+	return kept
+}
+
+// run delivers the queue until the outbox is closed
+func (o *outbox) run() {
+	defer close(o.ch)
+
+	for {
+		o.mu.Lock()
+		if len(o.queue) == 0 {
+			closed := o.closed
+			o.mu.Unlock()
+			if closed {
+				return
+			}
+			select {
+			case <-o.wake:
+				continue
+			case <-o.stop:
+				return
+			}
+		}
+		// the delivered updates aren't kept by the queue
+		update := o.queue[0]
+		o.queue[0] = types.Update{}
+		o.queue = o.queue[1:]
+		if len(o.queue) == 0 {
+			o.queue = nil
+		}
+		o.mu.Unlock()
+
+		select {
+		case o.ch <- update:
+		case <-o.stop:
+			return
+		}
+	}
+}
+
+func (o *outbox) close() {
+	o.mu.Lock()
+	o.closed = true
+	o.queue = nil
+	o.mu.Unlock()
+
+	o.stopOnce.Do(func() { close(o.stop) })
+}
+
+// finish closes the outbox once the queued updates are delivered, or after the timeout
+func (o *outbox) finish(timeout time.Duration) {
+	o.mu.Lock()
+	o.closed = true
+	o.mu.Unlock()
+
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+	time.AfterFunc(timeout, o.close)
+}
@@ -0,0 +1,178 @@
+package p2pgame
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func state(round int) types.Update {
+	return types.Update{State: &types.Message{Score: types.MatchScore{Round: round}}}
+}
+
+func chat(id uint64) types.Update {
+	return types.Update{Chat: &types.ChatMessage{ID: id}}
+}
+
+func TestOutboxOrder(t *testing.T) {
+	o := newOutbox()
+	defer o.close()
+
+	const n = 10000
+	go func() {
+		for i := 1; i <= n; i++ {
+			o.send(state(i))
+		}
+	}()
+
+	// a fast reader gets everything, a slow one may miss the coalesced states
+	var last uint64
+	round := 0
+	for round < n {
+		u := <-o.ch
+		assert.Greater(t, u.Seq, last)
+		assert.Greater(t, u.State.Score.Round, round)
+		last, round = u.Seq, u.State.Score.Round
+	}
+}
+
+func TestOutboxCoalesce(t *testing.T) {
+	o := newOutbox()
+	defer o.close()
+
+	// nobody reads while the queue overflows
+	for i := 1; i <= 500; i++ {
+		o.send(state(i))
+		if i%50 == 0 {
+			o.send(chat(uint64(i / 50)))
+		}
+	}
+	o.send(chat(11))
+
+	var got []types.Update
+	for len(got) == 0 || got[len(got)-1].Chat == nil || got[len(got)-1].Chat.ID != 11 {
+		got = append(got, <-o.ch)
+	}
+	// one update may be delivering while the rest are queued
+	assert.LessOrEqual(t, len(got), outboxSize+1)
+
+	var chats []uint64
+	round := 0
+	for i, u := range got {
+		if i > 0 {
+			assert.Greater(t, u.Seq, got[i-1].Seq)
+		}
+		if u.Chat != nil {
+			chats = append(chats, u.Chat.ID)
+		} else {
+			round = u.State.Score.Round
+		}
+	}
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}, chats, "chat messages are not coalesced")
+	assert.Equal(t, 500, round, "the latest state is kept")
+}
+
+func TestOutboxDropsOldestChat(t *testing.T) {
+	o := newOutbox()
+	defer o.close()
+
+	for i := 1; i <= 3*outboxSize; i++ {
+		o.send(chat(uint64(i)))
+	}
+
+	var ids []uint64
+	for len(ids) == 0 || ids[len(ids)-1] != 3*outboxSize {
+		ids = append(ids, (<-o.ch).Chat.ID)
+	}
+	assert.LessOrEqual(t, len(ids), outboxSize+1)
+	assert.Equal(t, uint64(2*outboxSize+1), ids[len(ids)-outboxSize], "the newest messages are kept")
+}
+
+func TestOutboxKeepsRestart(t *testing.T) {
+	queue := []types.Update{{Restart: &types.Restart{}}}
+	for i := 1; i <= 2*outboxSize; i++ {
+		queue = append(queue, chat(uint64(i)))
+	}
+	kept := coalesce(queue)
+	assert.Len(t, kept, outboxSize)
+	assert.NotNil(t, kept[0].Restart, "only chat messages are dropped")
+	for _, u := range queue[len(kept):] {
+		assert.Equal(t, types.Update{}, u, "the dropped updates aren't kept")
+	}
+}
+
+func TestOutboxDrained(t *testing.T) {
+	o := newOutbox()
+	defer o.close()
+
+	for i := 1; i <= 3; i++ {
+		o.send(state(i))
+	}
+	for i := 1; i <= 3; i++ {
+		assert.Equal(t, i, (<-o.ch).State.Score.Round)
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	assert.Nil(t, o.queue, "the delivered updates are released")
+}
+
+func TestOutboxClose(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		o := newOutbox()
+		var wg sync.WaitGroup
+		for j := 0; j < 4; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for k := 0; k < 100; k++ {
+					o.send(state(k))
+				}
+			}()
+		}
+		o.send(state(0))
+
+		// the channel is closed however many updates are left
+		timeout := time.After(time.Second)
+	drain:
+		for {
+			select {
+			case _, ok := <-o.ch:
+				if !ok {
+					break drain
+				}
+				o.close()
+			case <-timeout:
+				t.Fatal("channel is not closed")
+			}
+		}
+		wg.Wait()
+		o.send(state(1))
+		o.close()
+	}
+}
+
+func TestOutboxFinish(t *testing.T) {
+	o := newOutbox()
+	for i := 1; i <= 3; i++ {
+		o.send(chat(uint64(i)))
+	}
+	o.finish(time.Second)
+	o.send(chat(4))
+
+	var ids []uint64
+	for u := range o.ch {
+		ids = append(ids, u.Chat.ID)
+	}
+	assert.Equal(t, []uint64{1, 2, 3}, ids, "the queued updates are delivered")
+
+	// unless nobody reads them in time
+	o = newOutbox()
+	o.send(chat(1))
+	o.finish(10 * time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	_, ok := <-o.ch
+	assert.False(t, ok)
+}
@@ -0,0 +1,186 @@
+package p2pgame
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"go.uber.org/zap"
+)
+
+const (
+	// saveDelay is how long the changes of a game are gathered before its snapshot is saved
+	saveDelay = time.Second
+	// savedChat is the number of the newest chat messages kept in the snapshot
+	savedChat = 10
+)
+
+// changed signals the runner to save the snapshot, the changes until it's saved are saved together,
+// must be called with the lock held
+func (g *p2pgame) changed() {
+	if g.factory.store == nil {
+		return
+	}
+	select {
+	case g.changes <- struct{}{}:
+	default:
+	}
+}
+
+// save stores the snapshot of the game, it's called by the runner only so that the snapshots are stored in order
+func (g *p2pgame) save() {
+	if g.factory.store == nil {
+		return
+	}
+	g.mu.RLock()
+	snapshot := g.snapshot()
+	g.mu.RUnlock()
+
+	// the game may be stopped already
+	if err := g.factory.store.SaveGame(context.Background(), snapshot); err != nil {
+		g.log.Error("Failed to save game", zap.Error(err))
+	}
+}
+
+// persist saves the final snapshot of the game stopped by the shutdown, or removes the one of the ended game
+func (g *p2pgame) persist() {
+	if g.factory.store == nil {
+		return
+	}
+	g.mu.RLock()
+	keep := g.keep
+	g.mu.RUnlock()
+
+	if keep {
+		g.save()
+		return
+	}
+	if err := g.factory.store.DeleteGame(context.Background(), g.ID); err != nil {
+		g.log.Error("Failed to delete game", zap.Error(err))
+	}
+}
+
+// snapshot returns the state of the game to restore it, must be called with the lock held
+func (g *p2pgame) snapshot() types.GameSnapshot {
+	banned := make([]types.Owner, 0, len(g.banned))
+	for owner := range g.banned {
+		banned = append(banned, owner)
+	}
+	slices.Sort(banned)
+	return types.GameSnapshot{
+		ID:           g.ID,
+		Settings:     g.settings,
+		Created:      g.created,
+		Active:       g.active,
+		Expires:      g.expires,
+		Creator:      g.creator,
+		Host:         g.host,
+		PasswordHash: g.password,
+		Banned:       banned,
+		Left:         g.left.snapshot(),
+		Right:        g.right.snapshot(),
+		Score:        g.score,
+		Match:        g.match,
+		Chat:         slices.Clone(g.chat[max(len(g.chat)-savedChat, 0):]),
+		LastChatID:   g.lastChatID,
+	}
+}
+
+// snapshot returns the seated player, nil for a free seat
+func (p *player) snapshot() *types.SeatSnapshot {
+	if p.Name == "" {
+		return nil
+	}
+	return &types.SeatSnapshot{
+		Name:      p.Name,
+		Owner:     p.Owner,
+		TokenHash: p.TokenHash,
+		Choice:    p.Choice,
+	}
+}
+
+func (gf *gameFactory) Restore(ctx context.Context) (int, error) {
+	if gf.store == nil {
+		return 0, nil
+	}
+	snapshots, err := gf.store.LoadGames(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("load games: %w", err)
+	}
+
+	now := time.Now()
+	restored := 0
+	for _, snapshot := range snapshots {
+		log := gf.log.With(zap.String("game_id", snapshot.ID.String()))
+		if !snapshot.Expires.After(now) {
+			log.Info("dropping expired game", zap.Time("expires", snapshot.Expires))
+			if err := gf.store.DeleteGame(ctx, snapshot.ID); err != nil {
+				log.Error("Failed to delete game", zap.Error(err))
+			}
+			continue
+		}
+
+		// a bad snapshot doesn't keep the others from being restored
+		g := gf.restoreGame(snapshot)
+		added, err := gf.setGameIfNotExist(g)
+		if err != nil {
+			log.Error("Failed to restore game", zap.Error(err))
+			continue
+		}
+		if !added {
+			log.Warn("game exists already")
+			continue
+		}
+		if err := g.Start(ctx); err != nil {
+			gf.removeGame(g)
+			log.Error("Failed to start game", zap.Error(err))
+			continue
+		}
+		g.lobbyChanged()
+		restored++
+	}
+	gf.log.Info("games restored", zap.Int("games", restored), zap.Int("snapshots", len(snapshots)))
+	return restored, nil
+}
+
+// restoreGame rebuilds the game from the snapshot, the seated players have the grace period to resume once it's started
+func (gf *gameFactory) restoreGame(snapshot types.GameSnapshot) *p2pgame {
+	g := gf.newGame(snapshot.Settings, snapshot.Created)
+	g.ID = snapshot.ID
+	g.active = snapshot.Active
+	g.expires = snapshot.Expires
+	g.creator = snapshot.Creator
+	g.host = snapshot.Host
+	g.password = snapshot.PasswordHash
+	for _, owner := range snapshot.Banned {
+		g.banned[owner] = struct{}{}
+	}
+	g.score = snapshot.Score
+	g.match = snapshot.Match
+	g.chat = snapshot.Chat
+	g.lastChatID = snapshot.LastChatID
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, rightSide := range []bool{false, true} {
+		seat := snapshot.Left
+		if rightSide {
+			seat = snapshot.Right
+		}
+		if seat == nil {
+			continue
+		}
+		*g.seat(rightSide) = player{
+			Name:      seat.Name,
+			Owner:     seat.Owner,
+			TokenHash: seat.TokenHash,
+			Choice:    seat.Choice,
+		}
+	}
+	// the latest states for the players to resume with
+	g.sendState(types.Unknown)
+	return g
+}
@@ -60,3 +60,7 @@ func (p *provider) Rand(ctx context.Context) (number int, err error) {
 
 	return res.RandomNumber - 1, nil
 }
+
+func (p *provider) Source() string {
+	return p.addr
+}
@@ -18,3 +18,7 @@ func NewSimpleRandom(addr string) pkg.RandomProvider {
 func (p *simple) Rand(ctx context.Context) (int, error) {
 	return rand.Intn(100), nil
 }
+
+func (p *simple) Source() string {
+	return "internal"
+}
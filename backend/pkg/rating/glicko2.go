@@ -0,0 +1,107 @@
+// Package rating rates the players with the Glicko-2 system,
+// see http://www.glicko.net/glicko/glicko2.pdf.
+// Every match is a rating period of its own.
+package rating
+
+import (
+	"math"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+)
+
+const (
+	// scale converts the ratings to the Glicko-2 scale and back
+	scale = 173.7178
+	// tau constrains the change of the volatility
+	tau = 0.5
+	// epsilon is the precision the volatility is found with
+	epsilon = 0.000001
+)
+
+// outcome is a game against the opponent, the score is 1 for a win, 0.5 for a draw and 0 for a loss
+type outcome struct {
+	opponent types.Rating
+	score    float64
+}
+
+// Match returns the ratings of the players after the match won by the side, none for a draw.
+func Match(left, right types.Rating, winner string) (types.Rating, types.Rating) {
+	score := 0.5
+	switch winner {
+	case types.SideLeft:
+		score = 1
+	case types.SideRight:
+		score = 0
+	}
+	return rate(left, []outcome{{right, score}}), rate(right, []outcome{{left, 1 - score}})
+}
+
+func g(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+func expected(mu, muJ, phiJ float64) float64 {
+	return 1 / (1 + math.Exp(-g(phiJ)*(mu-muJ)))
+}
+
+// rate returns the rating of the player after the games of a rating period
+func rate(player types.Rating, games []outcome) types.Rating {
+	mu := (player.Rating - types.InitialRating) / scale
+	phi := player.Deviation / scale
+
+	var vInv, sum float64
+	for _, game := range games {
+		muJ := (game.opponent.Rating - types.InitialRating) / scale
+		phiJ := game.opponent.Deviation / scale
+		e := expected(mu, muJ, phiJ)
+		vInv += g(phiJ) * g(phiJ) * e * (1 - e)
+		sum += g(phiJ) * (game.score - e)
+	}
+	v := 1 / vInv
+	delta := v * sum
+
+	sigma := volatility(phi, player.Volatility, v, delta)
+	phiStar := math.Sqrt(phi*phi + sigma*sigma)
+	phi = 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	mu += phi * phi * sum
+
+	player.Rating = scale*mu + types.InitialRating
+	player.Deviation = math.Min(scale*phi, types.InitialDeviation)
+	player.Volatility = sigma
+	player.Games += len(games)
+	return player
+}
+
+// volatility finds the new volatility with the Illinois algorithm
+func volatility(phi, sigma, v, delta float64) float64 {
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + v + ex
+		return ex*(delta*delta-phi*phi-v-ex)/(2*d*d) - (x-a)/(tau*tau)
+	}
+
+	A := a
+	var B float64
+	if delta*delta > phi*phi+v {
+		B = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.
+		for f(a-k*tau) < 0 {
+			k++
+		}
+		B = a - k*tau
+	}
+	fA, fB := f(A), f(B)
+	for math.Abs(B-A) > epsilon {
+		C := A + (A-B)*fA/(fB-fA)
+		fC := f(C)
+		if fC*fB <= 0 {
+			A, fA = B, fB
+		} else {
+			fA /= 2
+		}
+		B, fB = C, fC
+	}
+	return math.Exp(A / 2)
+}
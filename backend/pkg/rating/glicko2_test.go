@@ -0,0 +1,46 @@
+package rating
+
+import (
+	"testing"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRate(t *testing.T) {
+	// the example from the Glicko-2 paper
+	player := types.Rating{Rating: 1500, Deviation: 200, Volatility: 0.06}
+	rated := rate(player, []outcome{
+		{types.Rating{Rating: 1400, Deviation: 30}, 1},
+		{types.Rating{Rating: 1550, Deviation: 100}, 0},
+		{types.Rating{Rating: 1700, Deviation: 300}, 0},
+	})
+	assert.InDelta(t, 1464.06, rated.Rating, 0.01)
+	assert.InDelta(t, 151.52, rated.Deviation, 0.01)
+	assert.InDelta(t, 0.05999, rated.Volatility, 0.00001)
+	assert.Equal(t, 3, rated.Games)
+}
+
+func TestMatch(t *testing.T) {
+	sheldon := types.NewRating(types.UserOwner("sheldon"))
+	leonard := types.NewRating(types.UserOwner("leonard"))
+
+	winner, loser := Match(sheldon, leonard, types.SideLeft)
+	assert.Greater(t, winner.Rating, types.InitialRating)
+	assert.Less(t, loser.Rating, types.InitialRating)
+	assert.InDelta(t, winner.Rating-types.InitialRating, types.InitialRating-loser.Rating, 0.001)
+	assert.Less(t, winner.Deviation, types.InitialDeviation)
+	assert.Equal(t, 1, winner.Games)
+	assert.Equal(t, "sheldon", winner.Player)
+
+	loser2, winner2 := Match(loser, winner, types.SideRight)
+	assert.Equal(t, 2, winner2.Games)
+	assert.Greater(t, winner2.Rating, winner.Rating)
+	assert.Less(t, loser2.Rating, loser.Rating)
+
+	// a draw between equals changes only the deviations
+	left, right := Match(sheldon, leonard, "")
+	assert.InDelta(t, types.InitialRating, left.Rating, 0.001)
+	assert.InDelta(t, types.InitialRating, right.Rating, 0.001)
+	assert.Less(t, left.Deviation, types.InitialDeviation)
+}
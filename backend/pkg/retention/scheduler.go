@@ -0,0 +1,90 @@
+package retention
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"go.uber.org/zap"
+)
+
+// lock is held by a running compaction, so that there is only one at a time
+// per process even if several schedulers share the storage.
+var lock sync.Mutex
+
+type scheduler struct {
+	compactor pkg.Compactor
+	policy    types.RetentionPolicy
+	interval  time.Duration
+	log       *zap.Logger
+	now       func() time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// StartScheduler compacts the storage by the policy right away and then every interval.
+func StartScheduler(compactor pkg.Compactor, policy types.RetentionPolicy, interval time.Duration, log *zap.Logger) pkg.Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &scheduler{
+		compactor: compactor,
+		policy:    policy,
+		interval:  interval,
+		log:       log,
+		now:       time.Now,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+	go s.loop(ctx)
+
+	log.Info("Retention scheduler started",
+		zap.Duration("max_age", policy.MaxAge),
+		zap.Int("max_per_owner", policy.MaxPerOwner),
+		zap.Duration("interval", interval),
+	)
+	return s
+}
+
+func (s *scheduler) loop(ctx context.Context) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.run(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run compacts the storage unless another compaction is already running
+func (s *scheduler) run(ctx context.Context) {
+	if !lock.TryLock() {
+		s.log.Debug("Compaction is already running, skipped")
+		return
+	}
+	defer lock.Unlock()
+
+	start := time.Now()
+	n, err := s.compactor.Compact(ctx, s.policy, s.now())
+	if err != nil {
+		s.log.Error("Compaction failed", zap.Error(err))
+		return
+	}
+	s.log.Info("Compaction finished", zap.Int("deleted", n), zap.Duration("took", time.Since(start)))
+}
+
+func (s *scheduler) Stop(ctx context.Context) {
+	s.cancel()
+	select {
+	case <-s.done:
+		s.log.Info("Retention scheduler stopped")
+	case <-ctx.Done():
+		s.log.Warn("Retention scheduler didn't stop in time", zap.Error(ctx.Err()))
+	}
+}
@@ -0,0 +1,62 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/mocks"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestScheduler(t *testing.T) {
+	compactor := mocks.NewCompactor(t)
+	defer compactor.AssertExpectations(t)
+	policy := types.RetentionPolicy{MaxAge: time.Hour, MaxPerOwner: 10}
+
+	ran := make(chan struct{}, 10)
+	compactor.EXPECT().Compact(mock.Anything, policy, mock.Anything).Run(
+		func(ctx context.Context, policy types.RetentionPolicy, now time.Time) { ran <- struct{}{} },
+	).Return(1, nil).Once()
+	compactor.EXPECT().Compact(mock.Anything, policy, mock.Anything).Run(
+		func(ctx context.Context, policy types.RetentionPolicy, now time.Time) { ran <- struct{}{} },
+	).Return(0, errors.New("test"))
+
+	s := StartScheduler(compactor, policy, 10*time.Millisecond, zap.NewNop())
+	// right away and then on the ticker, errors don't stop it
+	for i := 0; i < 3; i++ {
+		select {
+		case <-ran:
+		case <-time.After(time.Second):
+			t.Fatal("compaction didn't run")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	s.Stop(ctx)
+	assert.NoError(t, ctx.Err(), "stopped in time")
+	for len(ran) > 0 {
+		<-ran
+	}
+	time.Sleep(30 * time.Millisecond)
+	assert.Empty(t, ran, "no runs after stop")
+}
+
+func TestSchedulerLock(t *testing.T) {
+	// no expectations, the compaction is skipped while another one is running
+	compactor := mocks.NewCompactor(t)
+	s := &scheduler{
+		compactor: compactor,
+		log:       zap.NewNop(),
+		now:       time.Now,
+	}
+
+	lock.Lock()
+	s.run(context.Background())
+	lock.Unlock()
+}
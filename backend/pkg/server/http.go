@@ -14,8 +14,9 @@ type server struct {
 	log *zap.Logger
 }
 
-func StartHTTPServer(listen string, api pkg.GameAPI, log *zap.Logger) pkg.Server {
-	mux := setupRouter(api, log)
+// StartHTTPServer serves the API, the browsers send the cookies to it from the allowed origins only.
+func StartHTTPServer(listen string, api pkg.GameAPI, log *zap.Logger, allowedOrigins ...string) pkg.Server {
+	mux := setupRouter(api, log, allowedOrigins)
 	srv := &server{
 		srv: &http.Server{
 			Addr:    listen,
@@ -35,11 +36,11 @@ func StartHTTPServer(listen string, api pkg.GameAPI, log *zap.Logger) pkg.Server
 	return srv
 }
 
-func setupRouter(api pkg.GameAPI, log *zap.Logger) *chi.Mux {
+func setupRouter(api pkg.GameAPI, log *zap.Logger, allowedOrigins []string) *chi.Mux {
 	httpRouter := chi.NewMux()
 
 	httpRouter.Use(
-		WithAccessControlAllowOrigin(),
+		WithAccessControlAllowOrigin(allowedOrigins...),
 	)
 
 	httpRouter.HandleFunc("/choices", api.Choices)
@@ -47,9 +48,31 @@ func setupRouter(api pkg.GameAPI, log *zap.Logger) *chi.Mux {
 	httpRouter.HandleFunc("/play", api.Play)
 	httpRouter.HandleFunc("/get_scores", api.GetScores)
 	httpRouter.HandleFunc("/clear_scores", api.ClearScores)
+	httpRouter.HandleFunc("/scores/restore", api.RestoreScores)
+	httpRouter.HandleFunc("/scores/audit", api.Audit)
+	httpRouter.HandleFunc("/history", api.History)
+	httpRouter.HandleFunc("/stats", api.Stats)
+	httpRouter.HandleFunc("/leaderboard", api.Leaderboard)
+	httpRouter.HandleFunc("/scores/stream", api.StreamScores)
+	httpRouter.HandleFunc("/export", api.Export)
+	httpRouter.HandleFunc("/import", api.Import)
 	httpRouter.HandleFunc("/create_p2p", api.CreateP2P)
 	httpRouter.HandleFunc("/connect_p2p", api.ConnectP2P)
+	httpRouter.HandleFunc("/watch_p2p", api.WatchP2P)
 	httpRouter.HandleFunc("/find_p2p", api.FindP2PGame)
+	httpRouter.HandleFunc("/matchmake", api.Matchmake)
+	httpRouter.Get("/p2p/{id}/score", api.P2PScore)
+	httpRouter.Get("/p2p/games", api.P2PGames)
+	httpRouter.Post("/p2p/{id}/invite", api.Invite)
+	httpRouter.Post("/p2p/{id}/kick", api.Kick)
+	httpRouter.Get("/admin/games", api.AdminGames)
+	httpRouter.Get("/admin/games/{id}", api.AdminGame)
+	httpRouter.Post("/admin/games/{id}/end", api.AdminEndGame)
+	httpRouter.Post("/admin/games/{id}/kick", api.AdminKick)
+	httpRouter.Post("/admin/games/{id}/notice", api.AdminNotice)
+	httpRouter.Post("/admin/notice", api.AdminNotice)
+	httpRouter.HandleFunc("/admin/games/{id}/tap", api.AdminTap)
+	httpRouter.HandleFunc("/p2p/lobby", api.Lobby)
 
 	return httpRouter
 }
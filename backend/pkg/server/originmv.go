@@ -1,14 +1,26 @@
 package server
 
-import "net/http"
+import (
+	"net/http"
+	"slices"
+)
 
-func WithAccessControlAllowOrigin() func(http.Handler) http.Handler {
+// WithAccessControlAllowOrigin lets any origin call the API without credentials,
+// the cookies are sent only from the allowed origins.
+func WithAccessControlAllowOrigin(allowedOrigins ...string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Headers",
-				"authorization, content-length, x-requested-with, accept, origin, content-type")
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Credentials", "true")
+				"authorization, content-length, x-requested-with, accept, origin, content-type, x-session-id, last-event-id")
+			w.Header().Set("Access-Control-Expose-Headers", "x-session-id")
+			// browsers reject the credentials allowed for any origin
+			w.Header().Add("Vary", "Origin")
+			if origin := r.Header.Get("Origin"); origin != "" && slices.Contains(allowedOrigins, origin) {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			}
 			w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS")
 			if r.Method == http.MethodOptions {
 				return
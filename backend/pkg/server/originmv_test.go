@@ -0,0 +1,34 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessControlAllowOrigin(t *testing.T) {
+	handler := WithAccessControlAllowOrigin("https://complynx.net")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	// the allowed origin may send the cookies
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/get_scores", nil)
+	r.Header.Set("Origin", "https://complynx.net")
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.Equal(t, "https://complynx.net", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "Origin", w.Header().Get("Vary"))
+
+	// the others may not
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodOptions, "/get_scores", nil)
+	r.Header.Set("Origin", "https://example.com")
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code, "preflight isn't passed on")
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+}
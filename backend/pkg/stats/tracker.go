@@ -0,0 +1,361 @@
+package stats
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+)
+
+var errNoCompaction = errors.New("storage doesn't support compaction")
+
+// records are loaded from the storage by pages of this size
+const pageSize = 500
+
+// Tracker is a storage which also provides statistics of the stored records.
+type Tracker interface {
+	pkg.StorageV2
+	pkg.StatsProvider
+}
+
+// tracker wraps the storage and updates the statistics with every added record,
+// so they never have to be computed by rescanning the storage on request.
+// The storage is only scanned on start, and the records of one owner when they are cleared or restored,
+// or when the owner's statistics have been dropped over maxOwners and are needed again.
+type tracker struct {
+	pkg.StorageV2
+	maxOwners int
+
+	mu     sync.Mutex
+	global types.Stats
+	owners map[types.Owner]*types.Stats
+	// P2P games of the registered users, the player names are free-form and may be anyone's
+	players map[types.Owner]*types.Stats
+	// what every owner added to the global statistics, taken back when the owner's records are cleared
+	shares map[types.Owner]*types.Stats
+	// the owners accounted, the one used last first, the last ones are dropped over maxOwners
+	used     *list.List
+	accounts map[types.Owner]*list.Element
+}
+
+// Option configures the tracker.
+type Option func(*tracker)
+
+// WithMaxOwners keeps the statistics of up to n owners, the ones used the longest time ago are dropped
+// and loaded from the stored records again when needed. Zero keeps them all.
+func WithMaxOwners(n int) Option {
+	return func(t *tracker) {
+		t.maxOwners = n
+	}
+}
+
+// NewTracker wraps the storage, statistics are loaded from the records it already has.
+func NewTracker(ctx context.Context, storage pkg.StorageV2, opts ...Option) (Tracker, error) {
+	t := &tracker{
+		StorageV2: storage,
+		used:      list.New(),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if err := t.rebuild(ctx); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// rebuild recomputes statistics from the stored records, must be called with the lock held
+func (t *tracker) rebuild(ctx context.Context) error {
+	t.reset()
+	// the owners are dropped only once everyone's records are accounted
+	defer t.trim()
+	return t.load(ctx, types.Global, t.addAggregate, t.add)
+}
+
+// reload recomputes statistics of the owner from its stored records, must be called with the lock held.
+// The global statistics are updated by the counts only, the global streaks stay as they are.
+func (t *tracker) reload(ctx context.Context, owner types.Owner) error {
+	if owner == types.Global {
+		return t.rebuild(ctx)
+	}
+	if share, ok := t.shares[owner]; ok {
+		t.global.Subtract(*share)
+	}
+	t.drop(owner)
+
+	err := t.load(ctx, owner, t.addOwnedAggregate, t.addOwned)
+	if share, ok := t.shares[owner]; ok {
+		t.global.Merge(*share)
+	}
+	return err
+}
+
+// use loads the statistics of the owner unless they are kept, must be called with the lock held.
+// The global statistics have the owner's records already.
+func (t *tracker) use(ctx context.Context, owner types.Owner) error {
+	defer t.trim()
+	if _, ok := t.accounts[owner]; ok || owner == types.Global {
+		t.touch(owner)
+		return nil
+	}
+	t.touch(owner)
+	if err := t.load(ctx, owner, t.addOwnedAggregate, t.addOwned); err != nil {
+		t.drop(owner)
+		return err
+	}
+	return nil
+}
+
+// touch marks the owner as used last
+func (t *tracker) touch(owner types.Owner) {
+	if owner == types.Global {
+		return
+	}
+	if e, ok := t.accounts[owner]; ok {
+		t.used.MoveToFront(e)
+		return
+	}
+	t.accounts[owner] = t.used.PushFront(owner)
+}
+
+// trim drops the statistics of the owners used the longest time ago over maxOwners
+func (t *tracker) trim() {
+	for t.maxOwners > 0 && t.used.Len() > t.maxOwners {
+		t.drop(t.used.Back().Value.(types.Owner))
+	}
+}
+
+// drop forgets the statistics of the owner, their share stays in the global ones
+func (t *tracker) drop(owner types.Owner) {
+	if e, ok := t.accounts[owner]; ok {
+		t.used.Remove(e)
+	}
+	delete(t.accounts, owner)
+	delete(t.owners, owner)
+	delete(t.players, owner)
+	delete(t.shares, owner)
+}
+
+// load accounts the stored records of the owner, or of everyone for the global one, oldest first
+func (t *tracker) load(
+	ctx context.Context,
+	owner types.Owner,
+	addAggregate func(types.DailyAggregate),
+	add func(types.GameRecord),
+) error {
+	// records rolled up by the retention are older than any of the stored ones
+	if compactor, ok := t.StorageV2.(pkg.Compactor); ok {
+		aggregates, err := compactor.GetAggregates(ctx, owner)
+		if err != nil {
+			return fmt.Errorf("load aggregates: %w", err)
+		}
+		for _, a := range aggregates {
+			addAggregate(a)
+		}
+	}
+	q := types.HistoryQuery{
+		Owner:     owner,
+		Limit:     pageSize,
+		Ascending: true,
+		Mirrors:   true,
+	}
+	for {
+		page, err := t.StorageV2.History(ctx, q)
+		if err != nil {
+			return fmt.Errorf("load records: %w", err)
+		}
+		for _, r := range page.Records {
+			add(r)
+		}
+		if page.Cursor == "" || len(page.Records) == 0 {
+			return nil
+		}
+		q.After = page.Records[len(page.Records)-1].ID
+	}
+}
+
+func (t *tracker) reset() {
+	t.global = types.Stats{}
+	t.owners = make(map[types.Owner]*types.Stats)
+	t.players = make(map[types.Owner]*types.Stats)
+	t.shares = make(map[types.Owner]*types.Stats)
+	t.used.Init()
+	t.accounts = make(map[types.Owner]*list.Element)
+}
+
+func statsOf(m map[types.Owner]*types.Stats, owner types.Owner) *types.Stats {
+	stats, ok := m[owner]
+	if !ok {
+		stats = &types.Stats{}
+		m[owner] = stats
+	}
+	return stats
+}
+
+// the global statistics count a P2P round once, without the mirror
+func (t *tracker) addAggregate(a types.DailyAggregate) {
+	if !a.Mirror {
+		t.global.AddAggregate(a)
+	}
+	t.addOwnedAggregate(a)
+}
+
+// accounts the aggregate for its owner only
+func (t *tracker) addOwnedAggregate(a types.DailyAggregate) {
+	if a.Owner == types.Global {
+		return
+	}
+	t.touch(a.Owner)
+	statsOf(t.owners, a.Owner).AddAggregate(a)
+	if !a.Mirror {
+		statsOf(t.shares, a.Owner).AddAggregate(a)
+	}
+}
+
+func (t *tracker) add(r types.GameRecord) {
+	if !r.Mirror {
+		t.global.Add(r)
+	}
+	t.addOwned(r)
+}
+
+// accounts the record for its owner and player only
+func (t *tracker) addOwned(r types.GameRecord) {
+	if r.Owner == types.Global {
+		return
+	}
+	t.touch(r.Owner)
+	statsOf(t.owners, r.Owner).Add(r)
+	if !r.Mirror {
+		statsOf(t.shares, r.Owner).Add(r)
+	}
+	if r.Mode == types.ModeP2P && r.Owner.IsUser() {
+		statsOf(t.players, r.Owner).Add(r)
+	}
+}
+
+// writes hold the lock so that statistics follow the storage order
+func (t *tracker) AddRecord(ctx context.Context, record types.GameRecord) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.StorageV2.AddRecord(ctx, record); err != nil {
+		return err
+	}
+	if _, ok := t.accounts[record.Owner]; ok || record.Owner == types.Global {
+		t.add(record)
+		return nil
+	}
+	// the owner's statistics are loaded with the record just stored
+	if !record.Mirror {
+		t.global.Add(record)
+	}
+	return t.use(ctx, record.Owner)
+}
+
+func (t *tracker) SetLastScore(r types.Result) error {
+	return t.AddRecord(context.Background(), types.GameRecord{
+		Time:   time.Now(),
+		Mode:   types.ModeComputer,
+		Result: r,
+	})
+}
+
+func (t *tracker) ClearRecords(ctx context.Context, owner types.Owner) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// the owner's share is taken back from the global statistics
+	if err := t.use(ctx, owner); err != nil {
+		return err
+	}
+	if err := t.StorageV2.ClearRecords(ctx, owner); err != nil {
+		return err
+	}
+	// the stored records may be fewer than the ones accounted, so only the owner's ones are rescanned
+	return t.reload(ctx, owner)
+}
+
+func (t *tracker) ClearScores() error {
+	return t.ClearRecords(context.Background(), types.Global)
+}
+
+func (t *tracker) RestoreRecords(ctx context.Context, owner types.Owner) (types.Tombstone, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.use(ctx, owner); err != nil {
+		return types.Tombstone{}, err
+	}
+	tombstone, err := t.StorageV2.RestoreRecords(ctx, owner)
+	if err != nil {
+		return tombstone, err
+	}
+	// restored records may be older than the ones added since, streaks depend on the order
+	return tombstone, t.reload(ctx, owner)
+}
+
+// compaction holds the lock so that statistics are never rebuilt from half of it,
+// they stay the same since the rolled up records are still accounted
+func (t *tracker) Compact(ctx context.Context, policy types.RetentionPolicy, now time.Time) (int, error) {
+	compactor, ok := t.StorageV2.(pkg.Compactor)
+	if !ok {
+		return 0, errNoCompaction
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return compactor.Compact(ctx, policy, now)
+}
+
+func (t *tracker) GetAggregates(ctx context.Context, owner types.Owner) ([]types.DailyAggregate, error) {
+	compactor, ok := t.StorageV2.(pkg.Compactor)
+	if !ok {
+		return nil, errNoCompaction
+	}
+	return compactor.GetAggregates(ctx, owner)
+}
+
+// other events don't change statistics, they are dropped if the storage doesn't keep them
+func (t *tracker) RecordEvent(ctx context.Context, event types.Event) error {
+	if recorder, ok := t.StorageV2.(pkg.EventRecorder); ok {
+		return recorder.RecordEvent(ctx, event)
+	}
+	return nil
+}
+
+// the statistics of the owners are loaded if they've been dropped, so even reading them takes the write lock
+func (t *tracker) GetStats(ctx context.Context, owner types.Owner) (types.Stats, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if owner == types.Global {
+		return t.global.Clone(), nil
+	}
+	if err := t.use(ctx, owner); err != nil {
+		return types.Stats{}, err
+	}
+	if stats, ok := t.owners[owner]; ok {
+		return stats.Clone(), nil
+	}
+	return types.Stats{}, nil
+}
+
+func (t *tracker) GetPlayerStats(ctx context.Context, name string) (types.Stats, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	owner := types.UserOwner(name)
+	if err := t.use(ctx, owner); err != nil {
+		return types.Stats{}, err
+	}
+	if stats, ok := t.players[owner]; ok {
+		return stats.Clone(), nil
+	}
+	return types.Stats{}, nil
+}
@@ -0,0 +1,271 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg"
+	"github.com/complynx/rpssl4bu/backend/pkg/mocks"
+	"github.com/complynx/rpssl4bu/backend/pkg/storage"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	sheldon = types.SessionOwner("00000000000000000000000000000001")
+	penny   = types.UserOwner("penny")
+)
+
+func TestTracker(t *testing.T) {
+	ctx := context.Background()
+	s := storage.NewSimple(100)
+
+	// existing records are loaded on start
+	require.NoError(t, s.AddRecord(ctx, types.GameRecord{Owner: sheldon, PlayerChoice: types.Spock, OpponentChoice: types.Rock, Result: types.Win}))
+
+	tr, err := NewTracker(ctx, s)
+	require.NoError(t, err)
+
+	stats, err := tr.GetStats(ctx, sheldon)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats.Wins)
+
+	require.NoError(t, tr.AddRecord(ctx, types.GameRecord{Owner: sheldon, PlayerChoice: types.Spock, OpponentChoice: types.Paper, Result: types.Lose}))
+	require.NoError(t, tr.AddRecord(ctx, types.GameRecord{
+		Owner: penny, Mode: types.ModeP2P, PlayerName: "Penny",
+		PlayerChoice: types.Rock, OpponentChoice: types.Scissors, Result: types.Win,
+	}))
+	// the other side of the same round counts for the player, but not globally
+	require.NoError(t, tr.AddRecord(ctx, types.GameRecord{
+		Owner: types.UserOwner("leonard"), Mode: types.ModeP2P, PlayerName: "Leonard",
+		PlayerChoice: types.Scissors, OpponentChoice: types.Rock, Result: types.Lose, Mirror: true,
+	}))
+	// the names of the others don't count for the player
+	require.NoError(t, tr.AddRecord(ctx, types.GameRecord{
+		Owner: sheldon, Mode: types.ModeP2P, PlayerName: "Penny",
+		PlayerChoice: types.Rock, OpponentChoice: types.Scissors, Result: types.Win,
+	}))
+	require.NoError(t, tr.SetLastScore(types.Tie))
+
+	stats, err = tr.GetStats(ctx, sheldon)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, stats.Games)
+	assert.Equal(t, 1, stats.Losses)
+	assert.Equal(t, 2, stats.Choices[types.Spock-1])
+
+	stats, err = tr.GetStats(ctx, types.Global)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, stats.Games)
+	assert.Equal(t, &types.Streak{Result: types.Tie, Length: 1}, stats.CurrentStreak)
+
+	stats, err = tr.GetPlayerStats(ctx, "penny")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats.Games)
+	assert.Equal(t, 1, stats.Wins)
+	assert.Equal(t, 1, stats.Matchups[types.Rock-1][types.Scissors-1])
+
+	stats, err = tr.GetPlayerStats(ctx, "leonard")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats.Losses)
+
+	stats, err = tr.GetPlayerStats(ctx, "Howard")
+	assert.NoError(t, err)
+	assert.Equal(t, types.Stats{}, stats)
+
+	// returned statistics are not affected by later games
+	stats, err = tr.GetStats(ctx, penny)
+	assert.NoError(t, err)
+	require.NoError(t, tr.AddRecord(ctx, types.GameRecord{Owner: penny, Result: types.Win}))
+	assert.Equal(t, 1, stats.CurrentStreak.Length)
+
+	// clearing removes the owner from every aggregate
+	require.NoError(t, tr.ClearRecords(ctx, sheldon))
+	stats, err = tr.GetStats(ctx, sheldon)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, stats.Games)
+	stats, err = tr.GetStats(ctx, types.Global)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, stats.Games)
+	stats, err = tr.GetPlayerStats(ctx, "penny")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats.Games)
+	stats, err = tr.GetStats(ctx, penny)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.Games)
+
+	// and restoring brings it back
+	_, err = tr.RestoreRecords(ctx, sheldon)
+	require.NoError(t, err)
+	stats, err = tr.GetStats(ctx, sheldon)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, stats.Games)
+	stats, err = tr.GetStats(ctx, types.Global)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, stats.Games)
+
+	require.NoError(t, tr.ClearScores())
+	stats, err = tr.GetStats(ctx, types.Global)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, stats.Games)
+}
+
+func TestTrackerClearOthers(t *testing.T) {
+	ctx := context.Background()
+	// the storage keeps fewer records than the tracker has accounted
+	tr, err := NewTracker(ctx, storage.NewSimple(10))
+	require.NoError(t, err)
+
+	for i := 0; i < 50; i++ {
+		require.NoError(t, tr.AddRecord(ctx, types.GameRecord{Owner: sheldon, Result: types.Win}))
+	}
+	require.NoError(t, tr.AddRecord(ctx, types.GameRecord{Owner: penny, Result: types.Lose}))
+
+	// clearing the others' records leaves the owner's statistics intact
+	require.NoError(t, tr.ClearRecords(ctx, penny))
+	stats, err := tr.GetStats(ctx, sheldon)
+	assert.NoError(t, err)
+	assert.Equal(t, 50, stats.Games)
+	stats, err = tr.GetStats(ctx, types.Global)
+	assert.NoError(t, err)
+	assert.Equal(t, 50, stats.Games)
+	assert.Equal(t, 50, stats.Wins)
+	assert.Equal(t, 0, stats.Losses)
+	assert.Equal(t, 1.0, stats.WinRate)
+
+	// and restoring them adds them back
+	_, err = tr.RestoreRecords(ctx, penny)
+	require.NoError(t, err)
+	stats, err = tr.GetStats(ctx, types.Global)
+	assert.NoError(t, err)
+	assert.Equal(t, 51, stats.Games)
+	stats, err = tr.GetStats(ctx, penny)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats.Losses)
+}
+
+func TestTrackerMaxOwners(t *testing.T) {
+	ctx := context.Background()
+	s := storage.NewSimple(10)
+	require.NoError(t, s.AddRecord(ctx, types.GameRecord{Owner: sheldon, Result: types.Win}))
+	require.NoError(t, s.AddRecord(ctx, types.GameRecord{Owner: sheldon, Result: types.Tie}))
+	tr, err := NewTracker(ctx, s, WithMaxOwners(1))
+	require.NoError(t, err)
+	require.NoError(t, tr.AddRecord(ctx, types.GameRecord{Owner: penny, Mode: types.ModeP2P, Result: types.Lose}))
+	require.NoError(t, tr.AddRecord(ctx, types.GameRecord{Owner: penny, Mode: types.ModeP2P, Result: types.Win}))
+	// only the statistics of the owner used last are kept
+	assert.Equal(t, 1, tr.(*tracker).used.Len())
+	assert.Len(t, tr.(*tracker).owners, 1)
+
+	// the dropped ones are loaded from the storage again
+	stats, err := tr.GetStats(ctx, sheldon)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.Games)
+	assert.Equal(t, &types.Streak{Result: types.Tie, Length: 1}, stats.CurrentStreak)
+	stats, err = tr.GetPlayerStats(ctx, "penny")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.Games)
+	assert.Len(t, tr.(*tracker).owners, 1)
+
+	// and their records are taken from the global statistics when cleared
+	require.NoError(t, tr.ClearRecords(ctx, sheldon))
+	stats, err = tr.GetStats(ctx, types.Global)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.Games)
+	assert.Equal(t, 1, stats.Wins)
+	require.NoError(t, tr.AddRecord(ctx, types.GameRecord{Owner: penny, Mode: types.ModeP2P, Result: types.Win}))
+	_, err = tr.RestoreRecords(ctx, sheldon)
+	require.NoError(t, err)
+	stats, err = tr.GetStats(ctx, types.Global)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, stats.Games)
+	stats, err = tr.GetStats(ctx, sheldon)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.Games)
+}
+
+func TestTrackerPages(t *testing.T) {
+	ctx := context.Background()
+	s := storage.NewSimple(pageSize * 3)
+	for i := 0; i < pageSize*2+1; i++ {
+		require.NoError(t, s.AddRecord(ctx, types.GameRecord{Owner: sheldon, Result: types.Tie}))
+	}
+
+	tr, err := NewTracker(ctx, s)
+	require.NoError(t, err)
+	stats, err := tr.GetStats(ctx, types.Global)
+	assert.NoError(t, err)
+	assert.Equal(t, pageSize*2+1, stats.Games)
+	assert.Equal(t, &types.Streak{Result: types.Tie, Length: pageSize*2 + 1}, stats.CurrentStreak)
+}
+
+func TestTrackerErrors(t *testing.T) {
+	ctx := context.Background()
+
+	s := mocks.NewStorageV2(t)
+	s.EXPECT().History(mock.Anything, mock.Anything).Times(1).Return(types.HistoryPage{}, errors.New("test"))
+	_, err := NewTracker(ctx, s)
+	assert.EqualError(t, err, "load records: test")
+
+	s = mocks.NewStorageV2(t)
+	s.EXPECT().History(mock.Anything, mock.Anything).Times(1).Return(types.HistoryPage{}, nil)
+	s.EXPECT().AddRecord(mock.Anything, mock.Anything).Times(1).Return(errors.New("test"))
+	tr, err := NewTracker(ctx, s)
+	require.NoError(t, err)
+
+	// failed records are not counted
+	assert.Error(t, tr.AddRecord(ctx, types.GameRecord{Result: types.Win}))
+	stats, err := tr.GetStats(ctx, types.Global)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, stats.Games)
+}
+
+func TestTrackerCompaction(t *testing.T) {
+	ctx := context.Background()
+	s, err := storage.NewSQLite(filepath.Join(t.TempDir(), "rpssl.db"), 10)
+	require.NoError(t, err)
+	defer s.(io.Closer).Close()
+	tr, err := NewTracker(ctx, s)
+	require.NoError(t, err)
+
+	now := time.Date(2023, 4, 8, 0, 0, 0, 0, time.UTC)
+	for i, result := range []types.Result{types.Win, types.Win, types.Lose, types.Tie} {
+		require.NoError(t, tr.AddRecord(ctx, types.GameRecord{
+			Owner:  sheldon,
+			Time:   now.Add(time.Duration(i-3) * 24 * time.Hour),
+			Result: result,
+		}))
+	}
+	n, err := tr.(pkg.Compactor).Compact(ctx, types.RetentionPolicy{MaxAge: 36 * time.Hour}, now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	// rolled up records are still accounted, after a restart too
+	stats, err := tr.GetStats(ctx, sheldon)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, stats.Games)
+	assert.Equal(t, 2, stats.Wins)
+
+	tr, err = NewTracker(ctx, s)
+	require.NoError(t, err)
+	stats, err = tr.GetStats(ctx, sheldon)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, stats.Games)
+	assert.Equal(t, 2, stats.Wins)
+	assert.Equal(t, 0.5, stats.WinRate)
+	assert.Equal(t, &types.Streak{Result: types.Tie, Length: 1}, stats.CurrentStreak)
+	stats, err = tr.GetStats(ctx, types.Global)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, stats.Games)
+
+	// storages without aggregates can't be compacted
+	tr, err = NewTracker(ctx, storage.NewSimple(10))
+	require.NoError(t, err)
+	_, err = tr.(pkg.Compactor).Compact(ctx, types.RetentionPolicy{MaxAge: time.Hour}, now)
+	assert.Error(t, err)
+}
@@ -0,0 +1,319 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg"
+	"github.com/complynx/rpssl4bu/backend/pkg/eventlog"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"go.uber.org/zap"
+)
+
+// snapshotEvery is the number of events between snapshots of the scoreboards
+const snapshotEvery = 1000
+
+// eventStore appends every change as an event to the log. The scoreboards are
+// a projection of the log kept in memory, restored on start from the latest
+// snapshot and the events after it.
+type eventStore struct {
+	options
+	dir      string
+	capacity int
+
+	mu  sync.RWMutex
+	log pkg.EventLog
+	// projection of the newest game records, up to maxRecords
+	scores      *simple
+	snapshotSeq uint64
+	// after a failed snapshot the next one is taken at this event
+	snapshotRetry uint64
+}
+
+// NewEventLog opens (or creates) the event log in the directory dir and restores
+// the scoreboards from it. GetLastScores returns up to capacity of the newest scores.
+// The history of everyone and of every owner is limited by WithMaxRecords.
+func NewEventLog(dir string, capacity int, opts ...Option) (pkg.StorageV2, error) {
+	log, err := eventlog.Open(filepath.Join(dir, "wal"), eventlog.DefaultSegmentSize)
+	if err != nil {
+		return nil, fmt.Errorf("open log: %w", err)
+	}
+	o := newOptions(opts)
+	maxRecords := o.maxRecords
+	if maxRecords <= 0 {
+		maxRecords = math.MaxInt
+	}
+	s := &eventStore{
+		options:  o,
+		dir:      dir,
+		capacity: capacity,
+		log:      log,
+		// the projection keeps every owner, the log is the durable storage
+		scores: NewSimple(maxRecords, WithMaxOwners(0)).(*simple),
+	}
+	if err := s.restore(); err != nil {
+		log.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *eventStore) restore() error {
+	var snap simpleState
+	seq, err := eventlog.ReadSnapshot(s.dir, &snap)
+	if err != nil {
+		return fmt.Errorf("read snapshot: %w", err)
+	}
+	if last := s.log.LastSeq(); last < seq {
+		return fmt.Errorf("log ends at event %d before the snapshot at %d", last, seq)
+	}
+	s.scores.load(snap)
+	s.snapshotSeq = seq
+
+	err = s.log.Replay(seq+1, func(ev types.Event) error {
+		s.apply(ev)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("replay: %w", err)
+	}
+	return nil
+}
+
+// apply projects the event to the scoreboards, ratings and running games, P2P player events don't affect them
+func (s *eventStore) apply(ev types.Event) {
+	ctx := context.Background()
+	switch ev.Type {
+	case types.EventGamePlayed:
+		if ev.Record != nil {
+			s.scores.AddRecord(ctx, *ev.Record)
+		}
+	case types.EventScoresCleared:
+		// logs from before the tombstones cleared the records for good
+		t := types.Tombstone{ID: s.scores.lastTombstone + 1, Owner: ev.Owner, Time: ev.Time, Expires: ev.Time}
+		if ev.Tombstone != nil {
+			t = *ev.Tombstone
+		}
+		s.scores.clear(t)
+	case types.EventScoresRestored:
+		if ev.Tombstone != nil {
+			s.scores.untrash(ev.Tombstone.ID, ev.Time)
+		}
+	case types.EventMatchRated:
+		if ev.Match != nil {
+			s.scores.rate(*ev.Match)
+		}
+	case types.EventGameSaved:
+		if ev.Game != nil {
+			s.scores.games[ev.Game.ID] = *ev.Game
+		}
+	case types.EventGameDeleted:
+		delete(s.scores.games, ev.GameID)
+	}
+}
+
+// appends and applies the event, must be called with the lock held
+func (s *eventStore) append(ev types.Event) error {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	ev, err := s.log.Append(ev)
+	if err != nil {
+		return fmt.Errorf("append %s: %w", ev.Type, err)
+	}
+	s.apply(ev)
+
+	if ev.Seq-s.snapshotSeq >= snapshotEvery && ev.Seq >= s.snapshotRetry {
+		// the event is already durable, a failed snapshot is retried after as many events again
+		if err := s.snapshot(); err != nil {
+			s.logger.Error("Failed to snapshot the event log", zap.Uint64("seq", ev.Seq), zap.Error(err))
+			s.snapshotRetry = ev.Seq + snapshotEvery
+		}
+	}
+	return nil
+}
+
+// snapshot stores the scoreboards and drops the log segments which are not needed
+// to restore from the previous snapshot anymore, must be called with the lock held
+func (s *eventStore) snapshot() error {
+	seq := s.log.LastSeq()
+	if seq == s.snapshotSeq {
+		return nil
+	}
+	err := eventlog.WriteSnapshot(s.dir, seq, s.scores.state())
+	if err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := s.log.Prune(s.snapshotSeq + 1); err != nil {
+		return fmt.Errorf("prune log: %w", err)
+	}
+	s.snapshotSeq = seq
+	return nil
+}
+
+// Close takes a snapshot for a fast start next time and closes the log.
+func (s *eventStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.snapshot()
+	if closeErr := s.log.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// lists last scores
+func (s *eventStore) GetLastScores() ([]types.Result, error) {
+	records, err := s.GetLastRecords(context.Background(), types.Global, s.capacity)
+	return resultsOf(records), err
+}
+
+// stores game result
+func (s *eventStore) SetLastScore(r types.Result) error {
+	return s.AddRecord(context.Background(), resultRecord(r))
+}
+
+// clears all the scores
+func (s *eventStore) ClearScores() error {
+	return s.ClearRecords(context.Background(), types.Global)
+}
+
+// appends the game played event, the record gets its ID from the projection
+func (s *eventStore) AddRecord(ctx context.Context, record types.GameRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record.ID = 0
+	return s.append(types.Event{
+		Type:   types.EventGamePlayed,
+		Time:   record.Time,
+		Record: &record,
+	})
+}
+
+// appends the scores cleared event with a new tombstone
+func (s *eventStore) ClearRecords(ctx context.Context, owner types.Owner) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.tombstone(s.scores.lastTombstone+1, owner)
+	return s.append(types.Event{
+		Type:      types.EventScoresCleared,
+		Time:      t.Time,
+		Owner:     owner,
+		Tombstone: &t,
+	})
+}
+
+// appends the scores restored event for the owner's last unexpired tombstone
+func (s *eventStore) RestoreRecords(ctx context.Context, owner types.Owner) (types.Tombstone, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	t, ok := s.scores.lastTombstoneOf(owner, now)
+	if !ok {
+		return t, types.ErrNoTombstone
+	}
+	err := s.append(types.Event{
+		Type:      types.EventScoresRestored,
+		Time:      now,
+		Owner:     owner,
+		Tombstone: &t,
+	})
+	return t, err
+}
+
+// lists newest audit entries of the owner
+func (s *eventStore) AuditLog(ctx context.Context, owner types.Owner, limit int) ([]types.AuditEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.scores.AuditLog(ctx, owner, limit)
+}
+
+// appends any other event
+func (s *eventStore) RecordEvent(ctx context.Context, event types.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.append(event)
+}
+
+// lists last records of the owner, newest first, without waiting for the log to be written
+func (s *eventStore) GetLastRecords(ctx context.Context, owner types.Owner, limit int) ([]types.GameRecord, error) {
+	return s.scores.GetLastRecords(ctx, owner, limit)
+}
+
+// lists page of the owner's records matching the query, the lists of the projection are lock-free
+func (s *eventStore) History(ctx context.Context, q types.HistoryQuery) (types.HistoryPage, error) {
+	return s.scores.History(ctx, q)
+}
+
+func (s *eventStore) GetRating(ctx context.Context, owner types.Owner) (types.Rating, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.scores.GetRating(ctx, owner)
+}
+
+// appends the match rated event, unless the match has been rated already
+func (s *eventStore) RateMatch(ctx context.Context, match types.MatchResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.scores.rated[match.ID] {
+		return nil
+	}
+	return s.append(types.Event{
+		Type:  types.EventMatchRated,
+		Time:  match.Time,
+		Match: &match,
+	})
+}
+
+// lists the best rated players
+func (s *eventStore) Leaderboard(ctx context.Context, limit int) ([]types.LeaderboardEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.scores.Leaderboard(ctx, limit)
+}
+
+// appends the game saved event with the snapshot
+func (s *eventStore) SaveGame(ctx context.Context, game types.GameSnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.append(types.Event{
+		Type: types.EventGameSaved,
+		Game: &game,
+	})
+}
+
+// appends the game deleted event, unless there's no snapshot of the game
+func (s *eventStore) DeleteGame(ctx context.Context, id types.GameID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.scores.games[id]; !ok {
+		return nil
+	}
+	return s.append(types.Event{
+		Type:   types.EventGameDeleted,
+		GameID: id,
+	})
+}
+
+// lists the snapshots of the running games, oldest first
+func (s *eventStore) LoadGames(ctx context.Context) ([]types.GameSnapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.scores.LoadGames(ctx)
+}
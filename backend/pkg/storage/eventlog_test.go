@@ -0,0 +1,239 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg"
+	"github.com/complynx/rpssl4bu/backend/pkg/eventlog"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestEventLogStorage(t *testing.T) {
+	s, err := NewEventLog(t.TempDir(), 2)
+	require.NoError(t, err)
+	defer s.(*eventStore).Close()
+
+	// same as for the other storages
+	assert.NoError(t, s.SetLastScore(types.Win))
+	assert.NoError(t, s.SetLastScore(types.Lose))
+	assert.NoError(t, s.SetLastScore(types.Tie))
+	scores, err := s.GetLastScores()
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Result{types.Tie, types.Lose}, scores)
+
+	assert.NoError(t, s.ClearScores())
+	scores, err = s.GetLastScores()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(scores))
+}
+
+func TestEventLogMaxRecords(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewEventLog(dir, 10, WithMaxRecords(3))
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, s.AddRecord(ctx, types.GameRecord{Result: types.Win}))
+	}
+	page, err := s.History(ctx, types.HistoryQuery{Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, page.Records, 3)
+	require.NoError(t, s.(*eventStore).Close())
+
+	// the snapshot holds the newest ones only
+	s, err = NewEventLog(dir, 10, WithMaxRecords(3))
+	require.NoError(t, err)
+	defer s.(*eventStore).Close()
+	page, err = s.History(ctx, types.HistoryQuery{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Records, 3)
+	assert.Equal(t, uint64(5), page.Records[0].ID)
+	assert.Equal(t, uint64(3), page.Records[2].ID)
+}
+
+func TestEventLogMaxOwners(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewEventLog(t.TempDir(), 10, WithMaxOwners(1))
+	require.NoError(t, err)
+	defer s.(*eventStore).Close()
+
+	// the owners aren't dropped from the projection of the log
+	sheldon, penny := types.SessionOwner("00000000000000000000000000000001"), types.UserOwner("penny")
+	require.NoError(t, s.AddRecord(ctx, types.GameRecord{Owner: sheldon, Result: types.Win}))
+	require.NoError(t, s.AddRecord(ctx, types.GameRecord{Owner: penny, Result: types.Lose}))
+	for _, owner := range []types.Owner{sheldon, penny} {
+		records, err := s.GetLastRecords(ctx, owner, 10)
+		require.NoError(t, err)
+		assert.Len(t, records, 1, owner)
+	}
+}
+
+func TestEventLogSnapshotFailure(t *testing.T) {
+	ctx := context.Background()
+	core, logs := observer.New(zap.ErrorLevel)
+	s, err := NewEventLog(t.TempDir(), 10, WithLogger(zap.New(core)))
+	require.NoError(t, err)
+	defer s.(*eventStore).Close()
+	// the snapshots can't be written there
+	s.(*eventStore).dir = filepath.Join(s.(*eventStore).dir, "missing")
+
+	for i := 1; i < snapshotEvery*2; i++ {
+		require.NoError(t, s.AddRecord(ctx, types.GameRecord{Result: types.Win}))
+	}
+	// the failure is logged, and the snapshot isn't retried with every event
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, "Failed to snapshot the event log", logs.All()[0].Message)
+	require.NoError(t, s.AddRecord(ctx, types.GameRecord{Result: types.Win}))
+	assert.Equal(t, 2, logs.Len())
+}
+
+func TestEventLogRestore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	sheldon := types.SessionOwner("00000000000000000000000000000001")
+	penny := types.UserOwner("penny")
+	start := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	s, err := NewEventLog(dir, 10)
+	require.NoError(t, err)
+	// enough events for a couple of snapshots and some after the last one
+	for i := 0; i < 2*snapshotEvery+5; i++ {
+		owner := sheldon
+		if i%2 == 1 {
+			owner = penny
+		}
+		require.NoError(t, s.AddRecord(ctx, types.GameRecord{
+			Owner:  owner,
+			Time:   start.Add(time.Duration(i) * time.Second),
+			Result: types.Result(i % 3),
+		}))
+	}
+	require.NoError(t, s.ClearRecords(ctx, penny))
+	require.NoError(t, s.(pkg.EventRecorder).RecordEvent(ctx, types.Event{
+		Type:       types.EventPlayerJoined,
+		GameID:     42,
+		PlayerName: "Penny",
+		Owner:      penny,
+	}))
+	expected, err := s.GetLastRecords(ctx, types.Global, 10000)
+	require.NoError(t, err)
+	assert.Len(t, expected, snapshotEvery+3)
+	assert.Equal(t, uint64(2*snapshotEvery+5), expected[0].ID)
+
+	// crash without the closing snapshot
+	es := s.(*eventStore)
+	require.NoError(t, es.log.Close())
+	assert.Equal(t, uint64(2*snapshotEvery), es.snapshotSeq)
+
+	s, err = NewEventLog(dir, 10)
+	require.NoError(t, err)
+	restored, err := s.GetLastRecords(ctx, types.Global, 10000)
+	require.NoError(t, err)
+	assert.Equal(t, expected, restored)
+	records, err := s.GetLastRecords(ctx, penny, 10)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	// IDs continue after the restored ones
+	require.NoError(t, s.AddRecord(ctx, types.GameRecord{Owner: penny, Time: start, Result: types.Win}))
+	records, err = s.GetLastRecords(ctx, penny, 10)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2*snapshotEvery+6), records[0].ID)
+
+	// events are kept in the log, not only the projection
+	var joined []types.Event
+	require.NoError(t, s.(*eventStore).log.Replay(0, func(ev types.Event) error {
+		if ev.Type == types.EventPlayerJoined {
+			ev.Time = time.Time{}
+			joined = append(joined, ev)
+		}
+		return nil
+	}))
+	assert.Equal(t, []types.Event{{
+		Seq:        2*snapshotEvery + 7,
+		Type:       types.EventPlayerJoined,
+		GameID:     42,
+		PlayerName: "Penny",
+		Owner:      penny,
+	}}, joined)
+
+	// close takes a snapshot, so nothing has to be replayed next time
+	require.NoError(t, s.(*eventStore).Close())
+	var snap simpleState
+	seq, err := eventlog.ReadSnapshot(dir, &snap)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2*snapshotEvery+8), seq)
+	assert.Len(t, snap.Records, snapshotEvery+4)
+
+	s, err = NewEventLog(dir, 10)
+	require.NoError(t, err)
+	defer s.(*eventStore).Close()
+	records, err = s.GetLastRecords(ctx, penny, 10)
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+}
+
+func TestEventLogBehindSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewEventLog(dir, 10)
+	require.NoError(t, err)
+	require.NoError(t, s.SetLastScore(types.Win))
+	require.NoError(t, s.(*eventStore).Close())
+
+	// snapshot can't be continued if the log it was taken from is lost
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, "wal")))
+	_, err = NewEventLog(dir, 10)
+	assert.Error(t, err)
+}
+
+func TestEventLogTrash(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	clock := &testClock{t: time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)}
+	penny := types.UserOwner("penny")
+
+	s, err := NewEventLog(dir, 10, withClock(clock))
+	require.NoError(t, err)
+	require.NoError(t, s.AddRecord(ctx, types.GameRecord{Owner: penny, Result: types.Win}))
+	require.NoError(t, s.ClearRecords(ctx, penny))
+
+	// the tombstone is replayed after a crash
+	require.NoError(t, s.(*eventStore).log.Close())
+	s, err = NewEventLog(dir, 10, withClock(clock))
+	require.NoError(t, err)
+	_, err = s.RestoreRecords(ctx, penny)
+	require.NoError(t, err)
+	records, err := s.GetLastRecords(ctx, penny, 10)
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+
+	// logs from before the tombstones can't be restored
+	require.NoError(t, s.(pkg.EventRecorder).RecordEvent(ctx, types.Event{Type: types.EventScoresCleared, Owner: penny}))
+	_, err = s.RestoreRecords(ctx, penny)
+	assert.ErrorIs(t, err, types.ErrNoTombstone)
+
+	// the audit log is kept in the snapshot
+	expected, err := s.AuditLog(ctx, types.Global, 10)
+	require.NoError(t, err)
+	assert.Len(t, expected, 3)
+	require.NoError(t, s.(*eventStore).Close())
+	s, err = NewEventLog(dir, 10, withClock(clock))
+	require.NoError(t, err)
+	defer s.(*eventStore).Close()
+	entries, err := s.AuditLog(ctx, types.Global, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, len(expected))
+	for i := range entries {
+		assert.True(t, expected[i].Time.Equal(entries[i].Time))
+		expected[i].Time, entries[i].Time = time.Time{}, time.Time{}
+	}
+	assert.Equal(t, expected, entries)
+}
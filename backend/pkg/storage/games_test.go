@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGames(t *testing.T) {
+	t.Run("simple", func(t *testing.T) {
+		s := NewSimple(10)
+		testGames(t, s.(pkg.GameStore))
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "rpssl.db")
+		s, err := NewSQLite(path, 10)
+		require.NoError(t, err)
+		games := testGames(t, s.(pkg.GameStore))
+		require.NoError(t, s.(*sqlite).Close())
+
+		s, err = NewSQLite(path, 10)
+		require.NoError(t, err)
+		defer s.(*sqlite).Close()
+		testRestoredGames(t, s.(pkg.GameStore), games)
+	})
+	t.Run("eventlog", func(t *testing.T) {
+		dir := t.TempDir()
+		s, err := NewEventLog(dir, 10)
+		require.NoError(t, err)
+		games := testGames(t, s.(pkg.GameStore))
+		require.NoError(t, s.(*eventStore).log.Close())
+
+		// from the log
+		s, err = NewEventLog(dir, 10)
+		require.NoError(t, err)
+		testRestoredGames(t, s.(pkg.GameStore), games)
+		require.NoError(t, s.(*eventStore).Close())
+
+		// from the snapshot
+		s, err = NewEventLog(dir, 10)
+		require.NoError(t, err)
+		defer s.(*eventStore).Close()
+		testRestoredGames(t, s.(pkg.GameStore), games)
+	})
+}
+
+func testGames(t *testing.T, s pkg.GameStore) []types.GameSnapshot {
+	ctx := context.Background()
+	games, err := s.LoadGames(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, games)
+
+	// the times are compared after the round trip through JSON
+	now := time.Now().UTC().Truncate(time.Second)
+	sheldon := types.UserOwner("sheldon")
+	game := types.GameSnapshot{
+		ID:           0xfedcba9876543210,
+		Settings:     types.GameSettings{Ruleset: types.RulesetRPSSL, BestOf: 3, Public: true},
+		Created:      now,
+		Active:       now,
+		Expires:      now.Add(time.Hour),
+		Creator:      "Sheldon",
+		Host:         sheldon,
+		PasswordHash: []byte{1, 2, 3},
+		Banned:       []types.Owner{types.UserOwner("howard")},
+		Left:         &types.SeatSnapshot{Name: "Sheldon", Owner: sheldon, TokenHash: "left", Choice: types.Spock},
+		Right:        &types.SeatSnapshot{Name: "Penny", TokenHash: "right"},
+		Score:        types.NewMatchScore(3),
+		Match:        2,
+		Chat:         []types.ChatMessage{{ID: 1, Time: now, Side: types.SideLeft, Name: "Sheldon", Text: "Knock knock"}},
+		LastChatID:   1,
+	}
+	older := types.GameSnapshot{
+		ID:       1,
+		Settings: types.GameSettings{Ruleset: types.RulesetClassic},
+		Created:  now.Add(-time.Minute),
+		Expires:  now.Add(time.Hour),
+		Score:    types.NewMatchScore(0),
+		Match:    1,
+	}
+	require.NoError(t, s.SaveGame(ctx, game))
+	require.NoError(t, s.SaveGame(ctx, older))
+
+	// the snapshot is replaced
+	game.Score.Round = 2
+	game.Left.Choice = types.Undefined
+	require.NoError(t, s.SaveGame(ctx, game))
+
+	games, err = s.LoadGames(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []types.GameSnapshot{older, game}, games)
+
+	require.NoError(t, s.DeleteGame(ctx, older.ID))
+	require.NoError(t, s.DeleteGame(ctx, older.ID))
+	games, err = s.LoadGames(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []types.GameSnapshot{game}, games)
+	return games
+}
+
+func testRestoredGames(t *testing.T, s pkg.GameStore, games []types.GameSnapshot) {
+	restored, err := s.LoadGames(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, games, restored)
+}
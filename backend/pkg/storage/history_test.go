@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	t.Run("simple", func(t *testing.T) {
+		testHistory(t, NewSimple(100))
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		s, err := NewSQLite(filepath.Join(t.TempDir(), "rpssl.db"), 10)
+		require.NoError(t, err)
+		defer s.(*sqlite).Close()
+		testHistory(t, s)
+	})
+	t.Run("eventlog", func(t *testing.T) {
+		s, err := NewEventLog(t.TempDir(), 10)
+		require.NoError(t, err)
+		defer s.(*eventStore).Close()
+		testHistory(t, s)
+	})
+}
+
+func ids(page types.HistoryPage) []uint64 {
+	ret := make([]uint64, len(page.Records))
+	for i, r := range page.Records {
+		ret[i] = r.ID
+	}
+	return ret
+}
+
+func testHistory(t *testing.T, s pkg.StorageV2) {
+	ctx := context.Background()
+	sheldon := types.SessionOwner("00000000000000000000000000000001")
+	penny := types.UserOwner("penny")
+	start := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, r := range []types.GameRecord{
+		{Owner: sheldon, Mode: types.ModeComputer, PlayerChoice: types.Rock, OpponentChoice: types.Spock, Result: types.Lose},
+		{Owner: sheldon, Mode: types.ModeComputer, PlayerChoice: types.Paper, OpponentChoice: types.Rock, Result: types.Win},
+		{Owner: penny, Mode: types.ModeComputer, PlayerChoice: types.Rock, OpponentChoice: types.Spock, Result: types.Lose},
+		{Owner: sheldon, Mode: types.ModeP2P, PlayerChoice: types.Scissors, OpponentChoice: types.Spock, Result: types.Lose},
+		{Owner: sheldon, Mode: types.ModeComputer, PlayerChoice: types.Spock, OpponentChoice: types.Spock, Result: types.Tie},
+		{Owner: sheldon, Mode: types.ModeComputer, PlayerChoice: types.Lizard, OpponentChoice: types.Spock, Result: types.Win},
+	} {
+		r.Time = start.Add(time.Duration(i) * 24 * time.Hour)
+		require.NoError(t, s.AddRecord(ctx, r))
+	}
+
+	lose := types.Lose
+	spock := types.Spock
+	rock := types.Rock
+	p2p := types.ModeP2P
+
+	// pagination
+	page, err := s.History(ctx, types.HistoryQuery{Owner: sheldon, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{6, 5}, ids(page))
+	require.NotEmpty(t, page.Cursor)
+
+	before, err := types.ParseHistoryCursor(page.Cursor)
+	require.NoError(t, err)
+	page, err = s.History(ctx, types.HistoryQuery{Owner: sheldon, Limit: 2, Before: before})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{4, 2}, ids(page))
+	require.NotEmpty(t, page.Cursor)
+
+	before, err = types.ParseHistoryCursor(page.Cursor)
+	require.NoError(t, err)
+	page, err = s.History(ctx, types.HistoryQuery{Owner: sheldon, Limit: 2, Before: before})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1}, ids(page))
+	assert.Empty(t, page.Cursor)
+
+	// exact page has no cursor
+	page, err = s.History(ctx, types.HistoryQuery{Owner: penny, Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{3}, ids(page))
+	assert.Empty(t, page.Cursor)
+
+	// losses to Spock
+	page, err = s.History(ctx, types.HistoryQuery{Owner: sheldon, Limit: 10, Result: &lose, OpponentChoice: &spock})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{4, 1}, ids(page))
+
+	page, err = s.History(ctx, types.HistoryQuery{Owner: types.Global, Limit: 10, Choice: &rock})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{3, 1}, ids(page))
+
+	page, err = s.History(ctx, types.HistoryQuery{Owner: sheldon, Limit: 10, Mode: &p2p})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{4}, ids(page))
+
+	page, err = s.History(ctx, types.HistoryQuery{
+		Owner: sheldon,
+		Limit: 10,
+		Since: start.Add(24 * time.Hour),
+		Until: start.Add(4 * 24 * time.Hour),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{4, 2}, ids(page))
+
+	// oldest first
+	page, err = s.History(ctx, types.HistoryQuery{Owner: sheldon, Limit: 2, Ascending: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2}, ids(page))
+	page, err = s.History(ctx, types.HistoryQuery{Owner: sheldon, Limit: 2, Ascending: true, After: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{4, 5}, ids(page))
+	page, err = s.History(ctx, types.HistoryQuery{Owner: types.Global, Limit: 10, After: 2, Before: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{4, 3}, ids(page))
+
+	page, err = s.History(ctx, types.HistoryQuery{Owner: types.UserOwner("nobody"), Limit: 10})
+	assert.NoError(t, err)
+	assert.NotNil(t, page.Records)
+	assert.Empty(t, page.Records)
+
+	// the global history lists a P2P round once, without its mirror
+	require.NoError(t, s.AddRecord(ctx, types.GameRecord{Owner: penny, Mode: types.ModeP2P, Result: types.Win}))
+	require.NoError(t, s.AddRecord(ctx, types.GameRecord{Owner: sheldon, Mode: types.ModeP2P, Result: types.Lose, Mirror: true}))
+	page, err = s.History(ctx, types.HistoryQuery{Owner: types.Global, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{7, 6}, ids(page))
+	page, err = s.History(ctx, types.HistoryQuery{Owner: types.Global, Limit: 2, Mirrors: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{8, 7}, ids(page))
+	page, err = s.History(ctx, types.HistoryQuery{Owner: sheldon, Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{8}, ids(page))
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"go.uber.org/zap"
+)
+
+// DefaultUndoWindow is how long cleared records can be restored by default.
+const DefaultUndoWindow = time.Hour
+
+// DefaultMaxRecords is the number of the newest records the event log keeps by default.
+const DefaultMaxRecords = 100000
+
+// DefaultMaxOwners is the number of the owners whose records are kept in memory by default.
+const DefaultMaxOwners = 10000
+
+type options struct {
+	undoWindow time.Duration
+	maxRecords int
+	maxOwners  int
+	logger     *zap.Logger
+	now        func() time.Time
+}
+
+// Option configures a storage.
+type Option func(*options)
+
+// WithUndoWindow sets how long cleared records can be restored, zero removes them right away.
+func WithUndoWindow(d time.Duration) Option {
+	return func(o *options) {
+		o.undoWindow = d
+	}
+}
+
+// WithMaxRecords limits the event log to the newest n records, kept in memory and in the snapshots,
+// the older ones are dropped with the log segments after a snapshot. Zero keeps them all.
+func WithMaxRecords(n int) Option {
+	return func(o *options) {
+		o.maxRecords = n
+	}
+}
+
+// WithMaxOwners limits the in-memory storage to the records of n owners, the ones who haven't played
+// for the longest time are dropped first. Zero keeps them all. The durable storages keep everyone.
+func WithMaxOwners(n int) Option {
+	return func(o *options) {
+		o.maxOwners = n
+	}
+}
+
+// WithLogger logs the failures the storage recovers from by itself.
+func WithLogger(log *zap.Logger) Option {
+	return func(o *options) {
+		o.logger = log
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		undoWindow: DefaultUndoWindow,
+		maxRecords: DefaultMaxRecords,
+		maxOwners:  DefaultMaxOwners,
+		logger:     zap.NewNop(),
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// tombstone for the records of the owner cleared now
+func (o options) tombstone(id uint64, owner types.Owner) types.Tombstone {
+	now := o.now()
+	return types.Tombstone{
+		ID:      id,
+		Owner:   owner,
+		Time:    now,
+		Expires: now.Add(o.undoWindow),
+	}
+}
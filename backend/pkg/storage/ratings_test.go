@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRatings(t *testing.T) {
+	t.Run("simple", func(t *testing.T) {
+		s := NewSimple(10)
+		testRatings(t, s.(pkg.RatingStorage))
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "rpssl.db")
+		s, err := NewSQLite(path, 10)
+		require.NoError(t, err)
+		leaderboard := testRatings(t, s.(pkg.RatingStorage))
+		require.NoError(t, s.(*sqlite).Close())
+
+		s, err = NewSQLite(path, 10)
+		require.NoError(t, err)
+		defer s.(*sqlite).Close()
+		testRestoredRatings(t, s.(pkg.RatingStorage), leaderboard)
+	})
+	t.Run("eventlog", func(t *testing.T) {
+		dir := t.TempDir()
+		s, err := NewEventLog(dir, 10)
+		require.NoError(t, err)
+		leaderboard := testRatings(t, s.(pkg.RatingStorage))
+		require.NoError(t, s.(*eventStore).log.Close())
+
+		// from the log
+		s, err = NewEventLog(dir, 10)
+		require.NoError(t, err)
+		testRestoredRatings(t, s.(pkg.RatingStorage), leaderboard)
+		require.NoError(t, s.(*eventStore).Close())
+
+		// from the snapshot
+		s, err = NewEventLog(dir, 10)
+		require.NoError(t, err)
+		defer s.(*eventStore).Close()
+		testRestoredRatings(t, s.(pkg.RatingStorage), leaderboard)
+	})
+}
+
+func testRatings(t *testing.T, s pkg.RatingStorage) []types.LeaderboardEntry {
+	ctx := context.Background()
+	sheldon, leonard, penny := types.UserOwner("sheldon"), types.UserOwner("leonard"), types.UserOwner("penny")
+
+	r, err := s.GetRating(ctx, sheldon)
+	require.NoError(t, err)
+	assert.Equal(t, types.NewRating(sheldon), r)
+	leaderboard, err := s.Leaderboard(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, leaderboard)
+
+	now := time.Now()
+	matches := []types.MatchResult{
+		{ID: "1-1", Time: now, Left: sheldon, Right: leonard, Winner: types.SideLeft},
+		{ID: "2-1", Time: now, Left: penny, Right: leonard},
+		{ID: "2-2", Time: now, Left: penny, Right: leonard, Winner: types.SideRight},
+	}
+	for _, m := range matches {
+		require.NoError(t, s.RateMatch(ctx, m))
+	}
+	leaderboard, err = s.Leaderboard(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, leaderboard, 3)
+
+	// the match is rated once
+	require.NoError(t, s.RateMatch(ctx, matches[0]))
+	again, err := s.Leaderboard(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, leaderboard, again)
+
+	assert.Equal(t, []string{"sheldon", "leonard", "penny"}, []string{
+		leaderboard[0].Player, leaderboard[1].Player, leaderboard[2].Player,
+	})
+	for i, entry := range leaderboard {
+		assert.Equal(t, i+1, entry.Rank)
+	}
+	assert.Equal(t, 3, leaderboard[1].Games)
+	assert.Greater(t, leaderboard[0].Rating.Rating, types.InitialRating)
+	assert.Less(t, leaderboard[2].Rating.Rating, types.InitialRating)
+	assert.Less(t, leaderboard[1].Deviation, leaderboard[0].Deviation)
+
+	r, err = s.GetRating(ctx, leonard)
+	require.NoError(t, err)
+	assert.Equal(t, leaderboard[1].Rating, r)
+
+	top, err := s.Leaderboard(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, leaderboard[:1], top)
+	return leaderboard
+}
+
+func testRestoredRatings(t *testing.T, s pkg.RatingStorage, leaderboard []types.LeaderboardEntry) {
+	ctx := context.Background()
+	restored, err := s.Leaderboard(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, leaderboard, restored)
+
+	// the rated matches are remembered as well
+	require.NoError(t, s.RateMatch(ctx, types.MatchResult{
+		ID:     "1-1",
+		Left:   types.UserOwner("sheldon"),
+		Right:  types.UserOwner("leonard"),
+		Winner: types.SideLeft,
+	}))
+	restored, err = s.Leaderboard(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, leaderboard, restored)
+}
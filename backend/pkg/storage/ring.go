@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"container/list"
+	"sync/atomic"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+)
+
+// ring keeps up to capacity of the last pushed records, overwriting the oldest one when full.
+// The buffer is allocated as it fills up, never beyond the capacity.
+// Reading is lock-free and safe while a record is pushed, but the writers must be serialized.
+type ring struct {
+	capacity int
+	buf      atomic.Pointer[ringBuf]
+	// owner is the element of the owner in the lists of the storage, nil for the global list
+	owner *list.Element
+}
+
+// ringBuf is never changed but by pushing, it's replaced when growing or resetting
+type ringBuf struct {
+	// the record number seq is in the slot seq%len(slots)
+	slots []atomic.Pointer[slot]
+	// number of the records ever pushed, it's stored after the slot
+	next atomic.Uint64
+}
+
+// slot keeps the record with its number, so that a reader can tell when it's been overwritten
+type slot struct {
+	seq    uint64
+	record types.GameRecord
+}
+
+func newRing(capacity int) *ring {
+	r := &ring{capacity: capacity}
+	r.buf.Store(&ringBuf{})
+	return r
+}
+
+// live returns the number of the pushed records and the number of them kept
+func (b *ringBuf) live() (next uint64, n int) {
+	next = b.next.Load()
+	return next, int(min(next, uint64(len(b.slots))))
+}
+
+// get returns the record number seq, false if it's been overwritten
+func (b *ringBuf) get(seq uint64) (types.GameRecord, bool) {
+	s := b.slots[seq%uint64(len(b.slots))].Load()
+	if s == nil || s.seq != seq {
+		return types.GameRecord{}, false
+	}
+	return s.record, true
+}
+
+func (r *ring) len() int {
+	_, n := r.buf.Load().live()
+	return n
+}
+
+// each calls fn for the records, the newest or the oldest first, until it returns false.
+// The records overwritten by the pushes made meanwhile are skipped.
+func (r *ring) each(newestFirst bool, fn func(types.GameRecord) bool) {
+	b := r.buf.Load()
+	next, n := b.live()
+	first := next - uint64(n)
+	for i := 0; i < n; i++ {
+		seq := first + uint64(i)
+		if newestFirst {
+			seq = next - uint64(i) - 1
+		}
+		record, ok := b.get(seq)
+		if !ok {
+			if newestFirst {
+				// the older ones are overwritten as well
+				return
+			}
+			continue
+		}
+		if !fn(record) {
+			return
+		}
+	}
+}
+
+func (r *ring) push(record types.GameRecord) {
+	if r.capacity <= 0 {
+		return
+	}
+	b := r.buf.Load()
+	next, n := b.live()
+	if n == len(b.slots) && n < r.capacity {
+		b = r.grow(b, min(max(2*n, 1), r.capacity))
+	}
+	b.slots[next%uint64(len(b.slots))].Store(&slot{seq: next, record: record})
+	b.next.Store(next + 1)
+}
+
+// grow replaces the buffer with a larger one with the same records
+func (r *ring) grow(b *ringBuf, size int) *ringBuf {
+	next, n := b.live()
+	grown := &ringBuf{slots: make([]atomic.Pointer[slot], size)}
+	for seq := next - uint64(n); seq < next; seq++ {
+		grown.slots[seq%uint64(size)].Store(b.slots[seq%uint64(len(b.slots))].Load())
+	}
+	grown.next.Store(next)
+	r.buf.Store(grown)
+	return grown
+}
+
+// records returns a copy of the records, the oldest first
+func (r *ring) records() []types.GameRecord {
+	ret := make([]types.GameRecord, 0, r.len())
+	r.each(false, func(record types.GameRecord) bool {
+		ret = append(ret, record)
+		return true
+	})
+	return ret
+}
+
+// reset replaces the records with the newest up to capacity of the given ones, oldest first
+func (r *ring) reset(records []types.GameRecord) {
+	if len(records) > r.capacity {
+		records = records[max(len(records)-r.capacity, 0):]
+	}
+	b := &ringBuf{slots: make([]atomic.Pointer[slot], len(records))}
+	for i, record := range records {
+		b.slots[i].Store(&slot{seq: uint64(i), record: record})
+	}
+	b.next.Store(uint64(len(records)))
+	r.buf.Store(b)
+}
+
+// filter keeps only the records matching keep and returns the rest, the oldest first
+func (r *ring) filter(keep func(types.GameRecord) bool) []types.GameRecord {
+	var kept, removed []types.GameRecord
+	for _, record := range r.records() {
+		if keep(record) {
+			kept = append(kept, record)
+		} else {
+			removed = append(removed, record)
+		}
+	}
+	if len(removed) > 0 {
+		r.reset(kept)
+	}
+	return removed
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// newestIDs lists the IDs of the records, the newest first
+func newestIDs(r *ring) []uint64 {
+	var ids []uint64
+	r.each(true, func(record types.GameRecord) bool {
+		ids = append(ids, record.ID)
+		return true
+	})
+	return ids
+}
+
+func TestRing(t *testing.T) {
+	r := newRing(3)
+	assert.Empty(t, r.records())
+
+	for id := uint64(1); id <= 5; id++ {
+		r.push(types.GameRecord{ID: id})
+	}
+	// the oldest ones are overwritten
+	assert.Equal(t, 3, r.len())
+	assert.Equal(t, []uint64{3, 4, 5}, recordIDs(r.records()))
+	assert.Equal(t, []uint64{5, 4, 3}, newestIDs(r))
+
+	// the buffer is never reallocated once full
+	buf := r.buf.Load()
+	r.push(types.GameRecord{ID: 6})
+	assert.Same(t, buf, r.buf.Load())
+	assert.Len(t, buf.slots, 3)
+
+	removed := r.filter(func(record types.GameRecord) bool { return record.ID != 5 })
+	assert.Equal(t, []uint64{5}, recordIDs(removed))
+	assert.Equal(t, []uint64{4, 6}, recordIDs(r.records()))
+	r.push(types.GameRecord{ID: 7})
+	r.push(types.GameRecord{ID: 8})
+	assert.Equal(t, []uint64{6, 7, 8}, recordIDs(r.records()))
+
+	r.reset([]types.GameRecord{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}})
+	assert.Equal(t, []uint64{2, 3, 4}, recordIDs(r.records()))
+
+	// nothing is kept without capacity
+	r = newRing(0)
+	r.push(types.GameRecord{ID: 1})
+	assert.Equal(t, 0, r.len())
+}
+
+func TestRingConcurrentReads(t *testing.T) {
+	r := newRing(8)
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				// the readers see the newest records in order, never a torn or stale one
+				ids := newestIDs(r)
+				assert.LessOrEqual(t, len(ids), 8)
+				for j := 1; j < len(ids); j++ {
+					assert.Equal(t, ids[j-1]-1, ids[j])
+				}
+			}
+		}()
+	}
+	// a single writer, like the storage holding its lock
+	for id := uint64(1); id <= 10000; id++ {
+		r.push(types.GameRecord{ID: id})
+	}
+	close(done)
+	wg.Wait()
+	assert.Equal(t, []uint64{10000, 9999, 9998, 9997, 9996, 9995, 9994, 9993}, newestIDs(r))
+}
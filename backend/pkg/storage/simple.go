@@ -1,42 +1,561 @@
 package storage
 
 import (
+	"container/list"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
 	"github.com/complynx/rpssl4bu/backend/pkg"
+	"github.com/complynx/rpssl4bu/backend/pkg/rating"
 	"github.com/complynx/rpssl4bu/backend/pkg/types"
 )
 
+// auditSize is the number of the newest audit entries kept in memory
+const auditSize = 1000
+
+// simple keeps last records of every owner and of the global view separately,
+// each list is a ring buffer limited by capacity. It's safe for concurrent use: the writers
+// share one lock, the lists are read without it, so a record may show up in the global list
+// a moment before it does in the owner's one.
 type simple struct {
-	scores   []types.Result
+	options
 	capacity int
+
+	// mu serializes the writers, and guards everything but the lists for the readers
+	mu sync.RWMutex
+	// lists of the owners, types.Owner to *ring
+	records sync.Map
+	// owners with the lists, the one with the newest record first, the last ones are evicted over maxOwners
+	owners *list.List
+	lastID uint64
+
+	// cleared records, oldest tombstone first
+	trash         []trashed
+	lastTombstone uint64
+	// newest audit entries, oldest first
+	audit []types.AuditEntry
+
+	ratings map[types.Owner]types.Rating
+	// IDs of the rated matches
+	rated map[string]bool
+
+	// snapshots of the running P2P games
+	games map[types.GameID]types.GameSnapshot
+}
+
+// trashed records of a tombstone, oldest first
+type trashed struct {
+	Tombstone types.Tombstone    `json:"tombstone"`
+	Records   []types.GameRecord `json:"records"`
+}
+
+// simpleState is everything the storage keeps, with all the records in the global list
+type simpleState struct {
+	LastID        uint64               `json:"last_id"`
+	Records       []types.GameRecord   `json:"records"`
+	LastTombstone uint64               `json:"last_tombstone,omitempty"`
+	Trash         []trashed            `json:"trash,omitempty"`
+	Audit         []types.AuditEntry   `json:"audit,omitempty"`
+	Ratings       []ownedRating        `json:"ratings,omitempty"`
+	Rated         []string             `json:"rated,omitempty"`
+	Games         []types.GameSnapshot `json:"games,omitempty"`
+}
+
+// ownedRating keeps the owner of the rating in the snapshot
+type ownedRating struct {
+	Owner types.Owner `json:"owner"`
+	types.Rating
 }
 
-func NewSimple(capacity int) pkg.Storage {
+// NewSimple creates in-memory storage keeping up to capacity of the last records of every owner.
+func NewSimple(capacity int, opts ...Option) pkg.StorageV2 {
 	return &simple{
+		options:  newOptions(opts),
+		owners:   list.New(),
 		capacity: capacity,
+		ratings:  make(map[types.Owner]types.Rating),
+		rated:    make(map[string]bool),
+		games:    make(map[types.GameID]types.GameSnapshot),
+	}
+}
+
+// The methods below without the lock are used by the event store projection,
+// which holds its own lock for them.
+
+// state returns everything kept, the lists of the owners are rebuilt from the global one
+func (s *simple) state() simpleState {
+	state := simpleState{
+		LastID:        s.lastID,
+		Records:       s.list(types.Global).records(),
+		LastTombstone: s.lastTombstone,
+		Trash:         append([]trashed{}, s.trash...),
+		Audit:         append([]types.AuditEntry{}, s.audit...),
+	}
+	for owner, r := range s.ratings {
+		state.Ratings = append(state.Ratings, ownedRating{Owner: owner, Rating: r})
+	}
+	for id := range s.rated {
+		state.Rated = append(state.Rated, id)
 	}
+	state.Games = s.gameList()
+	return state
+}
+
+// load replaces everything kept with the state, keeping the record IDs
+func (s *simple) load(state simpleState) {
+	s.dropAll()
+	for _, r := range state.Records {
+		s.push(r)
+	}
+	s.lastID = state.LastID
+	s.lastTombstone = state.LastTombstone
+	s.trash = state.Trash
+	s.audit = state.Audit
+	s.ratings = make(map[types.Owner]types.Rating)
+	for _, r := range state.Ratings {
+		r.Rating.Owner = r.Owner
+		s.ratings[r.Owner] = r.Rating
+	}
+	s.rated = make(map[string]bool)
+	for _, id := range state.Rated {
+		s.rated[id] = true
+	}
+	s.games = make(map[types.GameID]types.GameSnapshot)
+	for _, g := range state.Games {
+		s.games[g.ID] = g
+	}
+}
+
+// list returns the list of the owner, it's empty if the owner has no records
+func (s *simple) list(owner types.Owner) *ring {
+	if r, ok := s.records.Load(owner); ok {
+		return r.(*ring)
+	}
+	return newRing(0)
+}
+
+// push adds the record to the global and owner lists
+func (s *simple) push(record types.GameRecord) {
+	for _, owner := range listsOf(record) {
+		s.use(owner).push(record)
+	}
+}
+
+// use returns the list of the owner to add records to, creating it if needed,
+// the list of the owner who hasn't played for the longest time is evicted over maxOwners
+func (s *simple) use(owner types.Owner) *ring {
+	var r *ring
+	if loaded, ok := s.records.Load(owner); ok {
+		r = loaded.(*ring)
+		if r.owner != nil {
+			s.owners.MoveToFront(r.owner)
+		}
+	} else {
+		r = newRing(s.capacity)
+		s.records.Store(owner, r)
+		if owner != types.Global {
+			r.owner = s.owners.PushFront(owner)
+		}
+	}
+	if s.maxOwners > 0 && s.owners.Len() > s.maxOwners {
+		s.drop(s.owners.Back().Value.(types.Owner))
+	}
+	return r
+}
+
+// drop removes the list of the owner
+func (s *simple) drop(owner types.Owner) {
+	if r, ok := s.records.LoadAndDelete(owner); ok && r.(*ring).owner != nil {
+		s.owners.Remove(r.(*ring).owner)
+	}
+}
+
+// dropAll removes every list
+func (s *simple) dropAll() {
+	s.records.Range(func(owner, _ any) bool {
+		s.records.Delete(owner)
+		return true
+	})
+	s.owners.Init()
+}
+
+// listsOf returns the owners of the lists the record is on
+func listsOf(record types.GameRecord) []types.Owner {
+	if record.Owner == types.Global {
+		return []types.Owner{types.Global}
+	}
+	return []types.Owner{types.Global, record.Owner}
 }
 
 // lists last scores
 func (s *simple) GetLastScores() ([]types.Result, error) {
-	l := len(s.scores)
-	ret := make([]types.Result, l)
-	for i := range s.scores {
-		ret[l-i-1] = s.scores[i]
-	}
-	return ret, nil
+	return resultsOf(s.last(types.Global, s.capacity)), nil
 }
 
 // updates scoreboard adding last one, removing overflow if needed
 func (s *simple) SetLastScore(r types.Result) error {
-	s.scores = append(s.scores, r)
-	if len(s.scores) > s.capacity {
-		s.scores = s.scores[1:]
+	return s.AddRecord(context.Background(), resultRecord(r))
+}
+
+// moves all the scores to the trash
+func (s *simple) ClearScores() error {
+	return s.ClearRecords(context.Background(), types.Global)
+}
+
+// adds record to the owner and global lists, removing overflow if needed
+func (s *simple) AddRecord(ctx context.Context, record types.GameRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.add(record)
+	return nil
+}
+
+func (s *simple) add(record types.GameRecord) {
+	s.lastID++
+	record.ID = s.lastID
+	s.push(record)
+}
+
+// lists last records of the owner, newest first
+func (s *simple) GetLastRecords(ctx context.Context, owner types.Owner, limit int) ([]types.GameRecord, error) {
+	return s.last(owner, limit), nil
+}
+
+// last returns up to limit of the owner's newest records, the global scoreboard skips the mirror records
+func (s *simple) last(owner types.Owner, limit int) []types.GameRecord {
+	records := s.list(owner)
+	ret := make([]types.GameRecord, 0, min(limit, records.len()))
+	records.each(true, func(r types.GameRecord) bool {
+		if len(ret) == limit {
+			return false
+		}
+		if owner != types.Global || !r.Mirror {
+			ret = append(ret, r)
+		}
+		return true
+	})
+	return ret
+}
+
+// moves owner records to the trash, everything if the owner is global
+func (s *simple) ClearRecords(ctx context.Context, owner types.Owner) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.clear(s.tombstone(s.lastTombstone+1, owner))
+	return nil
+}
+
+// clear moves the records of the tombstone owner to the trash
+func (s *simple) clear(t types.Tombstone) {
+	s.purge(t.Time)
+	removed := s.remove(t.Owner)
+
+	t.Records = len(removed)
+	if t.ID > s.lastTombstone {
+		s.lastTombstone = t.ID
+	}
+	if !t.Expired(t.Time) {
+		s.trash = append(s.trash, trashed{Tombstone: t, Records: removed})
+	}
+	s.log(types.AuditEntry{
+		Time:      t.Time,
+		Action:    types.AuditScoresCleared,
+		Owner:     t.Owner,
+		Tombstone: t.ID,
+		Records:   t.Records,
+	})
+}
+
+// remove removes the owner records from every list and returns them once each, oldest first
+func (s *simple) remove(owner types.Owner) []types.GameRecord {
+	removed := make(map[uint64]types.GameRecord)
+	if owner == types.Global {
+		s.records.Range(func(_, records any) bool {
+			for _, r := range records.(*ring).records() {
+				removed[r.ID] = r
+			}
+			return true
+		})
+		s.dropAll()
+	} else {
+		for _, r := range s.list(owner).records() {
+			removed[r.ID] = r
+		}
+		s.drop(owner)
+
+		others := func(r types.GameRecord) bool { return r.Owner != owner }
+		for _, r := range s.list(types.Global).filter(others) {
+			removed[r.ID] = r
+		}
+	}
+
+	ret := make([]types.GameRecord, 0, len(removed))
+	for _, r := range removed {
+		ret = append(ret, r)
 	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].ID < ret[j].ID })
+	return ret
+}
+
+// purge drops the tombstones expired by now
+func (s *simple) purge(now time.Time) {
+	trash := s.trash[:0]
+	for _, t := range s.trash {
+		if !t.Tombstone.Expired(now) {
+			trash = append(trash, t)
+		}
+	}
+	s.trash = trash
+}
+
+// brings back the records of the owner's last unexpired tombstone
+func (s *simple) RestoreRecords(ctx context.Context, owner types.Owner) (types.Tombstone, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.purge(now)
+	t, ok := s.lastTombstoneOf(owner, now)
+	if !ok {
+		return t, types.ErrNoTombstone
+	}
+	return s.untrash(t.ID, now), nil
+}
+
+// lastTombstoneOf finds the last unexpired tombstone of the owner
+func (s *simple) lastTombstoneOf(owner types.Owner, now time.Time) (types.Tombstone, bool) {
+	for i := len(s.trash) - 1; i >= 0; i-- {
+		t := s.trash[i].Tombstone
+		if t.Owner == owner && !t.Expired(now) {
+			return t, true
+		}
+	}
+	return types.Tombstone{}, false
+}
+
+// untrash puts the records of the tombstone back to their lists
+func (s *simple) untrash(id uint64, now time.Time) types.Tombstone {
+	var t trashed
+	for i := range s.trash {
+		if s.trash[i].Tombstone.ID == id {
+			t = s.trash[i]
+			s.trash = append(s.trash[:i:i], s.trash[i+1:]...)
+			break
+		}
+	}
+	s.insert(t.Records)
+	s.log(types.AuditEntry{
+		Time:      now,
+		Action:    types.AuditScoresRestored,
+		Owner:     t.Tombstone.Owner,
+		Tombstone: t.Tombstone.ID,
+		Records:   len(t.Records),
+	})
+	return t.Tombstone
+}
+
+// insert puts the records, oldest first, to their lists in the order of IDs, removing overflow if needed
+func (s *simple) insert(records []types.GameRecord) {
+	byOwner := make(map[types.Owner][]types.GameRecord)
+	for _, r := range records {
+		for _, owner := range listsOf(r) {
+			byOwner[owner] = append(byOwner[owner], r)
+		}
+	}
+	for owner, inserted := range byOwner {
+		merged := mergeRecords(s.list(owner).records(), inserted)
+		s.use(owner).reset(merged)
+	}
+}
+
+// mergeRecords merges two lists of records, both ordered by IDs
+func mergeRecords(a, b []types.GameRecord) []types.GameRecord {
+	ret := make([]types.GameRecord, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		if a[0].ID < b[0].ID {
+			ret, a = append(ret, a[0]), a[1:]
+		} else {
+			ret, b = append(ret, b[0]), b[1:]
+		}
+	}
+	ret = append(ret, a...)
+	return append(ret, b...)
+}
+
+func (s *simple) log(entry types.AuditEntry) {
+	entry.ID = 1
+	if len(s.audit) > 0 {
+		entry.ID = s.audit[len(s.audit)-1].ID + 1
+	}
+	s.audit = append(s.audit, entry)
+	if len(s.audit) > auditSize {
+		s.audit = s.audit[len(s.audit)-auditSize:]
+	}
+}
+
+// lists newest audit entries of the owner, of everyone if the owner is global
+func (s *simple) AuditLog(ctx context.Context, owner types.Owner, limit int) ([]types.AuditEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ret := []types.AuditEntry{}
+	for i := len(s.audit) - 1; i >= 0 && len(ret) < limit; i-- {
+		if owner == types.Global || s.audit[i].Owner == owner {
+			ret = append(ret, s.audit[i])
+		}
+	}
+	return ret, nil
+}
+
+// lists page of the owner's records matching the query, newest first
+func (s *simple) History(ctx context.Context, q types.HistoryQuery) (types.HistoryPage, error) {
+	page := types.HistoryPage{
+		Records: []types.GameRecord{},
+	}
+	if q.Limit <= 0 {
+		return page, nil
+	}
+	s.list(q.Owner).each(!q.Ascending, func(r types.GameRecord) bool {
+		if !q.Matches(r) {
+			return true
+		}
+		if len(page.Records) == q.Limit {
+			page.Cursor = types.HistoryCursor(page.Records[q.Limit-1].ID)
+			return false
+		}
+		page.Records = append(page.Records, r)
+		return true
+	})
+	return page, nil
+}
+
+// rating returns the rating of the owner, the initial one if there's none
+func (s *simple) rating(owner types.Owner) types.Rating {
+	if r, ok := s.ratings[owner]; ok {
+		return r
+	}
+	return types.NewRating(owner)
+}
+
+func (s *simple) GetRating(ctx context.Context, owner types.Owner) (types.Rating, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.rating(owner), nil
+}
+
+// rates the match, unless it's been rated already
+func (s *simple) RateMatch(ctx context.Context, match types.MatchResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rate(match)
 	return nil
 }
 
-// cleares storage
-func (s *simple) ClearScores() error {
-	s.scores = nil
+// rate updates the ratings of the players, it returns false if the match has been rated already
+func (s *simple) rate(match types.MatchResult) bool {
+	if s.rated[match.ID] {
+		return false
+	}
+	s.rated[match.ID] = true
+	left, right := rating.Match(s.rating(match.Left), s.rating(match.Right), match.Winner)
+	s.ratings[match.Left] = left
+	s.ratings[match.Right] = right
+	return true
+}
+
+// lists the best rated players
+func (s *simple) Leaderboard(ctx context.Context, limit int) ([]types.LeaderboardEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ratings := make([]types.Rating, 0, len(s.ratings))
+	for _, r := range s.ratings {
+		ratings = append(ratings, r)
+	}
+	// the same order as in the SQL storage
+	sort.Slice(ratings, func(i, j int) bool {
+		a, b := ratings[i], ratings[j]
+		if a.Rating != b.Rating {
+			return a.Rating > b.Rating
+		}
+		if a.Games != b.Games {
+			return a.Games > b.Games
+		}
+		return a.Owner < b.Owner
+	})
+	if limit < len(ratings) {
+		ratings = ratings[:limit]
+	}
+	return rank(ratings), nil
+}
+
+func (s *simple) SaveGame(ctx context.Context, game types.GameSnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.games[game.ID] = game
 	return nil
 }
+
+func (s *simple) DeleteGame(ctx context.Context, id types.GameID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.games, id)
+	return nil
+}
+
+// lists the snapshots of the running games, oldest first
+func (s *simple) LoadGames(ctx context.Context) ([]types.GameSnapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.gameList(), nil
+}
+
+// gameList returns the snapshots of the games, oldest first
+func (s *simple) gameList() []types.GameSnapshot {
+	ret := make([]types.GameSnapshot, 0, len(s.games))
+	for _, g := range s.games {
+		ret = append(ret, g)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if !ret[i].Created.Equal(ret[j].Created) {
+			return ret[i].Created.Before(ret[j].Created)
+		}
+		return ret[i].ID < ret[j].ID
+	})
+	return ret
+}
+
+// rank numbers the ratings ordered from the best one
+func rank(ratings []types.Rating) []types.LeaderboardEntry {
+	ret := make([]types.LeaderboardEntry, len(ratings))
+	for i, r := range ratings {
+		ret[i] = types.LeaderboardEntry{Rank: i + 1, Rating: r}
+	}
+	return ret
+}
+
+// record for a computer game stored through the legacy API
+func resultRecord(r types.Result) types.GameRecord {
+	return types.GameRecord{
+		Time:   time.Now(),
+		Mode:   types.ModeComputer,
+		Result: r,
+	}
+}
+
+func resultsOf(records []types.GameRecord) []types.Result {
+	ret := make([]types.Result, len(records))
+	for i := range records {
+		ret[i] = records[i].Result
+	}
+	return ret
+}
@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"context"
+	"sync"
 	"testing"
 
 	"github.com/complynx/rpssl4bu/backend/pkg/types"
@@ -46,3 +48,207 @@ func TestSimpleStorage(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 0, len(scores))
 }
+
+func TestSimpleStorageRecords(t *testing.T) {
+	ctx := context.Background()
+	s := NewSimple(2)
+
+	records, err := s.GetLastRecords(ctx, types.Global, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(records))
+
+	first := types.GameRecord{ID: 1, Mode: types.ModeP2P, PlayerName: "Leonard", Result: types.Win}
+	second := types.GameRecord{ID: 2, Mode: types.ModeComputer, PlayerName: "Penny", Result: types.Lose}
+	third := types.GameRecord{ID: 3, Mode: types.ModeComputer, PlayerName: "Howard", Result: types.Tie}
+	assert.NoError(t, s.AddRecord(ctx, first))
+	assert.NoError(t, s.AddRecord(ctx, second))
+
+	records, err = s.GetLastRecords(ctx, types.Global, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.GameRecord{second, first}, records)
+
+	records, err = s.GetLastRecords(ctx, types.Global, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.GameRecord{second}, records)
+
+	// records and scores are the same list
+	assert.NoError(t, s.AddRecord(ctx, third))
+	scores, err := s.GetLastScores()
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Result{types.Tie, types.Lose}, scores)
+}
+
+func TestSimpleStorageOwners(t *testing.T) {
+	ctx := context.Background()
+	s := NewSimple(2)
+	sheldon := types.SessionOwner("00000000000000000000000000000001")
+	penny := types.UserOwner("penny")
+
+	first := types.GameRecord{ID: 1, Owner: sheldon, Result: types.Win}
+	second := types.GameRecord{ID: 2, Owner: penny, Result: types.Lose}
+	third := types.GameRecord{ID: 3, Owner: sheldon, Result: types.Tie}
+	assert.NoError(t, s.AddRecord(ctx, first))
+	assert.NoError(t, s.AddRecord(ctx, second))
+	assert.NoError(t, s.AddRecord(ctx, third))
+
+	records, err := s.GetLastRecords(ctx, sheldon, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.GameRecord{third, first}, records)
+
+	records, err = s.GetLastRecords(ctx, penny, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.GameRecord{second}, records)
+
+	// global view is limited by capacity as well
+	records, err = s.GetLastRecords(ctx, types.Global, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.GameRecord{third, second}, records)
+
+	// clearing own scoreboard leaves others intact
+	assert.NoError(t, s.ClearRecords(ctx, sheldon))
+	records, err = s.GetLastRecords(ctx, sheldon, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(records))
+	records, err = s.GetLastRecords(ctx, penny, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.GameRecord{second}, records)
+	records, err = s.GetLastRecords(ctx, types.Global, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.GameRecord{second}, records)
+
+	assert.NoError(t, s.ClearRecords(ctx, types.Global))
+	records, err = s.GetLastRecords(ctx, penny, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(records))
+}
+
+func TestSimpleStorageMirror(t *testing.T) {
+	ctx := context.Background()
+	s := NewSimple(2)
+	sheldon := types.UserOwner("sheldon")
+	leonard := types.UserOwner("leonard")
+
+	// a P2P round, the global scoreboard counts it once
+	left := types.GameRecord{ID: 1, Owner: sheldon, Mode: types.ModeP2P, Result: types.Win}
+	right := types.GameRecord{ID: 2, Owner: leonard, Mode: types.ModeP2P, Result: types.Lose, Mirror: true}
+	assert.NoError(t, s.AddRecord(ctx, left))
+	assert.NoError(t, s.AddRecord(ctx, right))
+
+	records, err := s.GetLastRecords(ctx, types.Global, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.GameRecord{left}, records)
+	scores, err := s.GetLastScores()
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Result{types.Win}, scores)
+
+	records, err = s.GetLastRecords(ctx, leonard, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.GameRecord{right}, records)
+}
+
+func TestSimpleStorageMaxOwners(t *testing.T) {
+	ctx := context.Background()
+	s := NewSimple(10, WithMaxOwners(2))
+	sheldon := types.UserOwner("sheldon")
+	penny := types.UserOwner("penny")
+	leonard := types.UserOwner("leonard")
+
+	assert.NoError(t, s.AddRecord(ctx, types.GameRecord{Owner: sheldon, Result: types.Win}))
+	assert.NoError(t, s.AddRecord(ctx, types.GameRecord{Owner: penny, Result: types.Win}))
+	assert.NoError(t, s.AddRecord(ctx, types.GameRecord{Owner: sheldon, Result: types.Lose}))
+	// penny has played the longest time ago
+	assert.NoError(t, s.AddRecord(ctx, types.GameRecord{Owner: leonard, Result: types.Tie}))
+
+	records, err := s.GetLastRecords(ctx, penny, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+	records, err = s.GetLastRecords(ctx, sheldon, 10)
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	records, err = s.GetLastRecords(ctx, leonard, 10)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	// everyone's records are kept
+	records, err = s.GetLastRecords(ctx, types.Global, 10)
+	assert.NoError(t, err)
+	assert.Len(t, records, 4)
+
+	assert.NoError(t, s.ClearRecords(ctx, sheldon))
+	assert.NoError(t, s.AddRecord(ctx, types.GameRecord{Owner: penny, Result: types.Win}))
+	records, err = s.GetLastRecords(ctx, leonard, 10)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1, "the cleared owner doesn't count")
+}
+
+func TestSimpleStorageConcurrent(t *testing.T) {
+	ctx := context.Background()
+	s := NewSimple(50)
+	owners := []types.Owner{types.UserOwner("penny"), types.UserOwner("leonard"), types.Global}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			owner := owners[i%len(owners)]
+			for j := 0; j < 200; j++ {
+				assert.NoError(t, s.AddRecord(ctx, types.GameRecord{Owner: owner, Result: types.Win}))
+				_, err := s.GetLastScores()
+				assert.NoError(t, err)
+				_, err = s.GetLastRecords(ctx, owner, 10)
+				assert.NoError(t, err)
+				_, err = s.History(ctx, types.HistoryQuery{Owner: owner, Limit: 10})
+				assert.NoError(t, err)
+				if j%50 == 49 && owner != types.Global {
+					assert.NoError(t, s.ClearRecords(ctx, owner))
+					_, err = s.RestoreRecords(ctx, owner)
+					assert.NoError(t, err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// every record got its own ID, and the newest are kept in order
+	records, err := s.GetLastRecords(ctx, types.Global, 100)
+	assert.NoError(t, err)
+	assert.Len(t, records, 50)
+	assert.Equal(t, uint64(8*200), records[0].ID)
+	for i := 1; i < len(records); i++ {
+		assert.Less(t, records[i].ID, records[i-1].ID)
+	}
+}
+
+func BenchmarkSimpleAddRecord(b *testing.B) {
+	ctx := context.Background()
+	s := NewSimple(10)
+	record := types.GameRecord{Owner: types.UserOwner("penny"), Result: types.Win}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s.AddRecord(ctx, record)
+	}
+}
+
+func BenchmarkSimpleGetLastScores(b *testing.B) {
+	s := NewSimple(10)
+	for i := 0; i < 10; i++ {
+		s.SetLastScore(types.Win)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s.GetLastScores()
+	}
+}
+
+func BenchmarkSimplePlayParallel(b *testing.B) {
+	ctx := context.Background()
+	s := NewSimple(10)
+	record := types.GameRecord{Owner: types.UserOwner("penny"), Result: types.Win}
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			s.AddRecord(ctx, record)
+			s.GetLastRecords(ctx, record.Owner, 10)
+		}
+	})
+}
@@ -0,0 +1,871 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg"
+	"github.com/complynx/rpssl4bu/backend/pkg/rating"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	_ "modernc.org/sqlite"
+)
+
+type migration struct {
+	version int
+	name    string
+	up      string
+}
+
+// migrations are applied in order, each in its own transaction.
+// Never edit an applied migration, append a new one instead.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create games",
+		up: `
+			CREATE TABLE games (
+				id         INTEGER PRIMARY KEY AUTOINCREMENT,
+				result     INTEGER NOT NULL,
+				created_at INTEGER NOT NULL
+			);
+		`,
+	},
+	{
+		version: 2,
+		name:    "game records",
+		up: `
+			ALTER TABLE games ADD COLUMN mode INTEGER NOT NULL DEFAULT 0;
+			ALTER TABLE games ADD COLUMN game_id INTEGER NOT NULL DEFAULT 0;
+			ALTER TABLE games ADD COLUMN player_name TEXT NOT NULL DEFAULT '';
+			ALTER TABLE games ADD COLUMN opponent_name TEXT NOT NULL DEFAULT '';
+			ALTER TABLE games ADD COLUMN player_choice INTEGER NOT NULL DEFAULT 0;
+			ALTER TABLE games ADD COLUMN opponent_choice INTEGER NOT NULL DEFAULT 0;
+			ALTER TABLE games ADD COLUMN strategy TEXT NOT NULL DEFAULT '';
+			ALTER TABLE games ADD COLUMN rng_source TEXT NOT NULL DEFAULT '';
+		`,
+	},
+	{
+		version: 3,
+		name:    "game owners",
+		up: `
+			ALTER TABLE games ADD COLUMN owner TEXT NOT NULL DEFAULT '';
+			CREATE INDEX games_owner ON games (owner, id);
+		`,
+	},
+	{
+		version: 4,
+		name:    "daily aggregates",
+		up: `
+			CREATE TABLE daily_aggregates (
+				owner  TEXT NOT NULL,
+				day    INTEGER NOT NULL,
+				games  INTEGER NOT NULL,
+				wins   INTEGER NOT NULL,
+				losses INTEGER NOT NULL,
+				ties   INTEGER NOT NULL,
+				PRIMARY KEY (owner, day)
+			);
+			CREATE INDEX games_created_at ON games (created_at);
+		`,
+	},
+	{
+		version: 5,
+		name:    "tombstones",
+		up: `
+			CREATE TABLE tombstones (
+				id         INTEGER PRIMARY KEY AUTOINCREMENT,
+				owner      TEXT NOT NULL,
+				created_at INTEGER NOT NULL,
+				expires_at INTEGER NOT NULL,
+				records    INTEGER NOT NULL
+			);
+			CREATE INDEX tombstones_owner ON tombstones (owner, id);
+			CREATE TABLE trashed_games (
+				tombstone_id    INTEGER NOT NULL,
+				id              INTEGER NOT NULL,
+				owner           TEXT NOT NULL,
+				created_at      INTEGER NOT NULL,
+				mode            INTEGER NOT NULL,
+				game_id         INTEGER NOT NULL,
+				player_name     TEXT NOT NULL,
+				opponent_name   TEXT NOT NULL,
+				player_choice   INTEGER NOT NULL,
+				opponent_choice INTEGER NOT NULL,
+				result          INTEGER NOT NULL,
+				strategy        TEXT NOT NULL,
+				rng_source      TEXT NOT NULL
+			);
+			CREATE INDEX trashed_games_tombstone ON trashed_games (tombstone_id);
+			CREATE TABLE trashed_aggregates (
+				tombstone_id INTEGER NOT NULL,
+				owner        TEXT NOT NULL,
+				day          INTEGER NOT NULL,
+				games        INTEGER NOT NULL,
+				wins         INTEGER NOT NULL,
+				losses       INTEGER NOT NULL,
+				ties         INTEGER NOT NULL
+			);
+			CREATE INDEX trashed_aggregates_tombstone ON trashed_aggregates (tombstone_id);
+			CREATE TABLE audit_log (
+				id           INTEGER PRIMARY KEY AUTOINCREMENT,
+				created_at   INTEGER NOT NULL,
+				action       TEXT NOT NULL,
+				owner        TEXT NOT NULL,
+				tombstone_id INTEGER NOT NULL,
+				records      INTEGER NOT NULL
+			);
+			CREATE INDEX audit_log_owner ON audit_log (owner, id);
+		`,
+	},
+	{
+		version: 6,
+		name:    "ratings",
+		up: `
+			CREATE TABLE ratings (
+				owner      TEXT PRIMARY KEY,
+				rating     REAL NOT NULL,
+				deviation  REAL NOT NULL,
+				volatility REAL NOT NULL,
+				games      INTEGER NOT NULL
+			);
+			CREATE INDEX ratings_rating ON ratings (rating DESC, games DESC, owner);
+			CREATE TABLE rated_matches (
+				id         TEXT PRIMARY KEY,
+				created_at INTEGER NOT NULL
+			);
+		`,
+	},
+	{
+		version: 7,
+		name:    "p2p games",
+		up: `
+			CREATE TABLE p2p_games (
+				id         INTEGER PRIMARY KEY,
+				created_at INTEGER NOT NULL,
+				snapshot   TEXT NOT NULL
+			);
+		`,
+	},
+	{
+		version: 8,
+		name:    "mirror records",
+		// the mirror of a P2P round is written after the left player's record, with the same game and time,
+		// the aggregates can't tell them apart anymore
+		up: `
+			ALTER TABLE games ADD COLUMN mirror INTEGER NOT NULL DEFAULT 0;
+			UPDATE games SET mirror = 1 WHERE mode = 1 AND EXISTS (
+				SELECT 1 FROM games g
+				WHERE g.mode = 1 AND g.game_id = games.game_id AND g.created_at = games.created_at AND g.id < games.id
+			);
+			ALTER TABLE trashed_games ADD COLUMN mirror INTEGER NOT NULL DEFAULT 0;
+			UPDATE trashed_games SET mirror = 1 WHERE mode = 1 AND EXISTS (
+				SELECT 1 FROM trashed_games g
+				WHERE g.mode = 1 AND g.game_id = trashed_games.game_id AND g.created_at = trashed_games.created_at
+					AND g.id < trashed_games.id
+			);
+			CREATE TABLE daily_aggregates_v8 (
+				owner  TEXT NOT NULL,
+				day    INTEGER NOT NULL,
+				mirror INTEGER NOT NULL,
+				games  INTEGER NOT NULL,
+				wins   INTEGER NOT NULL,
+				losses INTEGER NOT NULL,
+				ties   INTEGER NOT NULL,
+				PRIMARY KEY (owner, day, mirror)
+			);
+			INSERT INTO daily_aggregates_v8 (owner, day, mirror, games, wins, losses, ties)
+				SELECT owner, day, 0, games, wins, losses, ties FROM daily_aggregates;
+			DROP TABLE daily_aggregates;
+			ALTER TABLE daily_aggregates_v8 RENAME TO daily_aggregates;
+			ALTER TABLE trashed_aggregates ADD COLUMN mirror INTEGER NOT NULL DEFAULT 0;
+		`,
+	},
+}
+
+type sqlite struct {
+	options
+	db         *sql.DB
+	lastScores int
+}
+
+// NewSQLite opens (or creates) the database at path and brings its schema
+// up to date. Every game is stored, GetLastScores returns up to lastScores
+// of the newest ones.
+func NewSQLite(path string, lastScores int, opts ...Option) (pkg.StorageV2, error) {
+	// sqlite decodes the escaped path of the URI, so that ? and # may be in it
+	dsn := url.URL{
+		Scheme:   "file",
+		Opaque:   (&url.URL{Path: path}).EscapedPath(),
+		RawQuery: url.Values{"_pragma": {"busy_timeout(5000)", "journal_mode(WAL)"}}.Encode(),
+	}
+	db, err := sql.Open("sqlite", dsn.String())
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	// sqlite allows only one writer anyway, this avoids SQLITE_BUSY between our own connections
+	db.SetMaxOpenConns(1)
+
+	s := &sqlite{
+		options:    newOptions(opts),
+		db:         db,
+		lastScores: lastScores,
+	}
+	if err := s.migrate(context.Background()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+	return s, nil
+}
+
+func (s *sqlite) migrate(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at INTEGER NOT NULL
+		)`)
+	if err != nil {
+		return fmt.Errorf("create migrations table: %w", err)
+	}
+
+	var current int
+	err = s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current)
+	if err != nil {
+		return fmt.Errorf("get schema version: %w", err)
+	}
+	if len(migrations) > 0 && current > migrations[len(migrations)-1].version {
+		return fmt.Errorf("database schema version %d is newer than supported", current)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := s.apply(ctx, m); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+	}
+	return nil
+}
+
+func (s *sqlite) apply(ctx context.Context, m migration) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, m.up); err != nil {
+		return fmt.Errorf("exec: %w", err)
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+		m.version, m.name, time.Now().Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("record version: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Close closes the underlying database.
+func (s *sqlite) Close() error {
+	return s.db.Close()
+}
+
+// lists last scores of all owners, newest first
+func (s *sqlite) GetLastScores() ([]types.Result, error) {
+	records, err := s.GetLastRecords(context.Background(), types.Global, s.lastScores)
+	if err != nil {
+		return nil, err
+	}
+	return resultsOf(records), nil
+}
+
+// stores game result
+func (s *sqlite) SetLastScore(r types.Result) error {
+	return s.AddRecord(context.Background(), resultRecord(r))
+}
+
+const recordColumns = `id, owner, created_at, mode, game_id, player_name, opponent_name,
+	player_choice, opponent_choice, result, strategy, rng_source, mirror`
+
+const insertColumns = `owner, created_at, mode, game_id, player_name, opponent_name,
+	player_choice, opponent_choice, result, strategy, rng_source, mirror`
+
+// stores game record
+func (s *sqlite) AddRecord(ctx context.Context, r types.GameRecord) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO games (`+insertColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.Owner.String(), r.Time.UnixNano(), int(r.Mode), int64(r.GameID), r.PlayerName, r.OpponentName,
+		r.PlayerChoice.Int(), r.OpponentChoice.Int(), r.Result.Int(), r.Strategy, r.RNGSource, r.Mirror,
+	)
+	if err != nil {
+		return fmt.Errorf("insert: %w", err)
+	}
+	return nil
+}
+
+// lists last records of the owner, newest first, the global scoreboard skips the mirror records
+func (s *sqlite) GetLastRecords(ctx context.Context, owner types.Owner, limit int) ([]types.GameRecord, error) {
+	var rows *sql.Rows
+	var err error
+	if owner == types.Global {
+		rows, err = s.db.QueryContext(ctx,
+			`SELECT `+recordColumns+` FROM games WHERE mirror = 0 ORDER BY id DESC LIMIT ?`, limit)
+	} else {
+		rows, err = s.db.QueryContext(ctx,
+			`SELECT `+recordColumns+` FROM games WHERE owner = ? ORDER BY id DESC LIMIT ?`, owner.String(), limit)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRecords(rows)
+}
+
+func scanRecords(rows *sql.Rows) ([]types.GameRecord, error) {
+	var ret []types.GameRecord
+	for rows.Next() {
+		r, err := scanRecord(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		ret = append(ret, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+	return ret, nil
+}
+
+func scanRecord(rows *sql.Rows) (types.GameRecord, error) {
+	var (
+		r                           types.GameRecord
+		owner                       string
+		id, createdAt, gameID       int64
+		mode, pChoice, oChoice, res int
+	)
+	err := rows.Scan(&id, &owner, &createdAt, &mode, &gameID, &r.PlayerName, &r.OpponentName,
+		&pChoice, &oChoice, &res, &r.Strategy, &r.RNGSource, &r.Mirror)
+	if err != nil {
+		return r, err
+	}
+	r.ID = uint64(id)
+	r.Owner = types.Owner(owner)
+	r.Time = time.Unix(0, createdAt)
+	r.Mode = types.GameMode(mode)
+	r.GameID = types.GameID(gameID)
+	r.PlayerChoice = types.IntToChoice(pChoice)
+	r.OpponentChoice = types.IntToChoice(oChoice)
+	r.Result = types.IntToResult(res)
+	return r, nil
+}
+
+// moves all the games to the trash
+func (s *sqlite) ClearScores() error {
+	return s.ClearRecords(context.Background(), types.Global)
+}
+
+// moves the games and aggregates of the owner, all of them for the global owner, to the trash
+func (s *sqlite) ClearRecords(ctx context.Context, owner types.Owner) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	t := s.tombstone(0, owner)
+	if err := purgeTrash(ctx, tx, t.Time); err != nil {
+		return err
+	}
+
+	where, args := "", []any{}
+	if owner != types.Global {
+		where, args = ` WHERE owner = ?`, []any{owner.String()}
+	}
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM games`+where, args...).Scan(&t.Records); err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO tombstones (owner, created_at, expires_at, records) VALUES (?, ?, ?, ?)`,
+		owner.String(), t.Time.UnixNano(), t.Expires.UnixNano(), t.Records,
+	)
+	if err != nil {
+		return fmt.Errorf("insert tombstone: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("tombstone id: %w", err)
+	}
+	t.ID = uint64(id)
+
+	if !t.Expired(t.Time) {
+		_, err = tx.ExecContext(ctx, `INSERT INTO trashed_games (tombstone_id, `+recordColumns+`)
+			SELECT ?, `+recordColumns+` FROM games`+where, append([]any{id}, args...)...)
+		if err != nil {
+			return fmt.Errorf("trash games: %w", err)
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO trashed_aggregates (tombstone_id, owner, day, mirror, games, wins, losses, ties)
+			SELECT ?, owner, day, mirror, games, wins, losses, ties FROM daily_aggregates`+where, append([]any{id}, args...)...)
+		if err != nil {
+			return fmt.Errorf("trash aggregates: %w", err)
+		}
+	}
+	for _, table := range []string{"games", "daily_aggregates"} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+where, args...); err != nil {
+			return fmt.Errorf("delete %s: %w", table, err)
+		}
+	}
+	if err := audit(ctx, tx, t.Time, types.AuditScoresCleared, t); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// brings back the games and aggregates of the owner's last unexpired tombstone
+func (s *sqlite) RestoreRecords(ctx context.Context, owner types.Owner) (types.Tombstone, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return types.Tombstone{}, fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := s.now()
+	if err := purgeTrash(ctx, tx, now); err != nil {
+		return types.Tombstone{}, err
+	}
+
+	var (
+		t                    types.Tombstone
+		id, created, expires int64
+	)
+	err = tx.QueryRowContext(ctx,
+		`SELECT id, created_at, expires_at, records FROM tombstones WHERE owner = ? ORDER BY id DESC LIMIT 1`,
+		owner.String(),
+	).Scan(&id, &created, &expires, &t.Records)
+	if err == sql.ErrNoRows {
+		return t, types.ErrNoTombstone
+	}
+	if err != nil {
+		return t, fmt.Errorf("find tombstone: %w", err)
+	}
+	t.ID = uint64(id)
+	t.Owner = owner
+	t.Time = time.Unix(0, created)
+	t.Expires = time.Unix(0, expires)
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO games (`+recordColumns+`)
+		SELECT `+recordColumns+` FROM trashed_games WHERE tombstone_id = ?`, id)
+	if err != nil {
+		return t, fmt.Errorf("restore games: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO daily_aggregates (owner, day, mirror, games, wins, losses, ties)
+		SELECT owner, day, mirror, games, wins, losses, ties FROM trashed_aggregates WHERE tombstone_id = ?
+		ON CONFLICT (owner, day, mirror) DO UPDATE SET
+			games = games + excluded.games,
+			wins = wins + excluded.wins,
+			losses = losses + excluded.losses,
+			ties = ties + excluded.ties`, id)
+	if err != nil {
+		return t, fmt.Errorf("restore aggregates: %w", err)
+	}
+	if err := deleteTombstones(ctx, tx, `id = ?`, id); err != nil {
+		return t, err
+	}
+	if err := audit(ctx, tx, now, types.AuditScoresRestored, t); err != nil {
+		return t, err
+	}
+	if err := tx.Commit(); err != nil {
+		return t, fmt.Errorf("commit: %w", err)
+	}
+	return t, nil
+}
+
+// purgeTrash deletes the tombstones expired by now with their trashed rows
+func purgeTrash(ctx context.Context, tx *sql.Tx, now time.Time) error {
+	return deleteTombstones(ctx, tx, `expires_at <= ?`, now.UnixNano())
+}
+
+func deleteTombstones(ctx context.Context, tx *sql.Tx, cond string, args ...any) error {
+	for _, table := range []string{"trashed_games", "trashed_aggregates"} {
+		_, err := tx.ExecContext(ctx,
+			`DELETE FROM `+table+` WHERE tombstone_id IN (SELECT id FROM tombstones WHERE `+cond+`)`, args...)
+		if err != nil {
+			return fmt.Errorf("delete %s: %w", table, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM tombstones WHERE `+cond, args...); err != nil {
+		return fmt.Errorf("delete tombstones: %w", err)
+	}
+	return nil
+}
+
+func audit(ctx context.Context, tx *sql.Tx, now time.Time, action types.AuditAction, t types.Tombstone) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO audit_log (created_at, action, owner, tombstone_id, records) VALUES (?, ?, ?, ?, ?)`,
+		now.UnixNano(), string(action), t.Owner.String(), int64(t.ID), t.Records,
+	)
+	if err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+	return nil
+}
+
+// lists newest audit entries of the owner, of everyone for the global owner
+func (s *sqlite) AuditLog(ctx context.Context, owner types.Owner, limit int) ([]types.AuditEntry, error) {
+	query := `SELECT id, created_at, action, owner, tombstone_id, records FROM audit_log`
+	var args []any
+	if owner != types.Global {
+		query += ` WHERE owner = ?`
+		args = append(args, owner.String())
+	}
+	rows, err := s.db.QueryContext(ctx, query+` ORDER BY id DESC LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+
+	ret := []types.AuditEntry{}
+	for rows.Next() {
+		var (
+			e                        types.AuditEntry
+			action, owner            string
+			id, created, tombstoneID int64
+		)
+		if err := rows.Scan(&id, &created, &action, &owner, &tombstoneID, &e.Records); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		e.ID = uint64(id)
+		e.Time = time.Unix(0, created)
+		e.Action = types.AuditAction(action)
+		e.Owner = types.Owner(owner)
+		e.Tombstone = uint64(tombstoneID)
+		ret = append(ret, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+	return ret, nil
+}
+
+// ids of the games which are older than the cutoff or not among the newest of their owner
+const expiredGames = `
+	SELECT id FROM (
+		SELECT id, created_at, ROW_NUMBER() OVER (PARTITION BY owner ORDER BY id DESC) AS n FROM games
+	) WHERE created_at < ? OR n > ?`
+
+const nsPerDay = int64(24 * time.Hour)
+
+// rolls up expired games into daily aggregates and deletes them, in one transaction
+func (s *sqlite) Compact(ctx context.Context, policy types.RetentionPolicy, now time.Time) (int, error) {
+	cutoff := int64(math.MinInt64)
+	if t := policy.Cutoff(now); !t.IsZero() {
+		cutoff = t.UnixNano()
+	}
+	keep := int64(math.MaxInt64)
+	if policy.MaxPerOwner > 0 {
+		keep = int64(policy.MaxPerOwner)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO daily_aggregates (owner, day, mirror, games, wins, losses, ties)
+		SELECT owner, created_at / ?, mirror, COUNT(*), SUM(result = ?), SUM(result = ?), SUM(result = ?)
+		FROM games WHERE id IN (`+expiredGames+`) AND result IN (?, ?, ?)
+		GROUP BY owner, created_at / ?, mirror
+		ON CONFLICT (owner, day, mirror) DO UPDATE SET
+			games = games + excluded.games,
+			wins = wins + excluded.wins,
+			losses = losses + excluded.losses,
+			ties = ties + excluded.ties`,
+		nsPerDay, types.Win.Int(), types.Lose.Int(), types.Tie.Int(),
+		cutoff, keep, types.Win.Int(), types.Lose.Int(), types.Tie.Int(),
+		nsPerDay,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("aggregate: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM games WHERE id IN (`+expiredGames+`)`, cutoff, keep)
+	if err != nil {
+		return 0, fmt.Errorf("delete: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rows affected: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit: %w", err)
+	}
+	return int(n), nil
+}
+
+// lists daily aggregates of the owner, of everyone for the global owner, oldest first
+func (s *sqlite) GetAggregates(ctx context.Context, owner types.Owner) ([]types.DailyAggregate, error) {
+	query := `SELECT owner, day, mirror, games, wins, losses, ties FROM daily_aggregates`
+	var args []any
+	if owner != types.Global {
+		query += ` WHERE owner = ?`
+		args = append(args, owner.String())
+	}
+	rows, err := s.db.QueryContext(ctx, query+` ORDER BY day, owner, mirror`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+
+	var ret []types.DailyAggregate
+	for rows.Next() {
+		var (
+			a     types.DailyAggregate
+			owner string
+			day   int64
+		)
+		if err := rows.Scan(&owner, &day, &a.Mirror, &a.Games, &a.Wins, &a.Losses, &a.Ties); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		a.Owner = types.Owner(owner)
+		a.Day = time.Unix(0, day*nsPerDay).UTC()
+		ret = append(ret, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+	return ret, nil
+}
+
+// lists page of the owner's records matching the query, newest first
+func (s *sqlite) History(ctx context.Context, q types.HistoryQuery) (types.HistoryPage, error) {
+	if q.Limit <= 0 {
+		return types.HistoryPage{Records: []types.GameRecord{}}, nil
+	}
+
+	var where []string
+	var args []any
+	cond := func(c string, arg any) {
+		where = append(where, c)
+		args = append(args, arg)
+	}
+
+	if q.Owner != types.Global {
+		cond("owner = ?", q.Owner.String())
+	} else if !q.Mirrors {
+		where = append(where, "mirror = 0")
+	}
+	if q.Before != 0 {
+		cond("id < ?", int64(q.Before))
+	}
+	if q.After != 0 {
+		cond("id > ?", int64(q.After))
+	}
+	if q.Result != nil {
+		cond("result = ?", q.Result.Int())
+	}
+	if q.Choice != nil {
+		cond("player_choice = ?", q.Choice.Int())
+	}
+	if q.OpponentChoice != nil {
+		cond("opponent_choice = ?", q.OpponentChoice.Int())
+	}
+	if q.Mode != nil {
+		cond("mode = ?", int(*q.Mode))
+	}
+	if !q.Since.IsZero() {
+		cond("created_at >= ?", q.Since.UnixNano())
+	}
+	if !q.Until.IsZero() {
+		cond("created_at < ?", q.Until.UnixNano())
+	}
+
+	query := `SELECT ` + recordColumns + ` FROM games`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	if q.Ascending {
+		query += ` ORDER BY id LIMIT ?`
+	} else {
+		query += ` ORDER BY id DESC LIMIT ?`
+	}
+	// one extra to know if there's a next page
+	args = append(args, q.Limit+1)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return types.HistoryPage{}, fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+
+	records, err := scanRecords(rows)
+	if err != nil {
+		return types.HistoryPage{}, err
+	}
+
+	page := types.HistoryPage{
+		Records: records,
+	}
+	if len(records) > q.Limit {
+		page.Records = records[:q.Limit]
+		page.Cursor = types.HistoryCursor(page.Records[q.Limit-1].ID)
+	}
+	if page.Records == nil {
+		page.Records = []types.GameRecord{}
+	}
+	return page, nil
+}
+
+// queryRower is either the database or a transaction
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// getRating returns the rating of the owner, the initial one if there's none
+func getRating(ctx context.Context, q queryRower, owner types.Owner) (types.Rating, error) {
+	r := types.NewRating(owner)
+	err := q.QueryRowContext(ctx,
+		`SELECT rating, deviation, volatility, games FROM ratings WHERE owner = ?`, owner.String(),
+	).Scan(&r.Rating, &r.Deviation, &r.Volatility, &r.Games)
+	if err != nil && err != sql.ErrNoRows {
+		return r, fmt.Errorf("get rating: %w", err)
+	}
+	return r, nil
+}
+
+func (s *sqlite) GetRating(ctx context.Context, owner types.Owner) (types.Rating, error) {
+	return getRating(ctx, s.db, owner)
+}
+
+// rates the match, unless it's in the rated matches already
+func (s *sqlite) RateMatch(ctx context.Context, match types.MatchResult) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO rated_matches (id, created_at) VALUES (?, ?) ON CONFLICT (id) DO NOTHING`,
+		match.ID, match.Time.UnixNano(),
+	)
+	if err != nil {
+		return fmt.Errorf("insert match: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+
+	left, err := getRating(ctx, tx, match.Left)
+	if err != nil {
+		return err
+	}
+	right, err := getRating(ctx, tx, match.Right)
+	if err != nil {
+		return err
+	}
+	left, right = rating.Match(left, right, match.Winner)
+	for _, r := range []types.Rating{left, right} {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO ratings (owner, rating, deviation, volatility, games) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (owner) DO UPDATE SET
+				rating = excluded.rating,
+				deviation = excluded.deviation,
+				volatility = excluded.volatility,
+				games = excluded.games`,
+			r.Owner.String(), r.Rating, r.Deviation, r.Volatility, r.Games,
+		)
+		if err != nil {
+			return fmt.Errorf("update rating: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// lists the best rated players
+func (s *sqlite) Leaderboard(ctx context.Context, limit int) ([]types.LeaderboardEntry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT owner, rating, deviation, volatility, games FROM ratings
+		ORDER BY rating DESC, games DESC, owner LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+
+	ratings := []types.Rating{}
+	for rows.Next() {
+		var owner string
+		var r types.Rating
+		if err := rows.Scan(&owner, &r.Rating, &r.Deviation, &r.Volatility, &r.Games); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		r.Owner = types.Owner(owner)
+		r.Player = r.Owner.User()
+		ratings = append(ratings, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+	return rank(ratings), nil
+}
+
+// stores the snapshot of the game as JSON, replacing the previous one
+func (s *sqlite) SaveGame(ctx context.Context, game types.GameSnapshot) error {
+	snapshot, err := json.Marshal(game)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO p2p_games (id, created_at, snapshot) VALUES (?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET snapshot = excluded.snapshot`,
+		int64(game.ID), game.Created.UnixNano(), string(snapshot),
+	)
+	if err != nil {
+		return fmt.Errorf("upsert: %w", err)
+	}
+	return nil
+}
+
+func (s *sqlite) DeleteGame(ctx context.Context, id types.GameID) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM p2p_games WHERE id = ?`, int64(id))
+	if err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+	return nil
+}
+
+// lists the snapshots of the running games, oldest first
+func (s *sqlite) LoadGames(ctx context.Context) ([]types.GameSnapshot, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT snapshot FROM p2p_games ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+
+	games := []types.GameSnapshot{}
+	for rows.Next() {
+		var snapshot string
+		if err := rows.Scan(&snapshot); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		var game types.GameSnapshot
+		if err := json.Unmarshal([]byte(snapshot), &game); err != nil {
+			return nil, fmt.Errorf("unmarshal: %w", err)
+		}
+		games = append(games, game)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+	return games, nil
+}
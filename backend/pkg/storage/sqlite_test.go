@@ -0,0 +1,382 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteStorage(t *testing.T) {
+	s, err := NewSQLite(filepath.Join(t.TempDir(), "rpssl.db"), 3)
+	require.NoError(t, err)
+	defer s.(*sqlite).Close()
+
+	// Test GetLastScores on empty storage
+	scores, err := s.GetLastScores()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(scores))
+
+	// Test SetLastScore and GetLastScores
+	err = s.SetLastScore(types.Win)
+	assert.NoError(t, err)
+	scores, err = s.GetLastScores()
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Result{types.Win}, scores)
+
+	// Test SetLastScore and GetLastScores with capacity
+	err = s.SetLastScore(types.Lose)
+	assert.NoError(t, err)
+	err = s.SetLastScore(types.Tie)
+	assert.NoError(t, err)
+	scores, err = s.GetLastScores()
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Result{types.Tie, types.Lose, types.Win}, scores)
+
+	// Test SetLastScore with overflow, older games are kept but not listed
+	err = s.SetLastScore(types.Tie)
+	assert.NoError(t, err)
+	scores, err = s.GetLastScores()
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Result{types.Tie, types.Tie, types.Lose}, scores)
+
+	var count int
+	err = s.(*sqlite).db.QueryRow(`SELECT COUNT(*) FROM games`).Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, count)
+
+	// Test ClearScores
+	err = s.ClearScores()
+	assert.NoError(t, err)
+	scores, err = s.GetLastScores()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(scores))
+}
+
+func TestSQLitePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rpssl?#%20.db")
+
+	s, err := NewSQLite(path, 10)
+	require.NoError(t, err)
+	assert.NoError(t, s.SetLastScore(types.Lose))
+	assert.NoError(t, s.SetLastScore(types.Win))
+	require.NoError(t, s.(*sqlite).Close())
+
+	// reopening must not reapply migrations or lose data
+	s, err = NewSQLite(path, 10)
+	require.NoError(t, err)
+	defer s.(*sqlite).Close()
+
+	scores, err := s.GetLastScores()
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Result{types.Win, types.Lose}, scores)
+
+	var version int
+	err = s.(*sqlite).db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version)
+	assert.NoError(t, err)
+	assert.Equal(t, migrations[len(migrations)-1].version, version)
+	// at the very path
+	assert.FileExists(t, path)
+}
+
+func TestSQLiteNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rpssl.db")
+
+	s, err := NewSQLite(path, 10)
+	require.NoError(t, err)
+	_, err = s.(*sqlite).db.ExecContext(context.Background(),
+		`INSERT INTO schema_migrations (version, name, applied_at) VALUES (1000, 'future', 0)`)
+	require.NoError(t, err)
+	require.NoError(t, s.(*sqlite).Close())
+
+	_, err = NewSQLite(path, 10)
+	assert.Error(t, err)
+}
+
+func TestNew(t *testing.T) {
+	s, err := New("", 10)
+	assert.NoError(t, err)
+	assert.IsType(t, &simple{}, s)
+
+	s, err = New("simple", 10)
+	assert.NoError(t, err)
+	assert.IsType(t, &simple{}, s)
+
+	s, err = New("sqlite://"+filepath.Join(t.TempDir(), "rpssl.db"), 10)
+	assert.NoError(t, err)
+	assert.IsType(t, &sqlite{}, s)
+	s.(*sqlite).Close()
+
+	_, err = New("sqlite://", 10)
+	assert.Error(t, err)
+
+	s, err = New("eventlog://"+t.TempDir(), 10)
+	assert.NoError(t, err)
+	assert.IsType(t, &eventStore{}, s)
+	s.(*eventStore).Close()
+
+	_, err = New("eventlog://", 10)
+	assert.Error(t, err)
+
+	_, err = New("redis://localhost", 10)
+	assert.Error(t, err)
+}
+
+func TestSQLiteRecords(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewSQLite(filepath.Join(t.TempDir(), "rpssl.db"), 10)
+	require.NoError(t, err)
+	defer s.(*sqlite).Close()
+
+	first := types.GameRecord{
+		ID:             1,
+		Time:           time.Unix(0, 1680350400000000001),
+		Mode:           types.ModeComputer,
+		PlayerChoice:   types.Rock,
+		OpponentChoice: types.Paper,
+		OpponentName:   "Computer",
+		Result:         types.Lose,
+		Strategy:       "random",
+		RNGSource:      "internal",
+	}
+	second := types.GameRecord{
+		ID:             2,
+		Time:           time.Unix(0, 1680350400000000002),
+		Mode:           types.ModeP2P,
+		GameID:         0xa71ea4ac49b6fc7b,
+		PlayerName:     "Amy",
+		OpponentName:   "Bernadette",
+		PlayerChoice:   types.Spock,
+		OpponentChoice: types.Scissors,
+		Result:         types.Win,
+	}
+	assert.NoError(t, s.AddRecord(ctx, first))
+	assert.NoError(t, s.AddRecord(ctx, second))
+
+	records, err := s.GetLastRecords(ctx, types.Global, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.GameRecord{second, first}, records)
+
+	records, err = s.GetLastRecords(ctx, types.Global, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.GameRecord{second}, records)
+
+	scores, err := s.GetLastScores()
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Result{types.Win, types.Lose}, scores)
+}
+
+func TestSQLiteMigrateExistingData(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "rpssl.db")
+
+	// database created before game records were introduced
+	s := &sqlite{lastScores: 10}
+	var err error
+	s.db, err = sql.Open("sqlite", "file:"+path)
+	require.NoError(t, err)
+	_, err = s.db.Exec(`CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at INTEGER NOT NULL)`)
+	require.NoError(t, err)
+	require.NoError(t, s.apply(ctx, migrations[0]))
+	_, err = s.db.Exec(`INSERT INTO games (result, created_at) VALUES (?, ?)`, types.Tie.Int(), 42)
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	upgraded, err := NewSQLite(path, 10)
+	require.NoError(t, err)
+	defer upgraded.(*sqlite).Close()
+
+	records, err := upgraded.GetLastRecords(ctx, types.Global, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.GameRecord{{
+		ID:     1,
+		Time:   time.Unix(0, 42),
+		Mode:   types.ModeComputer,
+		Result: types.Tie,
+	}}, records)
+}
+
+func TestSQLiteOwners(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewSQLite(filepath.Join(t.TempDir(), "rpssl.db"), 10)
+	require.NoError(t, err)
+	defer s.(*sqlite).Close()
+	sheldon := types.SessionOwner("00000000000000000000000000000001")
+	penny := types.UserOwner("penny")
+
+	first := types.GameRecord{ID: 1, Owner: sheldon, Time: time.Unix(0, 1), Result: types.Win}
+	second := types.GameRecord{ID: 2, Owner: penny, Time: time.Unix(0, 2), Result: types.Lose}
+	third := types.GameRecord{ID: 3, Owner: sheldon, Time: time.Unix(0, 3), Result: types.Tie}
+	assert.NoError(t, s.AddRecord(ctx, first))
+	assert.NoError(t, s.AddRecord(ctx, second))
+	assert.NoError(t, s.AddRecord(ctx, third))
+
+	records, err := s.GetLastRecords(ctx, sheldon, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.GameRecord{third, first}, records)
+
+	records, err = s.GetLastRecords(ctx, types.Global, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.GameRecord{third, second, first}, records)
+
+	assert.NoError(t, s.ClearRecords(ctx, sheldon))
+	records, err = s.GetLastRecords(ctx, types.Global, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.GameRecord{second}, records)
+
+	assert.NoError(t, s.ClearRecords(ctx, types.Global))
+	records, err = s.GetLastRecords(ctx, penny, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(records))
+}
+
+func TestSQLiteMirror(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewSQLite(filepath.Join(t.TempDir(), "rpssl.db"), 10)
+	require.NoError(t, err)
+	defer s.(*sqlite).Close()
+	sheldon := types.UserOwner("sheldon")
+	leonard := types.UserOwner("leonard")
+
+	left := types.GameRecord{ID: 1, Owner: sheldon, Time: time.Unix(0, 1), Mode: types.ModeP2P, Result: types.Win}
+	right := types.GameRecord{ID: 2, Owner: leonard, Time: time.Unix(0, 1), Mode: types.ModeP2P, Result: types.Lose, Mirror: true}
+	assert.NoError(t, s.AddRecord(ctx, left))
+	assert.NoError(t, s.AddRecord(ctx, right))
+
+	records, err := s.GetLastRecords(ctx, types.Global, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.GameRecord{left}, records)
+	records, err = s.GetLastRecords(ctx, leonard, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.GameRecord{right}, records)
+
+	// both stay in the history, the global one lists the round once
+	page, err := s.(*sqlite).History(ctx, types.HistoryQuery{Limit: 10, Mirrors: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []types.GameRecord{right, left}, page.Records)
+	page, err = s.(*sqlite).History(ctx, types.HistoryQuery{Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, []types.GameRecord{left}, page.Records)
+}
+
+func TestSQLiteMigrateMirror(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "rpssl.db")
+
+	// P2P rounds stored before the mirror records were marked
+	s := &sqlite{lastScores: 10}
+	var err error
+	s.db, err = sql.Open("sqlite", "file:"+path)
+	require.NoError(t, err)
+	_, err = s.db.Exec(`CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at INTEGER NOT NULL)`)
+	require.NoError(t, err)
+	for _, m := range migrations {
+		if m.version < 8 {
+			require.NoError(t, s.apply(ctx, m))
+		}
+	}
+	for _, owner := range []string{"user:sheldon", "user:leonard"} {
+		_, err = s.db.Exec(`INSERT INTO games (owner, mode, game_id, result, created_at) VALUES (?, 1, 7, ?, 42)`,
+			owner, types.Win.Int())
+		require.NoError(t, err)
+	}
+	_, err = s.db.Exec(`INSERT INTO daily_aggregates (owner, day, games, wins, losses, ties) VALUES ('user:sheldon', 1, 3, 1, 1, 1)`)
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	upgraded, err := NewSQLite(path, 10)
+	require.NoError(t, err)
+	defer upgraded.(*sqlite).Close()
+
+	records, err := upgraded.GetLastRecords(ctx, types.Global, 10)
+	assert.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, types.UserOwner("sheldon"), records[0].Owner)
+	records, err = upgraded.GetLastRecords(ctx, types.UserOwner("leonard"), 10)
+	assert.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.True(t, records[0].Mirror)
+
+	aggregates, err := upgraded.(*sqlite).GetAggregates(ctx, types.Global)
+	assert.NoError(t, err)
+	require.Len(t, aggregates, 1)
+	assert.Equal(t, 3, aggregates[0].Games)
+	assert.False(t, aggregates[0].Mirror)
+}
+
+func TestSQLiteCompact(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewSQLite(filepath.Join(t.TempDir(), "rpssl.db"), 10)
+	require.NoError(t, err)
+	defer s.(*sqlite).Close()
+	c := s.(pkg.Compactor)
+	sheldon := types.SessionOwner("00000000000000000000000000000001")
+	penny := types.UserOwner("penny")
+	day1 := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	now := day2.Add(48 * time.Hour)
+
+	for _, r := range []types.GameRecord{
+		{Owner: sheldon, Time: day1.Add(time.Hour), Result: types.Win},
+		{Owner: sheldon, Time: day1.Add(2 * time.Hour), Result: types.Lose},
+		{Owner: penny, Time: day1.Add(3 * time.Hour), Result: types.Win},
+		{Owner: sheldon, Time: day2.Add(time.Hour), Result: types.Tie},
+		{Owner: sheldon, Time: now.Add(-time.Hour), Result: types.Unknown},
+		{Owner: sheldon, Time: now.Add(-time.Minute), Result: types.Win},
+		{Owner: penny, Time: now, Result: types.Lose},
+	} {
+		require.NoError(t, s.AddRecord(ctx, r))
+	}
+
+	// nothing expires without a policy
+	n, err := c.Compact(ctx, types.RetentionPolicy{}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	// by age
+	n, err = c.Compact(ctx, types.RetentionPolicy{MaxAge: 48 * time.Hour}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	aggregates, err := c.GetAggregates(ctx, types.Global)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.DailyAggregate{
+		{Owner: sheldon, Day: day1, Games: 2, Wins: 1, Losses: 1},
+		{Owner: penny, Day: day1, Games: 1, Wins: 1},
+	}, aggregates)
+
+	// by count, the unknown result is deleted but not accounted
+	n, err = c.Compact(ctx, types.RetentionPolicy{MaxAge: 72 * time.Hour, MaxPerOwner: 1}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	aggregates, err = c.GetAggregates(ctx, sheldon)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.DailyAggregate{
+		{Owner: sheldon, Day: day1, Games: 2, Wins: 1, Losses: 1},
+		{Owner: sheldon, Day: day2, Games: 1, Ties: 1},
+	}, aggregates)
+	records, err := s.GetLastRecords(ctx, types.Global, 10)
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+
+	// later records of the same day are added to the existing aggregate
+	require.NoError(t, s.AddRecord(ctx, types.GameRecord{Owner: penny, Time: day1, Result: types.Tie}))
+	_, err = c.Compact(ctx, types.RetentionPolicy{MaxAge: time.Hour}, now)
+	assert.NoError(t, err)
+	aggregates, err = c.GetAggregates(ctx, penny)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.DailyAggregate{
+		{Owner: penny, Day: day1, Games: 2, Wins: 1, Ties: 1},
+	}, aggregates)
+
+	// clearing the owner clears the aggregates too
+	assert.NoError(t, s.ClearRecords(ctx, penny))
+	aggregates, err = c.GetAggregates(ctx, types.Global)
+	assert.NoError(t, err)
+	assert.Len(t, aggregates, 2)
+}
@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/complynx/rpssl4bu/backend/pkg"
+)
+
+const (
+	sqliteScheme   = "sqlite://"
+	eventlogScheme = "eventlog://"
+)
+
+// New creates storage described by uri:
+//   - "" or "simple" — in-memory storage, keeps only the last scores
+//   - "sqlite:///path/to/file.db" — SQLite database at the given path
+//   - "eventlog:///path/to/dir" — event log with snapshots in the given directory
+//
+// capacity is the number of scores returned by GetLastScores.
+func New(uri string, capacity int, opts ...Option) (pkg.StorageV2, error) {
+	switch {
+	case uri == "" || uri == "simple":
+		return NewSimple(capacity, opts...), nil
+	case strings.HasPrefix(uri, sqliteScheme):
+		path := strings.TrimPrefix(uri, sqliteScheme)
+		if path == "" {
+			return nil, fmt.Errorf("empty sqlite database path")
+		}
+		return NewSQLite(path, capacity, opts...)
+	case strings.HasPrefix(uri, eventlogScheme):
+		dir := strings.TrimPrefix(uri, eventlogScheme)
+		if dir == "" {
+			return nil, fmt.Errorf("empty event log directory")
+		}
+		return NewEventLog(dir, capacity, opts...)
+	}
+	return nil, fmt.Errorf("unsupported storage: %q", uri)
+}
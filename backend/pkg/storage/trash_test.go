@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/complynx/rpssl4bu/backend/pkg"
+	"github.com/complynx/rpssl4bu/backend/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testClock struct {
+	t time.Time
+}
+
+func (c *testClock) now() time.Time {
+	return c.t
+}
+
+func withClock(c *testClock) Option {
+	return func(o *options) {
+		o.now = c.now
+	}
+}
+
+func TestTrash(t *testing.T) {
+	t.Run("simple", func(t *testing.T) {
+		clock := &testClock{}
+		testTrash(t, NewSimple(10, withClock(clock)), clock)
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		clock := &testClock{}
+		s, err := NewSQLite(filepath.Join(t.TempDir(), "rpssl.db"), 10, withClock(clock))
+		require.NoError(t, err)
+		defer s.(*sqlite).Close()
+		testTrash(t, s, clock)
+	})
+	t.Run("eventlog", func(t *testing.T) {
+		clock := &testClock{}
+		s, err := NewEventLog(t.TempDir(), 10, withClock(clock))
+		require.NoError(t, err)
+		defer s.(*eventStore).Close()
+		testTrash(t, s, clock)
+	})
+}
+
+func recordIDs(records []types.GameRecord) []uint64 {
+	ret := make([]uint64, len(records))
+	for i, r := range records {
+		ret[i] = r.ID
+	}
+	return ret
+}
+
+func testTrash(t *testing.T, s pkg.StorageV2, clock *testClock) {
+	ctx := context.Background()
+	sheldon := types.SessionOwner("00000000000000000000000000000001")
+	penny := types.UserOwner("penny")
+	start := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+	clock.t = start
+
+	for _, owner := range []types.Owner{sheldon, penny, sheldon} {
+		require.NoError(t, s.AddRecord(ctx, types.GameRecord{Owner: owner, Time: start, Result: types.Win}))
+	}
+	require.NoError(t, s.ClearRecords(ctx, sheldon))
+	records, err := s.GetLastRecords(ctx, sheldon, 10)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+	records, err = s.GetLastRecords(ctx, types.Global, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{2}, recordIDs(records))
+
+	require.NoError(t, s.AddRecord(ctx, types.GameRecord{Owner: sheldon, Time: start, Result: types.Tie}))
+
+	// only the own scores are restored
+	_, err = s.RestoreRecords(ctx, penny)
+	assert.ErrorIs(t, err, types.ErrNoTombstone)
+
+	clock.t = start.Add(30 * time.Minute)
+	tombstone, err := s.RestoreRecords(ctx, sheldon)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), tombstone.ID)
+	assert.Equal(t, sheldon, tombstone.Owner)
+	assert.Equal(t, 2, tombstone.Records)
+	assert.True(t, start.Equal(tombstone.Time))
+	assert.True(t, start.Add(DefaultUndoWindow).Equal(tombstone.Expires))
+
+	// restored records keep their IDs and places
+	records, err = s.GetLastRecords(ctx, sheldon, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{4, 3, 1}, recordIDs(records))
+	records, err = s.GetLastRecords(ctx, types.Global, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{4, 3, 2, 1}, recordIDs(records))
+	page, err := s.History(ctx, types.HistoryQuery{Owner: sheldon, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{4, 3, 1}, ids(page))
+
+	// a tombstone is restored once
+	_, err = s.RestoreRecords(ctx, sheldon)
+	assert.ErrorIs(t, err, types.ErrNoTombstone)
+
+	// and not after it expires
+	require.NoError(t, s.ClearScores())
+	clock.t = clock.t.Add(DefaultUndoWindow)
+	_, err = s.RestoreRecords(ctx, types.Global)
+	assert.ErrorIs(t, err, types.ErrNoTombstone)
+	records, err = s.GetLastRecords(ctx, types.Global, 10)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	entries, err := s.AuditLog(ctx, types.Global, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	for i := range entries {
+		entries[i].Time = entries[i].Time.UTC()
+	}
+	assert.Equal(t, []types.AuditEntry{{
+		ID:        3,
+		Time:      start.Add(30 * time.Minute),
+		Action:    types.AuditScoresCleared,
+		Owner:     types.Global,
+		Tombstone: 2,
+		Records:   4,
+	}, {
+		ID:        2,
+		Time:      start.Add(30 * time.Minute),
+		Action:    types.AuditScoresRestored,
+		Owner:     sheldon,
+		Tombstone: 1,
+		Records:   2,
+	}, {
+		ID:        1,
+		Time:      start,
+		Action:    types.AuditScoresCleared,
+		Owner:     sheldon,
+		Tombstone: 1,
+		Records:   2,
+	}}, entries)
+
+	entries, err = s.AuditLog(ctx, sheldon, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{2}, []uint64{entries[0].ID})
+	entries, err = s.AuditLog(ctx, penny, 10)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestTrashNoUndo(t *testing.T) {
+	ctx := context.Background()
+	s := NewSimple(10, WithUndoWindow(0))
+	require.NoError(t, s.SetLastScore(types.Win))
+	require.NoError(t, s.ClearScores())
+	_, err := s.RestoreRecords(ctx, types.Global)
+	assert.ErrorIs(t, err, types.ErrNoTombstone)
+
+	entries, err := s.AuditLog(ctx, types.Global, 10)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -0,0 +1,16 @@
+package types
+
+import "fmt"
+
+var (
+	ErrBanned    = fmt.Errorf("banned from the game")
+	ErrSeatTaken = fmt.Errorf("seat is taken")
+)
+
+// GameAccess restricts who may join a P2P game.
+type GameAccess struct {
+	// Host is who created the game, they may invite, kick and ban the players. Nobody may for Global.
+	Host Owner
+	// Password the players and spectators have to know, empty for none
+	Password string
+}
@@ -0,0 +1,25 @@
+package types
+
+import "time"
+
+// SideServer is the side of the chat notices sent by the operators.
+const SideServer = "server"
+
+// PlayerStatus describes a player seated in a P2P game.
+type PlayerStatus struct {
+	Name  string `json:"name"`
+	Owner Owner  `json:"owner,omitempty"`
+	// Disconnected is true while their seat is held for them to resume
+	Disconnected bool `json:"disconnected,omitempty"`
+}
+
+// GameStatus describes a running P2P game for the operators.
+type GameStatus struct {
+	GameInfo
+	Host  Owner         `json:"host,omitempty"`
+	Left  *PlayerStatus `json:"left,omitempty"`
+	Right *PlayerStatus `json:"right,omitempty"`
+	// LastActivity is when a player last joined, chose or chatted
+	LastActivity time.Time  `json:"last_activity"`
+	Score        MatchScore `json:"score"`
+}
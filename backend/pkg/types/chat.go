@@ -0,0 +1,59 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+var ErrBadChat = fmt.Errorf("bad chat message")
+var ErrBadEmote = fmt.Errorf("unknown emote")
+var ErrNoPlayer = fmt.Errorf("no player on the side")
+
+// Emote is a reaction a P2P player can send instead of a chat text.
+type Emote string
+
+const (
+	EmoteWave     Emote = "wave"
+	EmoteLaugh    Emote = "laugh"
+	EmoteCry      Emote = "cry"
+	EmoteAngry    Emote = "angry"
+	EmoteThumbsUp Emote = "thumbs_up"
+	EmoteGG       Emote = "gg"
+)
+
+var emotes = map[Emote]bool{
+	EmoteWave:     true,
+	EmoteLaugh:    true,
+	EmoteCry:      true,
+	EmoteAngry:    true,
+	EmoteThumbsUp: true,
+	EmoteGG:       true,
+}
+
+// Valid tells if the emote is one of the known ones.
+func (e Emote) Valid() bool {
+	return emotes[e]
+}
+
+// ChatMessage is a chat text or an emote of a P2P player.
+type ChatMessage struct {
+	// ID grows by one with every message of the game.
+	ID   uint64    `json:"id"`
+	Time time.Time `json:"time"`
+	// Side of the player, "left" or "right", or "server" for the notices of the operators.
+	Side  string `json:"side"`
+	Name  string `json:"name"`
+	Text  string `json:"text,omitempty"`
+	Emote Emote  `json:"emote,omitempty"`
+}
+
+// Update is sent to the P2P players and spectators, it has either the State of the game, a Chat message
+// or the Restart notice.
+type Update struct {
+	// Seq grows by one with every update of a connection, a gap means the updates
+	// were coalesced or dropped for a slow connection.
+	Seq     uint64       `json:"seq"`
+	State   *Message     `json:"state,omitempty"`
+	Chat    *ChatMessage `json:"chat,omitempty"`
+	Restart *Restart     `json:"restart,omitempty"`
+}
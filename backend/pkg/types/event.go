@@ -0,0 +1,86 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type EventType byte
+
+const (
+	EventGamePlayed EventType = iota + 1
+	EventPlayerJoined
+	EventPlayerLeft
+	EventScoresCleared
+	EventScoresRestored
+	EventMatchRated
+	EventGameSaved
+	EventGameDeleted
+)
+
+var eventTypeToString = map[EventType]string{
+	EventGamePlayed:     "game_played",
+	EventPlayerJoined:   "player_joined",
+	EventPlayerLeft:     "player_left",
+	EventScoresCleared:  "scores_cleared",
+	EventScoresRestored: "scores_restored",
+	EventMatchRated:     "match_rated",
+	EventGameSaved:      "game_saved",
+	EventGameDeleted:    "game_deleted",
+}
+
+var stringToEventType = map[string]EventType{
+	"game_played":     EventGamePlayed,
+	"player_joined":   EventPlayerJoined,
+	"player_left":     EventPlayerLeft,
+	"scores_cleared":  EventScoresCleared,
+	"scores_restored": EventScoresRestored,
+	"match_rated":     EventMatchRated,
+	"game_saved":      EventGameSaved,
+	"game_deleted":    EventGameDeleted,
+}
+
+func (t EventType) String() string {
+	return eventTypeToString[t]
+}
+
+func (t EventType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+func (t *EventType) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	eventType, ok := stringToEventType[s]
+	if !ok {
+		return fmt.Errorf("invalid event type: %s", s)
+	}
+	*t = eventType
+	return nil
+}
+
+// Event is a domain event, the fields besides the type and time depend on the type:
+//   - GamePlayed has the Record
+//   - PlayerJoined and PlayerLeft have the GameID, PlayerName and Owner of the P2P player
+//   - ScoresCleared has the Owner, types.Global for everyone, and the Tombstone of the removed records
+//   - ScoresRestored has the Owner and the Tombstone of the restored records
+//   - MatchRated has the Match
+//   - GameSaved has the Game snapshot of the running P2P game
+//   - GameDeleted has the GameID of the ended P2P game
+type Event struct {
+	// Seq is assigned by the event log, it grows by one with every event.
+	Seq  uint64    `json:"seq"`
+	Type EventType `json:"type"`
+	Time time.Time `json:"time"`
+
+	Record     *GameRecord   `json:"record,omitempty"`
+	GameID     GameID        `json:"game_id,omitempty"`
+	PlayerName string        `json:"player_name,omitempty"`
+	Owner      Owner         `json:"owner,omitempty"`
+	Tombstone  *Tombstone    `json:"tombstone,omitempty"`
+	Match      *MatchResult  `json:"match,omitempty"`
+	Game       *GameSnapshot `json:"game,omitempty"`
+}
@@ -0,0 +1,92 @@
+package types
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HistoryQuery selects a page of game records, newest first unless Ascending is set.
+// Nil and zero filters match everything.
+type HistoryQuery struct {
+	Owner Owner
+	Limit int
+	// Before limits the page to records with ID lower than this one, zero means no limit.
+	Before uint64
+	// After limits the page to records with ID greater than this one.
+	After uint64
+	// Ascending lists the oldest records first.
+	Ascending bool
+	// Mirrors keeps the mirror records of the P2P rounds in the global history,
+	// which lists every round once without them, as the global scoreboard does.
+	Mirrors bool
+
+	Result         *Result
+	Choice         *Choice
+	OpponentChoice *Choice
+	Mode           *GameMode
+	// Since is inclusive, Until is exclusive.
+	Since time.Time
+	Until time.Time
+}
+
+// Matches returns true if the record passes the filters and bounds of the query, except for the owner and the limit.
+func (q HistoryQuery) Matches(r GameRecord) bool {
+	if q.Before != 0 && r.ID >= q.Before || r.ID <= q.After {
+		return false
+	}
+	if q.Owner == Global && r.Mirror && !q.Mirrors {
+		return false
+	}
+	if q.Result != nil && r.Result != *q.Result {
+		return false
+	}
+	if q.Choice != nil && r.PlayerChoice != *q.Choice {
+		return false
+	}
+	if q.OpponentChoice != nil && r.OpponentChoice != *q.OpponentChoice {
+		return false
+	}
+	if q.Mode != nil && r.Mode != *q.Mode {
+		return false
+	}
+	if !q.Since.IsZero() && r.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !r.Time.Before(q.Until) {
+		return false
+	}
+	return true
+}
+
+// HistoryPage is a page of game records in the order of the query.
+type HistoryPage struct {
+	Records []GameRecord `json:"records"`
+	// Cursor continues the listing after this page, empty if there are no more records.
+	Cursor string `json:"cursor,omitempty"`
+}
+
+const cursorPrefix = "v1:"
+
+// HistoryCursor returns an opaque cursor continuing after the record with the given ID.
+func HistoryCursor(id uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.FormatUint(id, 10)))
+}
+
+// ParseHistoryCursor returns the record ID the cursor continues after.
+func ParseHistoryCursor(cursor string) (uint64, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("decode cursor: %w", err)
+	}
+	if !strings.HasPrefix(string(data), cursorPrefix) {
+		return 0, fmt.Errorf("unknown cursor version")
+	}
+	id, err := strconv.ParseUint(strings.TrimPrefix(string(data), cursorPrefix), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse cursor: %w", err)
+	}
+	return id, nil
+}
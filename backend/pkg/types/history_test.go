@@ -0,0 +1,70 @@
+package types
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryCursor(t *testing.T) {
+	for _, id := range []uint64{1, 42, 0xffffffffffffffff} {
+		got, err := ParseHistoryCursor(HistoryCursor(id))
+		assert.NoError(t, err)
+		assert.Equal(t, id, got)
+	}
+
+	for _, bad := range []string{
+		"!!!",
+		base64.RawURLEncoding.EncodeToString([]byte("v2:42")),
+		base64.RawURLEncoding.EncodeToString([]byte("v1:abc")),
+	} {
+		_, err := ParseHistoryCursor(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestHistoryQueryMatches(t *testing.T) {
+	now := time.Now()
+	record := GameRecord{
+		ID:             42,
+		Time:           now,
+		Mode:           ModeComputer,
+		PlayerChoice:   Rock,
+		OpponentChoice: Spock,
+		Result:         Lose,
+	}
+	lose, win := Lose, Win
+	rock, spock := Rock, Spock
+	computer, p2p := ModeComputer, ModeP2P
+
+	tests := []struct {
+		name  string
+		query HistoryQuery
+		want  bool
+	}{
+		{"empty", HistoryQuery{}, true},
+		{"result", HistoryQuery{Result: &lose}, true},
+		{"other result", HistoryQuery{Result: &win}, false},
+		{"choice", HistoryQuery{Choice: &rock}, true},
+		{"other choice", HistoryQuery{Choice: &spock}, false},
+		{"opponent choice", HistoryQuery{OpponentChoice: &spock}, true},
+		{"other opponent choice", HistoryQuery{OpponentChoice: &rock}, false},
+		{"mode", HistoryQuery{Mode: &computer}, true},
+		{"other mode", HistoryQuery{Mode: &p2p}, false},
+		{"since is inclusive", HistoryQuery{Since: now}, true},
+		{"since", HistoryQuery{Since: now.Add(time.Second)}, false},
+		{"until is exclusive", HistoryQuery{Until: now}, false},
+		{"until", HistoryQuery{Until: now.Add(time.Second)}, true},
+		{"before", HistoryQuery{Before: 43}, true},
+		{"before is exclusive", HistoryQuery{Before: 42}, false},
+		{"after", HistoryQuery{After: 41}, true},
+		{"after is exclusive", HistoryQuery{After: 42}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, test.query.Matches(record))
+		})
+	}
+}
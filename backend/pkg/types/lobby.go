@@ -0,0 +1,19 @@
+package types
+
+import "time"
+
+// GameInfo describes an open public P2P game in the lobby.
+type GameInfo struct {
+	ID GameID `json:"id"`
+	// Creator is the name of the first player who took a seat, empty until then
+	Creator string `json:"creator"`
+	GameSettings
+	Created time.Time `json:"created"`
+	// Age is how many seconds ago the game was created
+	Age int `json:"age"`
+	// Players is the number of the taken seats out of two
+	Players    int `json:"players"`
+	Spectators int `json:"spectators"`
+	// Protected is true if a password is needed to join
+	Protected bool `json:"protected,omitempty"`
+}
@@ -0,0 +1,62 @@
+package types
+
+// Sides of a P2P game
+const (
+	SideLeft  = "left"
+	SideRight = "right"
+)
+
+// Tally counts the round results of a side.
+type Tally struct {
+	Win  int `json:"win"`
+	Lose int `json:"lose"`
+	Tie  int `json:"tie"`
+}
+
+func (t *Tally) count(r Result) {
+	switch r {
+	case Win:
+		t.Win++
+	case Lose:
+		t.Lose++
+	case Tie:
+		t.Tie++
+	}
+}
+
+// MatchScore is the score of the P2P match between the players currently in the game.
+type MatchScore struct {
+	// Round is the number of the round being played, starting from 1.
+	// With the result of a round it's the number of that round.
+	Round int   `json:"round"`
+	Left  Tally `json:"left"`
+	Right Tally `json:"right"`
+	// Winner is the side with more wins, empty while the sides are even.
+	Winner string `json:"winner"`
+	// BestOf is the number of rounds of the match, 0 if it has no end.
+	BestOf int `json:"best_of"`
+	// Over tells that a side has won most of the rounds of the match.
+	Over bool `json:"over"`
+}
+
+func NewMatchScore(bestOf int) MatchScore {
+	return MatchScore{Round: 1, BestOf: bestOf}
+}
+
+// Count adds the result of the round for the left side to the tallies.
+func (s *MatchScore) Count(r Result) {
+	s.Left.count(r)
+	s.Right.count(r.Swap())
+
+	switch {
+	case s.Left.Win > s.Right.Win:
+		s.Winner = SideLeft
+	case s.Left.Win < s.Right.Win:
+		s.Winner = SideRight
+	default:
+		s.Winner = ""
+	}
+	if s.BestOf > 0 && (s.Left.Win > s.BestOf/2 || s.Right.Win > s.BestOf/2) {
+		s.Over = true
+	}
+}
@@ -0,0 +1,36 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchScore(t *testing.T) {
+	s := NewMatchScore(0)
+	assert.Equal(t, 1, s.Round)
+
+	s.Count(Win)
+	assert.Equal(t, SideLeft, s.Winner)
+	s.Count(Lose)
+	assert.Equal(t, "", s.Winner)
+	s.Count(Tie)
+	s.Count(Lose)
+	assert.Equal(t, MatchScore{
+		Round:  1,
+		Left:   Tally{Win: 1, Lose: 2, Tie: 1},
+		Right:  Tally{Win: 2, Lose: 1, Tie: 1},
+		Winner: SideRight,
+	}, s)
+}
+
+func TestMatchScoreBestOf(t *testing.T) {
+	s := NewMatchScore(3)
+	s.Count(Win)
+	s.Count(Tie)
+	s.Count(Lose)
+	assert.False(t, s.Over)
+	s.Count(Lose)
+	assert.True(t, s.Over)
+	assert.Equal(t, SideRight, s.Winner)
+}
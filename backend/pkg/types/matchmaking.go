@@ -0,0 +1,23 @@
+package types
+
+import "fmt"
+
+var ErrNoMatch = fmt.Errorf("no match")
+
+// MatchRequest is a player looking for an opponent.
+type MatchRequest struct {
+	Name  string
+	Owner Owner
+	// Settings of the game the player wants to play
+	Settings GameSettings
+}
+
+// Match is the seat the player got in a P2P game.
+type Match struct {
+	GameID GameID `json:"game_id"`
+	Side   string `json:"side"`
+	// Resume is the token to connect to the seat with
+	Resume   string       `json:"resume"`
+	Opponent string       `json:"opponent"`
+	Settings GameSettings `json:"settings"`
+}
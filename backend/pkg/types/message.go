@@ -6,4 +6,14 @@ type Message struct {
 	LeftPlayerChoice  Choice `json:"left_player_choice"`
 	RightPlayerChoice Choice `json:"right_player_choice"`
 	Result            Result `json:"result"`
+	// LeftPlayerDisconnected tells that the connection of the left player is lost,
+	// and their seat is held for them to resume
+	LeftPlayerDisconnected  bool `json:"left_player_disconnected"`
+	RightPlayerDisconnected bool `json:"right_player_disconnected"`
+	// Spectators is the number of people watching the game
+	Spectators int `json:"spectators"`
+	// Score is the score of the match, including the result
+	Score MatchScore `json:"score"`
+	// Settings are the rules of the game
+	Settings GameSettings `json:"settings"`
 }
@@ -0,0 +1,56 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Owner identifies whose scoreboard a game record belongs to:
+// an anonymous session or an authenticated user.
+type Owner string
+
+// Global is the aggregate view over all the owners.
+const Global Owner = ""
+
+const (
+	sessionPrefix = "session:"
+	userPrefix    = "user:"
+)
+
+func SessionOwner(sessionID string) Owner {
+	return Owner(sessionPrefix + sessionID)
+}
+
+func UserOwner(user string) Owner {
+	return Owner(userPrefix + user)
+}
+
+func (o Owner) String() string {
+	return string(o)
+}
+
+// Public returns the owner as it may be shown to others. The session IDs are secrets
+// which would let anyone act as the session, so the sessions are hidden as Global.
+func (o Owner) Public() Owner {
+	if o.IsUser() {
+		return o
+	}
+	return Global
+}
+
+// IsUser returns true if the owner is an authenticated user.
+func (o Owner) IsUser() bool {
+	return strings.HasPrefix(string(o), userPrefix)
+}
+
+// OwnerFromString parses owner previously formatted with String.
+func OwnerFromString(s string) (Owner, error) {
+	if s == "" {
+		return Global, nil
+	}
+	if strings.HasPrefix(s, sessionPrefix) && len(s) > len(sessionPrefix) ||
+		strings.HasPrefix(s, userPrefix) && len(s) > len(userPrefix) {
+		return Owner(s), nil
+	}
+	return Global, fmt.Errorf("invalid owner: %s", s)
+}
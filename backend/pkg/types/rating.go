@@ -0,0 +1,61 @@
+package types
+
+import (
+	"strings"
+	"time"
+)
+
+// The rating of a player who hasn't played rated matches yet
+const (
+	InitialRating     = 1500.
+	InitialDeviation  = 350.
+	InitialVolatility = 0.06
+)
+
+// Rating is the Glicko-2 rating of a registered player.
+type Rating struct {
+	Owner Owner `json:"-"`
+	// Player is the name of the user
+	Player string  `json:"player"`
+	Rating float64 `json:"rating"`
+	// Deviation is how uncertain the rating is, it goes down with every rated match
+	Deviation  float64 `json:"deviation"`
+	Volatility float64 `json:"volatility"`
+	// Games is the number of rated matches played
+	Games int `json:"games"`
+}
+
+func NewRating(owner Owner) Rating {
+	return Rating{
+		Owner:      owner,
+		Player:     owner.User(),
+		Rating:     InitialRating,
+		Deviation:  InitialDeviation,
+		Volatility: InitialVolatility,
+	}
+}
+
+// LeaderboardEntry is the rating of a player with their place among everyone, starting from 1.
+type LeaderboardEntry struct {
+	Rank int `json:"rank"`
+	Rating
+}
+
+// MatchResult is a finished P2P match between two registered players.
+type MatchResult struct {
+	// ID is unique for every match, a match is rated only once
+	ID    string    `json:"id"`
+	Time  time.Time `json:"time"`
+	Left  Owner     `json:"left"`
+	Right Owner     `json:"right"`
+	// Winner is the side which won the match, empty for a draw
+	Winner string `json:"winner,omitempty"`
+}
+
+// User returns the name of the authenticated user, empty for the others.
+func (o Owner) User() string {
+	if !o.IsUser() {
+		return ""
+	}
+	return strings.TrimPrefix(string(o), userPrefix)
+}
@@ -0,0 +1,90 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type GameMode byte
+
+const (
+	ModeComputer GameMode = iota
+	ModeP2P
+)
+
+var modeToString = map[GameMode]string{
+	ModeComputer: "computer",
+	ModeP2P:      "p2p",
+}
+
+var stringToMode = map[string]GameMode{
+	"computer": ModeComputer,
+	"p2p":      ModeP2P,
+}
+
+func (m GameMode) String() string {
+	return modeToString[m]
+}
+
+func (m GameMode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+func (m *GameMode) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	mode, err := GameModeFromString(s)
+	if err != nil {
+		return err
+	}
+	*m = mode
+	return nil
+}
+
+func GameModeFromString(s string) (GameMode, error) {
+	mode, ok := stringToMode[s]
+	if !ok {
+		return ModeComputer, fmt.Errorf("invalid game mode: %s", s)
+	}
+	return mode, nil
+}
+
+// GameRecord is a single played game as seen by one of the players.
+// P2P rounds produce a record for each side, the one of the right side is the mirror.
+type GameRecord struct {
+	// ID is assigned by the storage, it grows with every stored record.
+	ID uint64 `json:"id,omitempty"`
+	// Owner is the scoreboard the record belongs to.
+	Owner Owner `json:"owner,omitempty"`
+	// Time is when the game result was determined.
+	Time time.Time `json:"time"`
+	// Mode tells whether the game was played against the computer or another player.
+	Mode GameMode `json:"mode"`
+	// GameID is the P2P game the round was played in, zero for computer games.
+	GameID GameID `json:"game_id,omitempty"`
+
+	PlayerName   string `json:"player_name"`
+	OpponentName string `json:"opponent_name"`
+	// Choices are Undefined for records stored through the legacy API.
+	PlayerChoice   Choice `json:"player_choice,omitempty"`
+	OpponentChoice Choice `json:"opponent_choice,omitempty"`
+	// Result is the result for the player.
+	Result Result `json:"result"`
+	// Mirror is the record of the right player of a P2P round, which is the left player's round seen
+	// from the other side. The global scoreboard and statistics skip it to count the round once.
+	Mirror bool `json:"mirror,omitempty"`
+
+	// Strategy is how the computer made its choice, empty for P2P games.
+	Strategy string `json:"strategy,omitempty"`
+	// RNGSource is the random number provider used by the computer, empty for P2P games.
+	RNGSource string `json:"rng_source,omitempty"`
+}
+
+// Public returns the record as it may be shown to others, without the session of the owner.
+func (r GameRecord) Public() GameRecord {
+	r.Owner = r.Owner.Public()
+	return r
+}
@@ -0,0 +1,90 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGameModeJSON(t *testing.T) {
+	tests := []struct {
+		mode GameMode
+		want string
+	}{
+		{ModeComputer, `"computer"`},
+		{ModeP2P, `"p2p"`},
+	}
+	for _, test := range tests {
+		t.Run(test.mode.String(), func(t *testing.T) {
+			got, err := json.Marshal(test.mode)
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, string(got))
+
+			var m GameMode
+			assert.NoError(t, json.Unmarshal(got, &m))
+			assert.Equal(t, test.mode, m)
+		})
+	}
+
+	var m GameMode
+	assert.Error(t, json.Unmarshal([]byte(`"invalid"`), &m))
+	assert.Error(t, json.Unmarshal([]byte(`1`), &m))
+}
+
+func TestGameRecordJSON(t *testing.T) {
+	record := GameRecord{
+		Time:           time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC),
+		Mode:           ModeP2P,
+		GameID:         0xc33,
+		PlayerName:     "Sheldon",
+		OpponentName:   "Raj",
+		PlayerChoice:   Spock,
+		OpponentChoice: Lizard,
+		Result:         Lose,
+	}
+	got, err := json.Marshal(record)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"time": "2023-04-01T12:00:00Z",
+		"mode": "p2p",
+		"game_id": "0000000000000c33",
+		"player_name": "Sheldon",
+		"opponent_name": "Raj",
+		"player_choice": {"id": 5, "name": "spock"},
+		"opponent_choice": {"id": 4, "name": "lizard"},
+		"result": "lose"
+	}`, string(got))
+
+	var decoded GameRecord
+	assert.NoError(t, json.Unmarshal(got, &decoded))
+	assert.Equal(t, record, decoded)
+}
+
+func TestOwnerFromString(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    Owner
+		wantErr bool
+	}{
+		{"", Global, false},
+		{"session:0123", SessionOwner("0123"), false},
+		{"user:sheldon", UserOwner("sheldon"), false},
+		{"session:", Global, true},
+		{"user:", Global, true},
+		{"sheldon", Global, true},
+	}
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			got, err := OwnerFromString(test.s)
+			assert.Equal(t, test.wantErr, err != nil)
+			assert.Equal(t, test.want, got)
+			if !test.wantErr {
+				assert.Equal(t, test.s, got.String())
+			}
+		})
+	}
+	assert.True(t, UserOwner("sheldon").IsUser())
+	assert.False(t, SessionOwner("0123").IsUser())
+}
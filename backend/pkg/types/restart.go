@@ -0,0 +1,14 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+var ErrShuttingDown = fmt.Errorf("server is shutting down")
+
+// Restart tells the P2P players that the server is restarting: no new rounds can be started,
+// and the game ends once the round being played is over, at the latest by the Deadline.
+type Restart struct {
+	Deadline time.Time `json:"deadline"`
+}
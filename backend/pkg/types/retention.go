@@ -0,0 +1,37 @@
+package types
+
+import "time"
+
+// RetentionPolicy bounds which game records are kept, zero fields disable their bound.
+type RetentionPolicy struct {
+	// MaxAge is the age after which records expire.
+	MaxAge time.Duration
+	// MaxPerOwner is the number of newest records kept for every owner.
+	MaxPerOwner int
+}
+
+// Cutoff returns the time before which records expire, zero time if the age is unbounded.
+func (p RetentionPolicy) Cutoff(now time.Time) time.Time {
+	if p.MaxAge <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-p.MaxAge)
+}
+
+// DailyAggregate sums up the results of an owner's expired records of one UTC day.
+type DailyAggregate struct {
+	Owner Owner `json:"owner,omitempty"`
+	// Day is the midnight UTC the day starts at.
+	Day    time.Time `json:"day"`
+	Games  int       `json:"games"`
+	Wins   int       `json:"wins"`
+	Losses int       `json:"losses"`
+	Ties   int       `json:"ties"`
+	// Mirror aggregates the mirror records of P2P rounds, which the global statistics skip.
+	Mirror bool `json:"mirror,omitempty"`
+}
+
+// Day returns the midnight UTC of the day of t.
+func Day(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
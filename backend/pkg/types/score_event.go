@@ -0,0 +1,43 @@
+package types
+
+// ScoreEventType is the kind of a scoreboard change.
+type ScoreEventType string
+
+const (
+	// ScorePlayed adds the record to the owner's scoreboard.
+	ScorePlayed ScoreEventType = "played"
+	// ScoresCleared empties the owner's scoreboard, or every one for types.Global.
+	ScoresCleared ScoreEventType = "cleared"
+	// ScoresRestored brings back the records of the owner's cleared scoreboard, or every one for types.Global.
+	ScoresRestored ScoreEventType = "restored"
+)
+
+// ScoreEvent is a change of a scoreboard, streamed to the watchers.
+type ScoreEvent struct {
+	// ID grows with every change, watchers resume after the last ID they've seen.
+	ID     uint64         `json:"id"`
+	Type   ScoreEventType `json:"type"`
+	Owner  Owner          `json:"owner,omitempty"`
+	Record *GameRecord    `json:"record,omitempty"`
+	// Stats of the watched scoreboard after the change, if statistics are enabled.
+	Stats *Stats `json:"stats,omitempty"`
+}
+
+// Public returns the event as it may be shown to others, without the sessions of the owners.
+func (e ScoreEvent) Public() ScoreEvent {
+	e.Owner = e.Owner.Public()
+	if e.Record != nil {
+		record := e.Record.Public()
+		e.Record = &record
+	}
+	return e
+}
+
+// Concerns returns true if the event changes the scoreboard of the owner,
+// every event but the mirror records concerns the global one.
+func (e ScoreEvent) Concerns(owner Owner) bool {
+	if owner == Global {
+		return e.Record == nil || !e.Record.Mirror
+	}
+	return e.Owner == owner || e.Type != ScorePlayed && e.Owner == Global
+}
@@ -0,0 +1,62 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var ErrBadName = fmt.Errorf("bad name")
+var ErrBadChoice = fmt.Errorf("choice is not in the ruleset")
+
+var playerNameRe = regexp.MustCompile(`^[a-zA-Z ]{0,20}$`)
+
+// ValidPlayerName tells if the name of a P2P player has only Latin letters and spaces, and is not longer than 20.
+func ValidPlayerName(name string) bool {
+	return playerNameRe.MatchString(name)
+}
+
+// Ruleset is the set of the choices of a P2P game.
+type Ruleset string
+
+const (
+	// RulesetRPSSL is Rock, Paper, Scissors, Lizard, Spock, the default
+	RulesetRPSSL Ruleset = "rpssl"
+	// RulesetClassic is Rock, Paper, Scissors
+	RulesetClassic Ruleset = "classic"
+)
+
+// Allows tells if the choice is in the ruleset.
+func (r Ruleset) Allows(c Choice) bool {
+	if r == RulesetClassic {
+		return c >= Rock && c <= Scissors
+	}
+	return c >= Rock && c <= Spock
+}
+
+// maxBestOf limits the number of rounds of a match
+const maxBestOf = 99
+
+// GameSettings are the rules of a P2P game.
+type GameSettings struct {
+	Ruleset Ruleset `json:"ruleset"`
+	// BestOf is the number of rounds of the match, which is over when a side has won most of them.
+	// 0 plays without end.
+	BestOf int `json:"best_of"`
+	// Public games are listed in the lobby for anyone to join
+	Public bool `json:"public,omitempty"`
+}
+
+// Normalize fills the defaults in and checks the settings.
+func (s GameSettings) Normalize() (GameSettings, error) {
+	switch s.Ruleset {
+	case "":
+		s.Ruleset = RulesetRPSSL
+	case RulesetRPSSL, RulesetClassic:
+	default:
+		return s, fmt.Errorf("unknown ruleset %q", s.Ruleset)
+	}
+	if s.BestOf < 0 || s.BestOf > maxBestOf || s.BestOf%2 == 0 && s.BestOf != 0 {
+		return s, fmt.Errorf("best of must be an odd number up to %d, or 0", maxBestOf)
+	}
+	return s, nil
+}
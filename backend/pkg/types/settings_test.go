@@ -0,0 +1,34 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGameSettingsNormalize(t *testing.T) {
+	s, err := GameSettings{}.Normalize()
+	assert.NoError(t, err)
+	assert.Equal(t, GameSettings{Ruleset: RulesetRPSSL}, s)
+
+	s, err = GameSettings{Ruleset: RulesetClassic, BestOf: 5}.Normalize()
+	assert.NoError(t, err)
+	assert.Equal(t, GameSettings{Ruleset: RulesetClassic, BestOf: 5}, s)
+
+	for _, s := range []GameSettings{
+		{Ruleset: "chess"},
+		{BestOf: 4},
+		{BestOf: -1},
+		{BestOf: 101},
+	} {
+		_, err := s.Normalize()
+		assert.Error(t, err, s)
+	}
+}
+
+func TestRulesetAllows(t *testing.T) {
+	assert.True(t, RulesetClassic.Allows(Scissors))
+	assert.False(t, RulesetClassic.Allows(Lizard))
+	assert.True(t, RulesetRPSSL.Allows(Spock))
+	assert.False(t, RulesetRPSSL.Allows(Undefined))
+}
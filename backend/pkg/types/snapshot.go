@@ -0,0 +1,39 @@
+package types
+
+import "time"
+
+// SeatSnapshot is a player seated in a GameSnapshot.
+type SeatSnapshot struct {
+	Name  string `json:"name"`
+	Owner Owner  `json:"owner,omitempty"`
+	// TokenHash is the SHA-256 of the token letting the player resume after the restart,
+	// the token itself isn't stored
+	TokenHash string `json:"token_hash"`
+	// Choice made in the round being played
+	Choice Choice `json:"choice,omitempty"`
+}
+
+// GameSnapshot is the state of a running P2P game, kept to restore the game after a restart.
+type GameSnapshot struct {
+	ID       GameID       `json:"id"`
+	Settings GameSettings `json:"settings"`
+	Created  time.Time    `json:"created"`
+	// Active is when a player last joined, chose or chatted
+	Active time.Time `json:"active"`
+	// Expires is when the game ends unless somebody plays it
+	Expires time.Time `json:"expires"`
+	Creator string    `json:"creator,omitempty"`
+	Host    Owner     `json:"host,omitempty"`
+	// PasswordHash is the bcrypt hash of the password, empty if there's none
+	PasswordHash []byte  `json:"password_hash,omitempty"`
+	Banned       []Owner `json:"banned,omitempty"`
+
+	Left  *SeatSnapshot `json:"left,omitempty"`
+	Right *SeatSnapshot `json:"right,omitempty"`
+	Score MatchScore    `json:"score"`
+	// Match is the number of the current match in the game
+	Match int `json:"match"`
+	// Chat is the newest messages only
+	Chat       []ChatMessage `json:"chat,omitempty"`
+	LastChatID uint64        `json:"last_chat_id"`
+}
@@ -0,0 +1,193 @@
+package types
+
+import (
+	"encoding/json"
+)
+
+// number of real choices, from Rock to Spock
+const choicesCount = int(Spock)
+
+// ChoiceCounts counts games by choice, indexed by Choice-1.
+type ChoiceCounts [choicesCount]int
+
+func (c ChoiceCounts) MarshalJSON() ([]byte, error) {
+	m := make(map[string]int, choicesCount)
+	for i, n := range c {
+		m[Choice(i+1).String()] = n
+	}
+	return json.Marshal(m)
+}
+
+func (c *ChoiceCounts) UnmarshalJSON(data []byte) error {
+	var m map[string]int
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	*c = ChoiceCounts{}
+	for name, n := range m {
+		choice, ok := stringToChoice[name]
+		if !ok {
+			continue
+		}
+		c[choice-1] = n
+	}
+	return nil
+}
+
+// Matchups counts games by player choice against opponent choice,
+// indexed by player Choice-1 and then by opponent Choice-1.
+type Matchups [choicesCount]ChoiceCounts
+
+func (m Matchups) MarshalJSON() ([]byte, error) {
+	ret := make(map[string]ChoiceCounts, choicesCount)
+	for i, row := range m {
+		ret[Choice(i+1).String()] = row
+	}
+	return json.Marshal(ret)
+}
+
+func (m *Matchups) UnmarshalJSON(data []byte) error {
+	var rows map[string]ChoiceCounts
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return err
+	}
+	*m = Matchups{}
+	for name, row := range rows {
+		choice, ok := stringToChoice[name]
+		if !ok {
+			continue
+		}
+		m[choice-1] = row
+	}
+	return nil
+}
+
+// Streak is a run of consecutive equal results.
+type Streak struct {
+	Result Result `json:"result"`
+	Length int    `json:"length"`
+}
+
+// Stats are aggregate statistics over a set of game records, from the player's point of view.
+// Records are added one by one, oldest first.
+type Stats struct {
+	Games  int `json:"games"`
+	Wins   int `json:"wins"`
+	Losses int `json:"losses"`
+	Ties   int `json:"ties"`
+
+	WinRate  float64 `json:"win_rate"`
+	LoseRate float64 `json:"lose_rate"`
+	TieRate  float64 `json:"tie_rate"`
+
+	// Choices is how often the player made each choice.
+	Choices ChoiceCounts `json:"choices"`
+
+	// CurrentStreak is nil until the first game.
+	CurrentStreak     *Streak `json:"current_streak"`
+	LongestWinStreak  int     `json:"longest_win_streak"`
+	LongestLoseStreak int     `json:"longest_lose_streak"`
+
+	// Matchups is the matrix of player choices against opponent choices.
+	Matchups Matchups `json:"matchups"`
+}
+
+// Add accounts the record in the statistics.
+func (s *Stats) Add(r GameRecord) {
+	switch r.Result {
+	case Win:
+		s.Wins++
+	case Lose:
+		s.Losses++
+	case Tie:
+		s.Ties++
+	default:
+		return
+	}
+	s.Games++
+	s.WinRate = float64(s.Wins) / float64(s.Games)
+	s.LoseRate = float64(s.Losses) / float64(s.Games)
+	s.TieRate = float64(s.Ties) / float64(s.Games)
+
+	if s.CurrentStreak != nil && s.CurrentStreak.Result == r.Result {
+		s.CurrentStreak.Length++
+	} else {
+		s.CurrentStreak = &Streak{Result: r.Result, Length: 1}
+	}
+	if r.Result == Win && s.CurrentStreak.Length > s.LongestWinStreak {
+		s.LongestWinStreak = s.CurrentStreak.Length
+	}
+	if r.Result == Lose && s.CurrentStreak.Length > s.LongestLoseStreak {
+		s.LongestLoseStreak = s.CurrentStreak.Length
+	}
+
+	// legacy records have no choices
+	if r.PlayerChoice < Rock || r.PlayerChoice > Spock {
+		return
+	}
+	s.Choices[r.PlayerChoice-1]++
+	if r.OpponentChoice < Rock || r.OpponentChoice > Spock {
+		return
+	}
+	s.Matchups[r.PlayerChoice-1][r.OpponentChoice-1]++
+}
+
+// AddAggregate accounts the results of records rolled up into the aggregate.
+// Streaks, choices and matchups of those records are lost.
+func (s *Stats) AddAggregate(a DailyAggregate) {
+	s.Wins += a.Wins
+	s.Losses += a.Losses
+	s.Ties += a.Ties
+	s.Games += a.Wins + a.Losses + a.Ties
+	s.updateRates()
+}
+
+// Merge accounts the counts of the other statistics, the streaks are left as they are.
+func (s *Stats) Merge(o Stats) {
+	s.Games += o.Games
+	s.Wins += o.Wins
+	s.Losses += o.Losses
+	s.Ties += o.Ties
+	for i := range s.Choices {
+		s.Choices[i] += o.Choices[i]
+		for j := range s.Matchups[i] {
+			s.Matchups[i][j] += o.Matchups[i][j]
+		}
+	}
+	s.updateRates()
+}
+
+// Subtract removes the counts of the other statistics, which were merged or added before.
+// The streaks can't be taken back and are left as they are.
+func (s *Stats) Subtract(o Stats) {
+	s.Games -= o.Games
+	s.Wins -= o.Wins
+	s.Losses -= o.Losses
+	s.Ties -= o.Ties
+	for i := range s.Choices {
+		s.Choices[i] -= o.Choices[i]
+		for j := range s.Matchups[i] {
+			s.Matchups[i][j] -= o.Matchups[i][j]
+		}
+	}
+	s.updateRates()
+}
+
+func (s *Stats) updateRates() {
+	if s.Games == 0 {
+		s.WinRate, s.LoseRate, s.TieRate = 0, 0, 0
+		return
+	}
+	s.WinRate = float64(s.Wins) / float64(s.Games)
+	s.LoseRate = float64(s.Losses) / float64(s.Games)
+	s.TieRate = float64(s.Ties) / float64(s.Games)
+}
+
+// Clone returns a deep copy of the statistics.
+func (s Stats) Clone() Stats {
+	if s.CurrentStreak != nil {
+		streak := *s.CurrentStreak
+		s.CurrentStreak = &streak
+	}
+	return s
+}
@@ -0,0 +1,98 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsAdd(t *testing.T) {
+	var s Stats
+	for _, r := range []GameRecord{
+		{PlayerChoice: Rock, OpponentChoice: Scissors, Result: Win},
+		{PlayerChoice: Rock, OpponentChoice: Lizard, Result: Win},
+		{PlayerChoice: Paper, OpponentChoice: Paper, Result: Tie},
+		{PlayerChoice: Spock, OpponentChoice: Lizard, Result: Lose},
+		{Result: Lose}, // legacy record without choices
+		{PlayerChoice: Rock, OpponentChoice: Scissors, Result: Win},
+		{PlayerChoice: Rock, OpponentChoice: Rock, Result: Unknown},
+	} {
+		s.Add(r)
+	}
+
+	assert.Equal(t, 6, s.Games)
+	assert.Equal(t, 3, s.Wins)
+	assert.Equal(t, 2, s.Losses)
+	assert.Equal(t, 1, s.Ties)
+	assert.InDelta(t, 0.5, s.WinRate, 1e-9)
+	assert.InDelta(t, 1.0/3, s.LoseRate, 1e-9)
+	assert.InDelta(t, 1.0/6, s.TieRate, 1e-9)
+	assert.Equal(t, ChoiceCounts{3, 1, 0, 0, 1}, s.Choices)
+	assert.Equal(t, &Streak{Result: Win, Length: 1}, s.CurrentStreak)
+	assert.Equal(t, 2, s.LongestWinStreak)
+	assert.Equal(t, 2, s.LongestLoseStreak)
+	assert.Equal(t, 2, s.Matchups[Rock-1][Scissors-1])
+	assert.Equal(t, 1, s.Matchups[Rock-1][Lizard-1])
+	assert.Equal(t, 1, s.Matchups[Paper-1][Paper-1])
+	assert.Equal(t, 1, s.Matchups[Spock-1][Lizard-1])
+	assert.Equal(t, 0, s.Matchups[Rock-1][Rock-1])
+}
+
+func TestStatsMerge(t *testing.T) {
+	var s, o Stats
+	s.Add(GameRecord{PlayerChoice: Rock, OpponentChoice: Paper, Result: Lose})
+	o.Add(GameRecord{PlayerChoice: Spock, OpponentChoice: Rock, Result: Win})
+	o.Add(GameRecord{PlayerChoice: Spock, OpponentChoice: Rock, Result: Win})
+
+	s.Merge(o)
+	assert.Equal(t, 3, s.Games)
+	assert.Equal(t, 2, s.Wins)
+	assert.InDelta(t, 2.0/3, s.WinRate, 1e-9)
+	assert.Equal(t, 2, s.Matchups[Spock-1][Rock-1])
+	assert.Equal(t, &Streak{Result: Lose, Length: 1}, s.CurrentStreak, "streaks are left as they are")
+
+	s.Subtract(o)
+	assert.Equal(t, 1, s.Games)
+	assert.Equal(t, 0, s.Wins)
+	assert.Equal(t, 1.0, s.LoseRate)
+	assert.Equal(t, 0, s.Choices[Spock-1])
+	assert.Equal(t, 0, s.Matchups[Spock-1][Rock-1])
+
+	s.Subtract(Stats{Games: 1, Losses: 1, Choices: ChoiceCounts{1}})
+	assert.Equal(t, 0.0, s.LoseRate)
+}
+
+func TestStatsClone(t *testing.T) {
+	var s Stats
+	s.Add(GameRecord{Result: Win})
+	c := s.Clone()
+	s.Add(GameRecord{Result: Win})
+
+	assert.Equal(t, 2, s.CurrentStreak.Length)
+	assert.Equal(t, 1, c.CurrentStreak.Length)
+}
+
+func TestStatsJSON(t *testing.T) {
+	var s Stats
+	data, err := json.Marshal(s)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"current_streak":null`)
+
+	s.Add(GameRecord{PlayerChoice: Lizard, OpponentChoice: Spock, Result: Win})
+	data, err = json.Marshal(s)
+	assert.NoError(t, err)
+
+	var decoded map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.JSONEq(t, `{"rock":0,"paper":0,"scissors":0,"lizard":1,"spock":0}`, string(decoded["choices"]))
+	assert.JSONEq(t, `{"result":"win","length":1}`, string(decoded["current_streak"]))
+	var matchups map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal(decoded["matchups"], &matchups))
+	assert.Len(t, matchups, 5)
+	assert.JSONEq(t, `{"rock":0,"paper":0,"scissors":0,"lizard":0,"spock":1}`, string(matchups["lizard"]))
+
+	var back Stats
+	assert.NoError(t, json.Unmarshal(data, &back))
+	assert.Equal(t, s, back)
+}
@@ -0,0 +1,46 @@
+package types
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNoTombstone is returned when there are no cleared records to restore.
+var ErrNoTombstone = errors.New("nothing to restore")
+
+// Tombstone marks the records removed by clearing a scoreboard,
+// they can be restored until it expires.
+type Tombstone struct {
+	ID uint64 `json:"id"`
+	// Owner is the cleared scoreboard, types.Global if everything was cleared.
+	Owner   Owner     `json:"owner,omitempty"`
+	Time    time.Time `json:"time"`
+	Expires time.Time `json:"expires"`
+	// Records is the number of removed records.
+	Records int `json:"records"`
+}
+
+// Expired returns true if the records can't be restored at the given time anymore.
+func (t Tombstone) Expired(now time.Time) bool {
+	return !now.Before(t.Expires)
+}
+
+// AuditAction is the kind of an audited change.
+type AuditAction string
+
+const (
+	AuditScoresCleared  AuditAction = "scores_cleared"
+	AuditScoresRestored AuditAction = "scores_restored"
+)
+
+// AuditEntry records who changed a scoreboard and when.
+type AuditEntry struct {
+	ID     uint64      `json:"id"`
+	Time   time.Time   `json:"time"`
+	Action AuditAction `json:"action"`
+	// Owner made the change of their own scoreboard, types.Global stands for the legacy API.
+	Owner Owner `json:"owner,omitempty"`
+	// Tombstone of the cleared or restored records.
+	Tombstone uint64 `json:"tombstone"`
+	Records   int    `json:"records"`
+}